@@ -120,6 +120,10 @@ if [ "$CI_JOB_IMAGE" == "null" ]; then
     CI_JOB_IMAGE=$(yq eval ".jobs.$GITHUB_JOB.container" "${GITHUB_WORKSPACE}/.github/workflows/$WORKFLOW_FILE_NAME")
 fi
 
+# Get the Service Containers of the Job
+export CI_SERVICES=""
+CI_SERVICES=$(yq eval -o=json -I=0 ".jobs.$GITHUB_JOB.services" "${GITHUB_WORKSPACE}/.github/workflows/$WORKFLOW_FILE_NAME")
+
 # Generate the jwt token
 export ITERATION
 ITERATION=$(cat "${currentDir}/certs/iteration.txt")
@@ -31,20 +31,18 @@ if [ "$MFA_REQUIRED" == true ] && [ "$CONTAINS_SCRIPT" == true ]; then
     # shellcheck disable=SC1003
     RUNNER_SCRIPT=$(echo "$RUNNER_SCRIPT" | tr -d '\\')
 
-    # Find the scripts
-    SCRIPTS=$(echo "$RUNNER_SCRIPT" | grep -oE "(./|/|[a-zA-Z0-9_-])([a-zA-Z0-9_-]*/){0,}.{0,1}[a-zA-Z0-9_-]*\.(sh|py|ps1|rb|js)")
-
-    # Expand the scripts
-    for script in $SCRIPTS; do
-        script=$(echo "$script" | tr -d '[:space:]')
-        if [[ -f $script ]]; then
-            ADD_SCRIPT='x1b[32;1m'
-            ADD_SCRIPT="${ADD_SCRIPT}${script}"
-            ADD_SCRIPT="${ADD_SCRIPT}"'x1b[0;m'
-            ADD_SCRIPT="${ADD_SCRIPT}"'x1b[32;1m'$(tr '\n' ';' < "${script}" )'x1b[0;m'
-            RUNNER_SCRIPT="${RUNNER_SCRIPT}${ADD_SCRIPT}"
-        fi
-    done
+    # The sources are checked out in the container, copy them for the job script (whichever
+    # stage runs it, i.e. step_script or build_script) so that the scripts it references (i.e.
+    # ./deploy.sh) can be hashed by the script hash check. Without a copy the project directory
+    # is left empty, which fails the check if the script references files instead of skipping them.
+    export CI_PROJECT_DIR=""
+    PROJECT_COPY=$(mktemp -d)
+    trap 'rm -rf "$PROJECT_COPY"' EXIT
+    if docker cp "$CONTAINER_ID:$CUSTOM_ENV_CI_PROJECT_DIR/." "$PROJECT_COPY" >/dev/null; then
+        CI_PROJECT_DIR="$PROJECT_COPY"
+    else
+        echo "Could Not Copy the Project Sources from the Job Container"
+    fi
 
     # As of writing this, the runner script gives the CI_JOB_NAME environment variable right before
     # CI_JOB_STAGE.
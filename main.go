@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/kudelskisecurity/youshallnotpass/pkg/cmd/scriptsourcecmd"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/cmd/validatetokencmd"
 	"github.com/urfave/cli/v2"
 )
@@ -54,6 +55,7 @@ func commands() []*cli.Command {
 	}
 
 	cmds = append(cmds, validatetokencmd.Commands()...)
+	cmds = append(cmds, scriptsourcecmd.Commands()...)
 
 	return cmds
 }
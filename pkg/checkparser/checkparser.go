@@ -2,19 +2,38 @@ package checkparser
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
-	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/datetime"
-	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/imagehash"
-	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/mfarequired"
-	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/scripthash"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+
+	// Register the built-in checks
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/actionpinning"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/commitsignature"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/composite"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/containerruntime"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/datetime"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/freezewindow"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/imagehash"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/imagesignature"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/imagesource"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/mfarequired"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/mrapprovals"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/refrestriction"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/runnerconstraint"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/scripthash"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/scriptlint"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/secretscan"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/triggersource"
+	_ "github.com/kudelskisecurity/youshallnotpass/pkg/checks/userallowlist"
 )
 
 var ErrUnknownCheckNameError = errors.New("unknown check name")
 
-func ParseChecks(configs []config.CheckConfig, jobName string, image string, scriptLines []string, checkType string, ciPlatform string) ([]checks.Check, error) {
+// ParseChecks resolves every check configuration through the check registry and returns
+// the checks which are valid for the given check type and the job's CI platform.
+func ParseChecks(configs []config.CheckConfig, job checks.JobInfo, checkType string) ([]checks.Check, error) {
 	var stage uint
 	if checkType == "image" {
 		stage = checks.ImageCheck
@@ -23,32 +42,23 @@ func ParseChecks(configs []config.CheckConfig, jobName string, image string, scr
 	} else {
 		stage = checks.All
 	}
+	job.CheckType = stage
 
 	var performChecks []checks.Check
 	for _, config := range configs {
-		switch strings.ToLower(config.Name) {
-		case "scripthash":
-			scriptHashCheck := scripthash.NewScriptHashCheck(config, jobName, scriptLines)
-			if scriptHashCheck.IsValidForPlatform(ciPlatform) && scriptHashCheck.IsValidForCheckType(stage) {
-				performChecks = append(performChecks, &scriptHashCheck)
-			}
-		case "imagehash":
-			imageHashCheck := imagehash.NewImageHashCheck(config, jobName, image)
-			if imageHashCheck.IsValidForPlatform(ciPlatform) && imageHashCheck.IsValidForCheckType(stage) {
-				performChecks = append(performChecks, &imageHashCheck)
-			}
-		case "mfarequired":
-			mfaRequiredCheck := mfarequired.NewMfaRequiredCheck(config, jobName)
-			if mfaRequiredCheck.IsValidForPlatform(ciPlatform) && mfaRequiredCheck.IsValidForCheckType(stage) {
-				performChecks = append(performChecks, &mfaRequiredCheck)
-			}
-		case "datetimecheck":
-			dateTimeCheck := datetime.NewDateTimeCheck(config, jobName)
-			if dateTimeCheck.IsValidForPlatform(ciPlatform) && dateTimeCheck.IsValidForCheckType(stage) {
-				performChecks = append(performChecks, &dateTimeCheck)
-			}
-		default:
-			return performChecks, ErrUnknownCheckNameError
+		registration, exists := checks.Lookup(config.Name)
+		if !exists {
+			return performChecks, fmt.Errorf("%w %q (registered checks: %s)", ErrUnknownCheckNameError, config.Name, strings.Join(checks.RegisteredNames(), ", "))
+		}
+
+		err := registration.ValidateOptions(config.Options)
+		if err != nil {
+			return performChecks, err
+		}
+
+		check := registration.Constructor(config, job)
+		if check.IsValidForPlatform(job.CiPlatform) && check.IsValidForCheckType(stage) {
+			performChecks = append(performChecks, check)
 		}
 	}
 
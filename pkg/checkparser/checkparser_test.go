@@ -1,14 +1,30 @@
 package checkparser
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/actionpinning"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/commitsignature"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/composite"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/containerruntime"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/datetime"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/freezewindow"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/imagehash"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/imagesignature"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/imagesource"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/mfarequired"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/mrapprovals"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/refrestriction"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/runnerconstraint"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/scripthash"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/scriptlint"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/secretscan"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/triggersource"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/userallowlist"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
 )
 
@@ -113,6 +129,334 @@ func TestParseChecks(t *testing.T) {
 				&datetime.DateTimeCheck{},
 			},
 		},
+		{
+			name: "test parse valid ref restriction check",
+			configs: []config.CheckConfig{
+				{
+					Name: "refRestriction",
+					Options: map[string]interface{}{
+						"allowedBranches": []interface{}{"main"},
+					},
+				},
+			},
+			checkType:     "image",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&refrestriction.RefRestrictionCheck{},
+			},
+		},
+		{
+			name: "test parse valid user allowlist check",
+			configs: []config.CheckConfig{
+				{
+					Name: "userAllowlist",
+					Options: map[string]interface{}{
+						"groups": []interface{}{"release-managers"},
+					},
+				},
+			},
+			checkType:     "script",
+			ciPlatform:    "github",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&userallowlist.UserAllowlistCheck{},
+			},
+		},
+		{
+			name: "test parse invalid image source check",
+			configs: []config.CheckConfig{
+				{
+					Name: "imageSource",
+					Options: map[string]interface{}{
+						"deny": []interface{}{"docker.io/**"},
+					},
+				},
+			},
+			checkType:      "script",
+			ciPlatform:     "gitlab",
+			expectedError:  nil,
+			expectedChecks: []checks.Check{},
+		},
+		{
+			name: "test parse valid image source check",
+			configs: []config.CheckConfig{
+				{
+					Name: "imageSource",
+					Options: map[string]interface{}{
+						"deny": []interface{}{"docker.io/**"},
+					},
+				},
+			},
+			checkType:     "image",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&imagesource.ImageSourceCheck{},
+			},
+		},
+		{
+			name: "test parse valid script lint check",
+			configs: []config.CheckConfig{
+				{
+					Name: "scriptLint",
+					Options: map[string]interface{}{
+						"rules": []interface{}{`\\bsudo\\b`},
+					},
+				},
+			},
+			checkType:     "script",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&scriptlint.ScriptLintCheck{},
+			},
+		},
+		{
+			name: "test parse valid commit signature check",
+			configs: []config.CheckConfig{
+				{
+					Name: "commitSignature",
+					Options: map[string]interface{}{
+						"signers": []interface{}{"alice@example.com"},
+					},
+				},
+			},
+			checkType:     "script",
+			ciPlatform:    "github",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&commitsignature.CommitSignatureCheck{},
+			},
+		},
+		{
+			name: "test parse valid trigger source check",
+			configs: []config.CheckConfig{
+				{
+					Name: "triggerSource",
+					Options: map[string]interface{}{
+						"allowedSources": []interface{}{"schedule"},
+						"mfaSources":     []interface{}{"web"},
+					},
+				},
+			},
+			checkType:     "all",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&triggersource.TriggerSourceCheck{},
+			},
+		},
+		{
+			name: "test parse valid freeze window check",
+			configs: []config.CheckConfig{
+				{
+					Name: "freezeWindow",
+					Options: map[string]interface{}{
+						"mfaOnFail": true,
+					},
+				},
+			},
+			checkType:     "all",
+			ciPlatform:    "github",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&freezewindow.FreezeWindowCheck{},
+			},
+		},
+		{
+			name: "test parse invalid image signature check",
+			configs: []config.CheckConfig{
+				{
+					Name: "imageSignature",
+				},
+			},
+			checkType:      "script",
+			ciPlatform:     "gitlab",
+			expectedError:  nil,
+			expectedChecks: []checks.Check{},
+		},
+		{
+			name: "test parse valid image signature check",
+			configs: []config.CheckConfig{
+				{
+					Name: "imageSignature",
+					Options: map[string]interface{}{
+						"keys":        []interface{}{"build-pipeline"},
+						"registryUrl": "http://localhost:5000",
+					},
+				},
+			},
+			checkType:     "image",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&imagesignature.ImageSignatureCheck{},
+			},
+		},
+		{
+			name: "test parse valid composite check",
+			configs: []config.CheckConfig{
+				{
+					Name: "composite",
+					Options: map[string]interface{}{
+						"anyOf": []interface{}{
+							map[string]interface{}{"name": "scriptHash"},
+							map[string]interface{}{"name": "userAllowlist", "options": map[string]interface{}{"users": []interface{}{"alice"}}},
+						},
+					},
+				},
+			},
+			checkType:     "script",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&composite.CompositeCheck{},
+			},
+		},
+		{
+			name: "test parse composite check of another check type",
+			configs: []config.CheckConfig{
+				{
+					Name: "composite",
+					Options: map[string]interface{}{
+						"not": map[string]interface{}{"name": "scriptHash"},
+					},
+				},
+			},
+			checkType:      "image",
+			ciPlatform:     "gitlab",
+			expectedError:  nil,
+			expectedChecks: []checks.Check{},
+		},
+		{
+			name: "test parse valid actionPinning check",
+			configs: []config.CheckConfig{
+				{
+					Name: "actionPinning",
+					Options: map[string]interface{}{
+						"requireAllowlist": true,
+					},
+				},
+			},
+			checkType:     "script",
+			ciPlatform:    "github",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&actionpinning.ActionPinningCheck{},
+			},
+		},
+		{
+			name: "test parse actionPinning check on gitlab",
+			configs: []config.CheckConfig{
+				{
+					Name: "actionPinning",
+				},
+			},
+			checkType:      "script",
+			ciPlatform:     "gitlab",
+			expectedError:  nil,
+			expectedChecks: []checks.Check{},
+		},
+		{
+			name: "test parse valid secretScan check",
+			configs: []config.CheckConfig{
+				{
+					Name: "secretScan",
+					Options: map[string]interface{}{
+						"rules":      []interface{}{"glpat-[0-9A-Za-z_-]{20}"},
+						"minEntropy": 4.0,
+					},
+				},
+			},
+			checkType:     "script",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&secretscan.SecretScanCheck{},
+			},
+		},
+		{
+			name: "test parse valid mrApprovals check",
+			configs: []config.CheckConfig{
+				{
+					Name: "mrApprovals",
+					Options: map[string]interface{}{
+						"minApprovals": float64(2),
+						"groups":       []interface{}{"team/release-managers"},
+					},
+				},
+			},
+			checkType:     "image",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&mrapprovals.MrApprovalsCheck{},
+			},
+		},
+		{
+			name: "test parse valid runnerConstraint check",
+			configs: []config.CheckConfig{
+				{
+					Name: "runnerConstraint",
+					Options: map[string]interface{}{
+						"requiredTags": []interface{}{"prod"},
+					},
+				},
+			},
+			checkType:     "image",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&runnerconstraint.RunnerConstraintCheck{},
+			},
+		},
+		{
+			name: "test parse valid containerRuntime check",
+			configs: []config.CheckConfig{
+				{
+					Name: "containerRuntime",
+					Options: map[string]interface{}{
+						"allowedCapabilities": []interface{}{"NET_ADMIN"},
+					},
+				},
+			},
+			checkType:     "image",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&containerruntime.ContainerRuntimeCheck{},
+			},
+		},
+		{
+			name: "test parse check name is case insensitive",
+			configs: []config.CheckConfig{
+				{
+					Name: "SCRIPTHASH",
+				},
+			},
+			checkType:     "all",
+			ciPlatform:    "gitlab",
+			expectedError: nil,
+			expectedChecks: []checks.Check{
+				&scripthash.ScriptHashCheck{},
+			},
+		},
+		{
+			name: "test parse check with invalid option type",
+			configs: []config.CheckConfig{
+				{
+					Name: "imageHash",
+					Options: map[string]interface{}{
+						"abortOnFail": "yes",
+					},
+				},
+			},
+			checkType:      "all",
+			ciPlatform:     "gitlab",
+			expectedError:  checks.ErrInvalidCheckOption,
+			expectedChecks: []checks.Check{},
+		},
 		{
 			name: "test invalid test name",
 			configs: []config.CheckConfig{
@@ -128,9 +472,10 @@ func TestParseChecks(t *testing.T) {
 	}
 
 	for _, test := range parseChecksTests {
-		checks, err := ParseChecks(test.configs, "testJob", "", []string{""}, test.checkType, test.ciPlatform)
+		job := checks.JobInfo{JobName: "testJob", ScriptLines: []string{""}, CiPlatform: test.ciPlatform}
+		checks, err := ParseChecks(test.configs, job, test.checkType)
 
-		if err != test.expectedError {
+		if !errors.Is(err, test.expectedError) {
 			t.Errorf("Unexpected error (%+v) in test: %s", err, test.name)
 		}
 
@@ -145,3 +490,22 @@ func TestParseChecks(t *testing.T) {
 		}
 	}
 }
+
+func TestParseChecksUnknownNameListsRegisteredChecks(t *testing.T) {
+	configs := []config.CheckConfig{
+		{
+			Name: "invalidCheck",
+		},
+	}
+
+	_, err := ParseChecks(configs, checks.JobInfo{JobName: "testJob", CiPlatform: "gitlab"}, "all")
+	if !errors.Is(err, ErrUnknownCheckNameError) {
+		t.Fatalf("unexpected error - expected: (%+v) - got: (%+v)", ErrUnknownCheckNameError, err)
+	}
+
+	for _, name := range []string{"scriptHash", "imageHash", "mfaRequired", "dateTimeCheck"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected registered check %s in error message - got: %s", name, err.Error())
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package checkrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	CheckRunnerError   = "---ERROR---"
+	ErrCheckPanic      = errors.New("check panicked")
+	ErrNoResult        = errors.New("check did not report a result")
+	ErrMultipleResults = errors.New("check reported more than one result")
+	// deadlineGrace is how long the checks have to report their result once the context is done.
+	deadlineGrace = 5 * time.Second
+)
+
+// run is a check running in its own goroutine.
+type run struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	check      checks.Check
+	results    []checks.CheckResult
+	panicValue interface{}
+	// done is closed once the check returned and its results were collected.
+	done chan struct{}
+	// expired is closed if the check has not returned shortly after its context is done.
+	expired chan struct{}
+}
+
+// Run runs the checks concurrently and returns exactly one result per check, in the order of
+// the checks (the order of the configuration). Each check runs with its own context, done once
+// checkTimeout elapsed (if not zero) or with ctx. A check which panics, reports no or several
+// results or has not returned shortly after its context is done gets an error result instead,
+// with the outcome of a failure of the check.
+func Run(ctx context.Context, jobChecks []checks.Check, w whitelist.Whitelist, checkTimeout time.Duration) []checks.CheckResult {
+	runs := make([]*run, len(jobChecks))
+	for i, check := range jobChecks {
+		runs[i] = start(ctx, check, w, checkTimeout)
+	}
+
+	results := make([]checks.CheckResult, len(runs))
+	for i, r := range runs {
+		select {
+		case <-r.done:
+		case <-r.expired:
+		}
+
+		// a check which returned in time keeps its result even once the grace period expired
+		select {
+		case <-r.done:
+			results[i] = r.result()
+		default:
+			results[i] = errorResult(r.check, "", checks.ContextError(r.ctx, ErrNoResult))
+		}
+		r.cancel()
+	}
+
+	return results
+}
+
+// start runs the check in a goroutine recovering its panics and collects the results it sends
+// until it returns. The wait group given to the check is not relied upon, so a check which
+// forgets to call Done does not block the run.
+func start(ctx context.Context, check checks.Check, w whitelist.Whitelist, checkTimeout time.Duration) *run {
+	ctx, cancel := checks.WithTimeout(ctx, checkTimeout)
+	r := &run{ctx: ctx, cancel: cancel, check: check, done: make(chan struct{}), expired: make(chan struct{})}
+
+	channel := make(chan checks.CheckResult)
+	returned := make(chan struct{})
+
+	go func() {
+		defer close(returned)
+		defer func() {
+			if value := recover(); value != nil {
+				r.panicValue = value
+			}
+		}()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		check.Check(ctx, channel, &wg, w)
+	}()
+
+	go func() {
+		defer close(r.done)
+
+		// the channel is unbuffered, every result sent by the check is received before it returns
+		for {
+			select {
+			case result := <-channel:
+				r.results = append(r.results, result)
+			case <-returned:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		select {
+		case <-r.done:
+			return
+		case <-ctx.Done():
+		}
+
+		timer := time.NewTimer(deadlineGrace)
+		defer timer.Stop()
+
+		select {
+		case <-r.done:
+		case <-timer.C:
+			close(r.expired)
+		}
+	}()
+
+	return r
+}
+
+// result returns the single result of the check or an error result.
+func (r *run) result() checks.CheckResult {
+	name := ""
+	if len(r.results) != 0 {
+		name = r.results[0].Name
+	}
+
+	switch {
+	case r.panicValue != nil:
+		return errorResult(r.check, name, fmt.Errorf("%w: %v", ErrCheckPanic, r.panicValue))
+	case len(r.results) == 0:
+		return errorResult(r.check, name, checks.ContextError(r.ctx, ErrNoResult))
+	case len(r.results) > 1:
+		return errorResult(r.check, name, fmt.Errorf("%w (%d results)", ErrMultipleResults, len(r.results)))
+	default:
+		return r.results[0]
+	}
+}
+
+// errorResult returns the error result of a misbehaving check, named after the type of the
+// check if it did not report a result. The check's abortOnFail and mfaOnFail options decide its
+// outcome, like for the errors the check reports itself.
+func errorResult(check checks.Check, name string, err error) checks.CheckResult {
+	if len(name) == 0 {
+		checkType := reflect.TypeOf(check)
+		for checkType.Kind() == reflect.Pointer {
+			checkType = checkType.Elem()
+		}
+		name = checkType.Name()
+	}
+
+	result := checks.CheckResult{Name: name, Error: err, Details: CheckRunnerError}
+	result.Fail(check.FailOptions())
+	return result
+}
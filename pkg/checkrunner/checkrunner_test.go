@@ -0,0 +1,150 @@
+package checkrunner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+// runnerTestCheck behaves as told by its fields, from a well behaved check to one which
+// panics, hangs or reports several results.
+type runnerTestCheck struct {
+	name      string
+	delay     time.Duration
+	results   int
+	panics    bool
+	hangs     bool
+	skipWg    bool
+	mfaOnFail bool
+}
+
+func (check *runnerTestCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	if !check.skipWg {
+		defer wg.Done()
+	}
+
+	time.Sleep(check.delay)
+
+	if check.panics {
+		var images []string
+		_ = images[len(w.AllowedImages)]
+	}
+
+	if check.hangs {
+		select {}
+	}
+
+	for i := 0; i < check.results; i++ {
+		channel <- checks.CheckResult{Name: check.name, Version: "1.0.0", Details: "passed"}
+	}
+}
+
+func (check *runnerTestCheck) IsValidForCheckType(checkType uint) bool {
+	return true
+}
+
+func (check *runnerTestCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *runnerTestCheck) FailOptions() (bool, bool) {
+	return !check.mfaOnFail, check.mfaOnFail
+}
+
+func TestRun(t *testing.T) {
+	jobChecks := []checks.Check{
+		&runnerTestCheck{name: "Slow Check", delay: 20 * time.Millisecond, results: 1},
+		&runnerTestCheck{name: "Fast Check", results: 1},
+		&runnerTestCheck{name: "Panicking Check", panics: true},
+		&runnerTestCheck{name: "Silent Check", mfaOnFail: true},
+		&runnerTestCheck{name: "Chatty Check", results: 2},
+		&runnerTestCheck{name: "Forgetful Check", results: 1, skipWg: true},
+	}
+
+	expected := []struct {
+		name    string
+		error   error
+		outcome checks.Outcome
+	}{
+		{name: "Slow Check", error: nil, outcome: checks.OutcomePass},
+		{name: "Fast Check", error: nil, outcome: checks.OutcomePass},
+		{name: "runnerTestCheck", error: ErrCheckPanic, outcome: checks.OutcomeAbort},
+		{name: "runnerTestCheck", error: ErrNoResult, outcome: checks.OutcomeMfa},
+		{name: "Chatty Check", error: ErrMultipleResults, outcome: checks.OutcomeAbort},
+		{name: "Forgetful Check", error: nil, outcome: checks.OutcomePass},
+	}
+
+	results := Run(context.Background(), jobChecks, whitelist.Whitelist{}, 0)
+
+	if len(results) != len(expected) {
+		t.Fatalf("unexpected number of results -\nexpected: (%d)\ngot: (%d)", len(expected), len(results))
+	}
+
+	for i, result := range results {
+		if result.Name != expected[i].name || !errors.Is(result.Error, expected[i].error) {
+			t.Errorf("\n%d) unexpected result -\nexpected: (%s, %+v)\ngot: (%+v)", i, expected[i].name, expected[i].error, result)
+		}
+
+		if result.Outcome != expected[i].outcome {
+			t.Errorf("\n%d) unexpected outcome -\nexpected: (%s)\ngot: (%s)", i, expected[i].outcome, result.Outcome)
+		}
+	}
+}
+
+func TestRunDeadline(t *testing.T) {
+	defaultGrace := deadlineGrace
+	deadlineGrace = 10 * time.Millisecond
+	defer func() { deadlineGrace = defaultGrace }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	jobChecks := []checks.Check{
+		&runnerTestCheck{name: "Hanging Check", hangs: true},
+		&runnerTestCheck{name: "Fast Check", results: 1},
+	}
+
+	results := Run(ctx, jobChecks, whitelist.Whitelist{}, 0)
+
+	if len(results) != 2 {
+		t.Fatalf("unexpected number of results -\nexpected: (2)\ngot: (%d)", len(results))
+	}
+
+	if !errors.Is(results[0].Error, checks.ErrCheckTimeout) || !errors.Is(results[0].Error, ErrNoResult) || results[0].Outcome != checks.OutcomeAbort {
+		t.Errorf("unexpected result for the hanging check -\nexpected: (%+v)\ngot: (%+v)", checks.ErrCheckTimeout, results[0])
+	}
+
+	if results[1].Name != "Fast Check" || results[1].Error != nil {
+		t.Errorf("unexpected result for the fast check: (%+v)", results[1])
+	}
+}
+
+func TestRunCheckTimeout(t *testing.T) {
+	defaultGrace := deadlineGrace
+	deadlineGrace = 10 * time.Millisecond
+	defer func() { deadlineGrace = defaultGrace }()
+
+	jobChecks := []checks.Check{
+		&runnerTestCheck{name: "Hanging Check", hangs: true, mfaOnFail: true},
+		&runnerTestCheck{name: "Fast Check", results: 1},
+	}
+
+	results := Run(context.Background(), jobChecks, whitelist.Whitelist{}, 10*time.Millisecond)
+
+	if len(results) != 2 {
+		t.Fatalf("unexpected number of results -\nexpected: (2)\ngot: (%d)", len(results))
+	}
+
+	if !errors.Is(results[0].Error, checks.ErrCheckTimeout) || !errors.Is(results[0].Error, context.DeadlineExceeded) || results[0].Outcome != checks.OutcomeMfa {
+		t.Errorf("unexpected result for the hanging check -\nexpected: (%+v)\ngot: (%+v)", checks.ErrCheckTimeout, results[0])
+	}
+
+	if results[1].Name != "Fast Check" || results[1].Error != nil {
+		t.Errorf("unexpected result for the fast check: (%+v)", results[1])
+	}
+}
@@ -0,0 +1,251 @@
+package actionpinning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/glob"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	ActionPinningCheckName       = "Action Pinning Check"
+	ActionPinningCheckVersion    = "1.0.0"
+	ActionPinningCheckSuccess    = "All Actions Are Pinned"
+	ActionPinningCheckNoActions  = "No Actions Used"
+	ActionPinningCheckFound      = "Action Violations Found:"
+	ActionPinningCheckNotPinned  = "\n - step %d: %s is not pinned to a full commit SHA"
+	ActionPinningCheckNotAllowed = "\n - step %d: %s is not on the actions allowlist"
+	ActionPinningCheckError      = "---ERROR---"
+	ErrNoActionVault             = errors.New("no vault available to read the actions allowlist")
+	actionAllowlistMount         = "actions"
+	usesPattern                  = regexp.MustCompile(`(?m)(?:^\s*(?:-\s+)?|[{,]\s*)uses:\s*["']?([^"'\s#,}]+)`)
+	commitShaPattern             = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+	imageDigestPattern           = regexp.MustCompile(`@sha256:[0-9a-fA-F]{64}$`)
+	localActionPrefix            = "./"
+	dockerActionPrefix           = "docker://"
+)
+
+func init() {
+	checks.Register("actionPinning", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewActionPinningCheck(config, job.JobName, job.ScriptLines, job.Vault, job.NamespaceMount, job.ProjectMount)
+		return &check
+	},
+		checks.Option{Name: "requireAllowlist", Type: checks.BoolOption},
+		checks.Option{Name: "actions", Type: checks.ListOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+// Actions is the document stored in the vault at {root}/{namespace}/actions and
+// {root}/{namespace}/{project}/actions. The actions are glob patterns of owner/repo.
+//
+// Example:
+//
+//	{
+//		"actions": ["actions/checkout", "docker/*"]
+//	}
+type Actions struct {
+	Actions []string `json:"actions"`
+}
+
+// ActionReference is an action referenced by the uses keyword of a GitHub step.
+type ActionReference struct {
+	// Uses is the reference as written in the step (i.e. actions/checkout@v3).
+	Uses string
+	// Name is what the allowlist is matched against: owner/repo for repository actions and
+	// docker://image for docker actions.
+	Name string
+	// Ref is the git ref or the image tag or digest the action is pinned to.
+	Ref string
+	// Step is the number of the step (starting with 1).
+	Step int
+}
+
+type ActionPinningCheck struct {
+	jobName          string
+	scriptLines      []string
+	requireAllowlist bool
+	actions          []string
+	abortOnFail      bool
+	mfaOnFail        bool
+	vault            checks.SecretReader
+	namespaceMount   string
+	projectMount     string
+}
+
+func NewActionPinningCheck(config config.CheckConfig, jobName string, scriptLines []string, vault checks.SecretReader, namespaceMount string, projectMount string) ActionPinningCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return ActionPinningCheck{
+		jobName:          jobName,
+		scriptLines:      scriptLines,
+		requireAllowlist: checks.GetBoolOption(config.Options, "requireAllowlist", false),
+		actions:          checks.GetStringListOption(config.Options, "actions"),
+		abortOnFail:      abortOnFail,
+		mfaOnFail:        mfaOnFail,
+		vault:            vault,
+		namespaceMount:   namespaceMount,
+		projectMount:     projectMount,
+	}
+}
+
+func (check *ActionPinningCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: ActionPinningCheckName, Version: ActionPinningCheckVersion}
+
+	references := ParseActionReferences(check.scriptLines)
+	if len(references) == 0 {
+		result.Details = ActionPinningCheckNoActions
+		channel <- result
+		return
+	}
+
+	var allowlist []string
+	if check.requireAllowlist {
+		actions, err := check.readActions(ctx)
+		if err != nil {
+			result.Error = checks.ContextError(ctx, err)
+			result.Details = ActionPinningCheckError
+			result.Fail(check.abortOnFail, check.mfaOnFail)
+			channel <- result
+			return
+		}
+
+		allowlist = append(append(allowlist, check.actions...), actions.Actions...)
+	}
+
+	var findings []string
+	for _, reference := range references {
+		if !reference.IsPinned() {
+			findings = append(findings, fmt.Sprintf(ActionPinningCheckNotPinned, reference.Step, reference.Uses))
+		}
+
+		if check.requireAllowlist && !glob.MatchAny(allowlist, reference.Name) {
+			findings = append(findings, fmt.Sprintf(ActionPinningCheckNotAllowed, reference.Step, reference.Uses))
+		}
+	}
+
+	if len(findings) == 0 {
+		result.Details = ActionPinningCheckSuccess
+	} else {
+		result.Details = ActionPinningCheckFound + strings.Join(findings, "")
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+// ParseActionReferences returns the actions referenced by the uses keyword of the steps, as
+// extracted by the GitHubCleaner, in block or flow style (i.e. "- {uses: actions/checkout@v4}").
+// Local actions (./path) are part of the repository and are not returned.
+func ParseActionReferences(scriptLines []string) []ActionReference {
+	var references []ActionReference
+	for step, scriptLine := range scriptLines {
+		for _, match := range usesPattern.FindAllStringSubmatch(scriptLine, -1) {
+			uses := match[1]
+			if strings.HasPrefix(uses, localActionPrefix) {
+				continue
+			}
+
+			references = append(references, parseActionReference(uses, step+1))
+		}
+	}
+
+	return references
+}
+
+func parseActionReference(uses string, step int) ActionReference {
+	reference := ActionReference{Uses: uses, Step: step}
+
+	if strings.HasPrefix(uses, dockerActionPrefix) {
+		name, digest, found := strings.Cut(strings.TrimPrefix(uses, dockerActionPrefix), "@")
+		if found {
+			reference.Ref = "@" + digest
+		} else if tag := strings.LastIndex(name, ":"); tag > strings.LastIndex(name, "/") {
+			reference.Ref = name[tag+1:]
+			name = name[:tag]
+		}
+
+		reference.Name = dockerActionPrefix + name
+		return reference
+	}
+
+	path, ref, _ := strings.Cut(uses, "@")
+	reference.Ref = ref
+
+	// owner/repo/path@ref refers to an action in a subdirectory of owner/repo
+	segments := strings.SplitN(path, "/", 3)
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+	reference.Name = strings.Join(segments, "/")
+
+	return reference
+}
+
+// IsPinned returns whether the action is pinned to a full commit SHA (or to an image digest
+// for docker actions).
+func (reference ActionReference) IsPinned() bool {
+	if strings.HasPrefix(reference.Name, dockerActionPrefix) {
+		return imageDigestPattern.MatchString(reference.Ref)
+	}
+
+	return commitShaPattern.MatchString(reference.Ref)
+}
+
+func (check *ActionPinningCheck) readActions(ctx context.Context) (Actions, error) {
+	actions := Actions{}
+
+	if check.vault == nil {
+		return actions, ErrNoActionVault
+	}
+
+	for _, mount := range []string{check.namespaceMount, check.projectMount} {
+		vaultRes, err := check.vault.ReadSecret(ctx, mount+"/"+actionAllowlistMount)
+		if err != nil {
+			return actions, err
+		}
+
+		if len(vaultRes) == 0 {
+			continue
+		}
+
+		var mountActions Actions
+		err = json.Unmarshal(vaultRes, &mountActions)
+		if err != nil {
+			return actions, fmt.Errorf("unable to parse actions at %s: %s", mount+"/"+actionAllowlistMount, err.Error())
+		}
+
+		actions.Actions = append(actions.Actions, mountActions.Actions...)
+	}
+
+	return actions, nil
+}
+
+func (check *ActionPinningCheck) IsValidForCheckType(checkType uint) bool {
+	switch checkType {
+	case checks.All:
+		return true
+	default:
+		return checkType == checks.ScriptCheck
+	}
+}
+
+func (check *ActionPinningCheck) IsValidForPlatform(ciPlatform string) bool {
+	return strings.EqualFold(ciPlatform, "github")
+}
+
+func (check *ActionPinningCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
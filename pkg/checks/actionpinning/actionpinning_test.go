@@ -0,0 +1,308 @@
+package actionpinning
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	errTestVault = errors.New("vault unavailable")
+	checkoutSha  = "8e5e7e5ab8b370d6c329ec480221332ada57f0ab"
+	imageDigest  = "sha256:4d5e29bfa3d2a9f1f2f6e9e1a1e3fd2ca7f0f0c1c5a7b2f0e1d8a3b6c9f2e4d1"
+)
+
+type mockVault struct {
+	secrets map[string]string
+	err     error
+}
+
+func (v *mockVault) ReadSecret(ctx context.Context, mount string) ([]byte, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	secret, exists := v.secrets[mount]
+	if !exists {
+		return nil, nil
+	}
+
+	return []byte(secret), nil
+}
+
+func TestNewActionPinningCheck(t *testing.T) {
+	vault := &mockVault{}
+	newActionPinningCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck ActionPinningCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "actionPinning",
+			},
+			expectedCheck: ActionPinningCheck{
+				jobName:        "testJob",
+				scriptLines:    []string{"uses: actions/checkout@v3"},
+				abortOnFail:    true,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+				projectMount:   "cicd/namespace/project",
+			},
+		},
+		{
+			name: "allowlist and mfaOnFail",
+			config: config.CheckConfig{
+				Name: "actionPinning",
+				Options: map[string]interface{}{
+					"requireAllowlist": true,
+					"actions":          []interface{}{"actions/*"},
+					"mfaOnFail":        true,
+				},
+			},
+			expectedCheck: ActionPinningCheck{
+				jobName:          "testJob",
+				scriptLines:      []string{"uses: actions/checkout@v3"},
+				requireAllowlist: true,
+				actions:          []string{"actions/*"},
+				mfaOnFail:        true,
+				vault:            vault,
+				namespaceMount:   "cicd/namespace",
+				projectMount:     "cicd/namespace/project",
+			},
+		},
+	}
+
+	for testNum, test := range newActionPinningCheckTests {
+		check := NewActionPinningCheck(test.config, "testJob", []string{"uses: actions/checkout@v3"}, vault, "cicd/namespace", "cicd/namespace/project")
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestParseActionReferences(t *testing.T) {
+	scriptLines := []string{
+		"name: Check out repository\n  uses: actions/checkout@" + checkoutSha + " # v4",
+		`run: echo "uses: not/an-action@v1"`,
+		"uses: 'github/codeql-action/init@v2'",
+		"uses: ./.github/actions/build",
+		"uses: docker://alpine:3.18",
+		"uses: docker://registry.internal:5000/team/tool@" + imageDigest,
+		"uses: octo-org/unversioned",
+		"- {uses: actions/setup-go@v4}",
+		`{name: Deploy, uses: "octo-org/deploy@v1", with: {env: prod}}`,
+	}
+
+	expectedReferences := []ActionReference{
+		{Uses: "actions/checkout@" + checkoutSha, Name: "actions/checkout", Ref: checkoutSha, Step: 1},
+		{Uses: "github/codeql-action/init@v2", Name: "github/codeql-action", Ref: "v2", Step: 3},
+		{Uses: "docker://alpine:3.18", Name: "docker://alpine", Ref: "3.18", Step: 5},
+		{Uses: "docker://registry.internal:5000/team/tool@" + imageDigest, Name: "docker://registry.internal:5000/team/tool", Ref: "@" + imageDigest, Step: 6},
+		{Uses: "octo-org/unversioned", Name: "octo-org/unversioned", Ref: "", Step: 7},
+		{Uses: "actions/setup-go@v4", Name: "actions/setup-go", Ref: "v4", Step: 8},
+		{Uses: "octo-org/deploy@v1", Name: "octo-org/deploy", Ref: "v1", Step: 9},
+	}
+
+	references := ParseActionReferences(scriptLines)
+	if !reflect.DeepEqual(references, expectedReferences) {
+		t.Errorf("references not equal -\nexpected: (%+v)\ngot: (%+v)", expectedReferences, references)
+	}
+
+	expectedPinned := []bool{true, false, false, true, false, false, false}
+	for i, reference := range references {
+		if reference.IsPinned() != expectedPinned[i] {
+			t.Errorf("\n%d) unexpected pinning for %s -\nexpected: (%t)\ngot: (%t)", i, reference.Uses, expectedPinned[i], reference.IsPinned())
+		}
+	}
+}
+
+func TestActionPinningCheck(t *testing.T) {
+	vault := &mockVault{
+		secrets: map[string]string{
+			"cicd/namespace/actions":         `{"actions": ["actions/*"]}`,
+			"cicd/namespace/project/actions": `{"actions": ["docker://alpine"]}`,
+		},
+	}
+
+	actionPinningCheckTests := []struct {
+		name           string
+		check          ActionPinningCheck
+		expectedResult checks.CheckResult
+	}{
+		{
+			name: "no actions",
+			check: ActionPinningCheck{
+				scriptLines: []string{`run: echo "testing"`},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    ActionPinningCheckName,
+				Version: ActionPinningCheckVersion,
+				Details: ActionPinningCheckNoActions,
+			},
+		},
+		{
+			name: "pinned actions",
+			check: ActionPinningCheck{
+				scriptLines: []string{"uses: actions/checkout@" + checkoutSha, `run: echo "testing"`},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    ActionPinningCheckName,
+				Version: ActionPinningCheckVersion,
+				Details: ActionPinningCheckSuccess,
+			},
+		},
+		{
+			name: "unpinned action - abort",
+			check: ActionPinningCheck{
+				scriptLines: []string{"uses: actions/checkout@" + checkoutSha, "uses: actions/setup-node@v3"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ActionPinningCheckName,
+				Version:  ActionPinningCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Action Violations Found:\n - step 2: actions/setup-node@v3 is not pinned to a full commit SHA",
+			},
+		},
+		{
+			name: "abbreviated sha - mfa",
+			check: ActionPinningCheck{
+				scriptLines: []string{"uses: actions/checkout@" + checkoutSha[:7]},
+				mfaOnFail:   true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ActionPinningCheckName,
+				Version:  ActionPinningCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Action Violations Found:\n - step 1: actions/checkout@8e5e7e5 is not pinned to a full commit SHA",
+			},
+		},
+		{
+			name: "allowlisted actions from the namespace, the project and the options",
+			check: ActionPinningCheck{
+				scriptLines: []string{
+					"uses: actions/checkout@" + checkoutSha,
+					"uses: docker://alpine@" + imageDigest,
+					"uses: octo-org/deploy@" + checkoutSha,
+				},
+				requireAllowlist: true,
+				actions:          []string{"octo-org/*"},
+				abortOnFail:      true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    ActionPinningCheckName,
+				Version: ActionPinningCheckVersion,
+				Details: ActionPinningCheckSuccess,
+			},
+		},
+		{
+			name: "action not on the allowlist",
+			check: ActionPinningCheck{
+				scriptLines:      []string{"uses: actions/checkout@" + checkoutSha, "uses: evil-org/steal-secrets@v1"},
+				requireAllowlist: true,
+				abortOnFail:      true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ActionPinningCheckName,
+				Version:  ActionPinningCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details: "Action Violations Found:" +
+					"\n - step 2: evil-org/steal-secrets@v1 is not pinned to a full commit SHA" +
+					"\n - step 2: evil-org/steal-secrets@v1 is not on the actions allowlist",
+			},
+		},
+	}
+
+	for testNum, test := range actionPinningCheckTests {
+		test.check.vault = vault
+		test.check.namespaceMount = "cicd/namespace"
+		test.check.projectMount = "cicd/namespace/project"
+
+		result := runCheck(&test.check)
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestActionPinningCheckVaultErrors(t *testing.T) {
+	actionPinningCheckVaultErrorTests := []struct {
+		name        string
+		vault       checks.SecretReader
+		expectedErr error
+	}{
+		{
+			name:        "vault read error",
+			vault:       &mockVault{err: errTestVault},
+			expectedErr: errTestVault,
+		},
+		{
+			name:        "no vault",
+			vault:       nil,
+			expectedErr: ErrNoActionVault,
+		},
+	}
+
+	for testNum, test := range actionPinningCheckVaultErrorTests {
+		check := ActionPinningCheck{
+			scriptLines:      []string{"uses: actions/checkout@" + checkoutSha},
+			requireAllowlist: true,
+			abortOnFail:      true,
+			vault:            test.vault,
+			namespaceMount:   "cicd/namespace",
+			projectMount:     "cicd/namespace/project",
+		}
+
+		result := runCheck(&check)
+		expectedResult := checks.CheckResult{
+			Name:     ActionPinningCheckName,
+			Version:  ActionPinningCheckVersion,
+			Error:    test.expectedErr,
+			Outcome:  checks.OutcomeAbort,
+			Severity: checks.SeverityHigh,
+			Details:  ActionPinningCheckError,
+		}
+
+		if !result.CompareCheckResult(expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, expectedResult, result)
+		}
+	}
+}
+
+func TestActionPinningCheckIsValid(t *testing.T) {
+	check := ActionPinningCheck{}
+
+	if !check.IsValidForPlatform("github") || !check.IsValidForPlatform("GitHub") || check.IsValidForPlatform("gitlab") {
+		t.Errorf("the check must only be valid for GitHub")
+	}
+
+	if !check.IsValidForCheckType(checks.ScriptCheck) || !check.IsValidForCheckType(checks.All) || check.IsValidForCheckType(checks.ImageCheck) {
+		t.Errorf("the check must only be valid for script checks")
+	}
+}
+
+func runCheck(check *ActionPinningCheck) checks.CheckResult {
+	var wg sync.WaitGroup
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+	wg.Wait()
+	close(channel)
+
+	return <-channel
+}
@@ -1,6 +1,8 @@
 package checks
 
 import (
+	"context"
+	"fmt"
 	"sync"
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
@@ -12,6 +14,20 @@ const (
 	All
 )
 
+// The common pipeline source vocabulary the platform specific pipeline sources
+// (GitLab CI_PIPELINE_SOURCE, GitHub GITHUB_EVENT_NAME) are mapped to.
+const (
+	SourcePush               = "push"
+	SourceMergeRequest       = "merge_request"
+	SourceMergeRequestTarget = "merge_request_target"
+	SourceSchedule           = "schedule"
+	SourceManual             = "manual"
+	SourceApi                = "api"
+	SourcePipeline           = "pipeline"
+	SourceRelease            = "release"
+	SourceOther              = "other"
+)
+
 // The Check interface should be implemented for all YouShallNotPass checks.
 type Check interface {
 	// Check performs whatever logic is necessary to determine whether an execution is
@@ -23,7 +39,10 @@ type Check interface {
 	// exists in the whitelist.  If it does exist, the check creates a CheckResult indicating the check
 	// was passed and sends the check through the channel.  Once this result is passed through the channel
 	// the wait group is told the check is done.
-	Check(chan<- CheckResult, *sync.WaitGroup, whitelist.Whitelist)
+	//
+	// The context is done when the run's deadline (or the check's own timeout) is reached, checks
+	// which wait on the vault, a registry or git then send an error result.
+	Check(context.Context, chan<- CheckResult, *sync.WaitGroup, whitelist.Whitelist)
 
 	// IsValidForCheckType returns true if a given check is valid for a given check
 	// type (i.e. ImageCheck, ScriptCheck, AllCheck)
@@ -39,15 +58,101 @@ type Check interface {
 	// If there is a check named ScriptLintCheck that is only valid on GitLab, this function
 	// will return true if the ciPlatform == "gitlab"
 	IsValidForPlatform(ciPlatform string) bool
+
+	// FailOptions returns the abortOnFail and mfaOnFail options of the check, which decide the
+	// outcome of the error result given to a check which could not report its own result (i.e.
+	// it panicked or did not return before its timeout).
+	FailOptions() (abortOnFail bool, mfaOnFail bool)
+}
+
+// Outcome is what a check result means for the job. The outcomes are ordered from the least to
+// the most blocking one.
+type Outcome uint
+
+const (
+	// OutcomePass lets the job run.
+	OutcomePass Outcome = iota
+	// OutcomeWarn lets the job run and reports the result as a warning.
+	OutcomeWarn
+	// OutcomeMfa requires user MFA for the job to run.
+	OutcomeMfa
+	// OutcomeAbort fails the job.
+	OutcomeAbort
+	// OutcomeError fails the job as the check could not decide.
+	OutcomeError
+)
+
+func (outcome Outcome) String() string {
+	switch outcome {
+	case OutcomePass:
+		return "pass"
+	case OutcomeWarn:
+		return "warn"
+	case OutcomeMfa:
+		return "mfa"
+	case OutcomeAbort:
+		return "abort"
+	case OutcomeError:
+		return "error"
+	default:
+		return fmt.Sprintf("outcome(%d)", uint(outcome))
+	}
+}
+
+// Severity is how serious the finding of a check result is, independently of its outcome.
+type Severity uint
+
+const (
+	SeverityInfo Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityInfo:
+		return "info"
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("severity(%d)", uint(severity))
+	}
+}
+
+// DefaultSeverity returns the severity of a result with the outcome when the check does not
+// know better.
+func DefaultSeverity(outcome Outcome) Severity {
+	switch outcome {
+	case OutcomeWarn:
+		return SeverityLow
+	case OutcomeMfa:
+		return SeverityMedium
+	case OutcomeAbort, OutcomeError:
+		return SeverityHigh
+	default:
+		return SeverityInfo
+	}
 }
 
 type CheckResult struct {
-	Name    string
-	Version string
-	Error   error
-	Abort   bool
-	Mfa     bool
-	Details string
+	Name     string
+	Version  string
+	Error    error
+	Outcome  Outcome
+	Severity Severity
+	Details  string
+	// Diff optionally holds a unified diff of what changed compared to the approved version.
+	Diff string
+	// Children holds the results of the checks nested in a composite check.
+	Children []CheckResult
 }
 
 // Deep Compare Two Check Results (Only Useful for the Testing).
@@ -64,11 +169,11 @@ func (result *CheckResult) CompareCheckResult(other CheckResult) bool {
 		return false
 	}
 
-	if result.Abort != other.Abort {
+	if result.Outcome != other.Outcome {
 		return false
 	}
 
-	if result.Mfa != other.Mfa {
+	if result.Severity != other.Severity {
 		return false
 	}
 
@@ -76,5 +181,77 @@ func (result *CheckResult) CompareCheckResult(other CheckResult) bool {
 		return false
 	}
 
+	if result.Diff != other.Diff {
+		return false
+	}
+
+	if len(result.Children) != len(other.Children) {
+		return false
+	}
+
+	for i := range result.Children {
+		if !result.Children[i].CompareCheckResult(other.Children[i]) {
+			return false
+		}
+	}
+
 	return true
 }
+
+// SetOutcome sets the outcome of the result along with its default severity.
+func (result *CheckResult) SetOutcome(outcome Outcome) {
+	result.Outcome = outcome
+	result.Severity = DefaultSeverity(outcome)
+}
+
+// Fail sets the outcome of a failed check from its abortOnFail and mfaOnFail options: the job
+// is aborted, requires user MFA or is only warned about when neither is set.
+func (result *CheckResult) Fail(abortOnFail bool, mfaOnFail bool) {
+	if abortOnFail {
+		result.SetOutcome(OutcomeAbort)
+	} else if mfaOnFail {
+		result.SetOutcome(OutcomeMfa)
+	} else {
+		result.SetOutcome(OutcomeWarn)
+	}
+}
+
+// WorstOutcome returns the most blocking outcome of the results (OutcomePass if there are none).
+func WorstOutcome(results []CheckResult) Outcome {
+	worst := OutcomePass
+	for _, result := range results {
+		if result.Outcome > worst {
+			worst = result.Outcome
+		}
+	}
+
+	return worst
+}
+
+// WalkResults calls visit for every result and its nested results (depth first) with the
+// prefix drawing the result's position in the tree of results.
+//
+// Example:
+//
+//	Composite Check
+//	└─ allOf
+//	   ├─ Image Hash Check
+//	   └─ Script Hash Check
+func WalkResults(results []CheckResult, visit func(prefix string, result CheckResult)) {
+	for _, result := range results {
+		visit("", result)
+		walkChildren(result.Children, "", visit)
+	}
+}
+
+func walkChildren(results []CheckResult, indent string, visit func(prefix string, result CheckResult)) {
+	for i, result := range results {
+		branch, childIndent := "├─ ", "│  "
+		if i == len(results)-1 {
+			branch, childIndent = "└─ ", "   "
+		}
+
+		visit(indent+branch, result)
+		walkChildren(result.Children, indent+childIndent, visit)
+	}
+}
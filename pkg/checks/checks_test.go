@@ -1 +1,105 @@
 package checks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkResults(t *testing.T) {
+	results := []CheckResult{
+		{
+			Name: "Composite Check",
+			Children: []CheckResult{
+				{
+					Name: "allOf",
+					Children: []CheckResult{
+						{Name: "Image Hash Check"},
+						{Name: "anyOf", Children: []CheckResult{{Name: "Script Hash Check"}}},
+					},
+				},
+			},
+		},
+		{Name: "Mfa Required Check"},
+	}
+
+	expected := []string{
+		"Composite Check",
+		"└─ allOf",
+		"   ├─ Image Hash Check",
+		"   └─ anyOf",
+		"      └─ Script Hash Check",
+		"Mfa Required Check",
+	}
+
+	var lines []string
+	WalkResults(results, func(prefix string, result CheckResult) {
+		lines = append(lines, prefix+result.Name)
+	})
+
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("unexpected result tree -\nexpected: (%+v)\ngot: (%+v)", expected, lines)
+	}
+}
+
+func TestFail(t *testing.T) {
+	failTests := []struct {
+		abortOnFail      bool
+		mfaOnFail        bool
+		expectedOutcome  Outcome
+		expectedSeverity Severity
+	}{
+		{abortOnFail: true, mfaOnFail: true, expectedOutcome: OutcomeAbort, expectedSeverity: SeverityHigh},
+		{abortOnFail: false, mfaOnFail: true, expectedOutcome: OutcomeMfa, expectedSeverity: SeverityMedium},
+		{abortOnFail: false, mfaOnFail: false, expectedOutcome: OutcomeWarn, expectedSeverity: SeverityLow},
+	}
+
+	for testNum, test := range failTests {
+		result := CheckResult{}
+		result.Fail(test.abortOnFail, test.mfaOnFail)
+
+		if result.Outcome != test.expectedOutcome || result.Severity != test.expectedSeverity {
+			t.Errorf("\n%d) unexpected outcome -\nexpected: (%s, %s)\ngot: (%s, %s)", testNum, test.expectedOutcome, test.expectedSeverity, result.Outcome, result.Severity)
+		}
+	}
+}
+
+func TestWorstOutcome(t *testing.T) {
+	worstOutcomeTests := []struct {
+		name     string
+		results  []CheckResult
+		expected Outcome
+	}{
+		{
+			name:     "no results",
+			results:  nil,
+			expected: OutcomePass,
+		},
+		{
+			name:     "warnings do not block",
+			results:  []CheckResult{{Outcome: OutcomePass}, {Outcome: OutcomeWarn}},
+			expected: OutcomeWarn,
+		},
+		{
+			name:     "mfa over warnings",
+			results:  []CheckResult{{Outcome: OutcomeWarn}, {Outcome: OutcomeMfa}, {Outcome: OutcomePass}},
+			expected: OutcomeMfa,
+		},
+		{
+			name:     "abort over mfa",
+			results:  []CheckResult{{Outcome: OutcomeMfa}, {Outcome: OutcomeAbort}},
+			expected: OutcomeAbort,
+		},
+		{
+			name:     "error over abort",
+			results:  []CheckResult{{Outcome: OutcomeError}, {Outcome: OutcomeAbort}},
+			expected: OutcomeError,
+		},
+	}
+
+	for testNum, test := range worstOutcomeTests {
+		outcome := WorstOutcome(test.results)
+		if outcome != test.expected {
+			t.Errorf("\n%d) unexpected outcome for %s -\nexpected: (%s)\ngot: (%s)", testNum, test.name, test.expected, outcome)
+		}
+	}
+}
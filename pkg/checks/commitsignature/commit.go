@@ -0,0 +1,100 @@
+package commitsignature
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var (
+	ErrCommitNotSigned       = errors.New("commit is not signed")
+	ErrUnknownSignatureType  = errors.New("unknown commit signature type")
+	commitSignatureHeaders   = []string{"gpgsig", "gpgsig-sha256"}
+	commitPgpSignatureBegin  = "-----BEGIN PGP SIGNATURE-----"
+	commitSshSignatureBegin  = "-----BEGIN SSH SIGNATURE-----"
+	commitSignatureTypePgp   = "GPG"
+	commitSignatureTypeSsh   = "SSH"
+	commitHeaderContinuation = " "
+)
+
+// readCommit returns the raw commit object of the given commit from the local git objects of
+// the repository checked out in dir (no remote is contacted).
+func readCommit(ctx context.Context, dir string, commitSha string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "cat-file", "commit", commitSha)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	commit, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read commit %s in %s: %s", commitSha, dir, strings.TrimSpace(stderr.String()))
+	}
+
+	return commit, nil
+}
+
+// splitSignature separates a raw commit object into the signed payload (the commit without
+// its signature header) and the armored signature.
+func splitSignature(commit []byte) ([]byte, []byte, error) {
+	lines := strings.SplitAfter(string(commit), "\n")
+
+	var payload strings.Builder
+	var signature strings.Builder
+
+	inHeaders := true
+	inSignature := false
+	for _, line := range lines {
+		if inHeaders && inSignature && strings.HasPrefix(line, commitHeaderContinuation) {
+			signature.WriteString(strings.TrimPrefix(line, commitHeaderContinuation))
+			continue
+		}
+		inSignature = false
+
+		if inHeaders && (line == "\n" || len(line) == 0) {
+			inHeaders = false
+		}
+
+		if inHeaders && signature.Len() == 0 {
+			if value, found := signatureHeader(line); found {
+				inSignature = true
+				signature.WriteString(value)
+				continue
+			}
+		}
+
+		payload.WriteString(line)
+	}
+
+	if signature.Len() == 0 {
+		return []byte(payload.String()), nil, ErrCommitNotSigned
+	}
+
+	return []byte(payload.String()), []byte(signature.String()), nil
+}
+
+func signatureHeader(line string) (string, bool) {
+	for _, header := range commitSignatureHeaders {
+		if strings.HasPrefix(line, header+" ") {
+			return strings.TrimPrefix(line, header+" "), true
+		}
+	}
+
+	return "", false
+}
+
+// signatureType returns the type (GPG or SSH) of an armored signature.
+func signatureType(signature []byte) (string, error) {
+	trimmed := strings.TrimSpace(string(signature))
+	if strings.HasPrefix(trimmed, commitPgpSignatureBegin) {
+		return commitSignatureTypePgp, nil
+	}
+
+	if strings.HasPrefix(trimmed, commitSshSignatureBegin) {
+		return commitSignatureTypeSsh, nil
+	}
+
+	return "", ErrUnknownSignatureType
+}
@@ -0,0 +1,237 @@
+package commitsignature
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	CommitSignatureCheckName      = "Commit Signature Check"
+	CommitSignatureCheckVersion   = "1.0.0"
+	CommitSignatureCheckSuccess   = "%s commit %s signed by %s (%s)"
+	CommitSignatureCheckNotSigned = "Commit %s is not signed"
+	CommitSignatureCheckUntrusted = "%s commit %s is not signed by a trusted key"
+	CommitSignatureCheckBad       = "%s commit %s has an invalid signature"
+	CommitSignatureCheckError     = "---ERROR---"
+	ErrNoSignerVault              = errors.New("no vault available to read the allowed signers")
+	ErrNoCommitSha                = errors.New("no commit sha provided")
+	ErrNoProjectDir               = errors.New("project directory not available to read the commit")
+	ErrUntrustedSigner            = errors.New("signature not made by an allowed signer")
+	ErrBadSignature               = errors.New("bad signature")
+	commitSignatureAllowedSigners = "allowed_signers"
+	commitSignatureShortShaLength = 8
+)
+
+func init() {
+	checks.Register("commitSignature", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewCommitSignatureCheck(config, job.JobName, job.CommitSha, job.ProjectDir, job.Vault, job.NamespaceMount, job.ProjectMount)
+		return &check
+	},
+		checks.Option{Name: "signers", Type: checks.ListOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+// Signer is a trusted key allowed to sign commits.
+type Signer struct {
+	// Identity is the name or email of the key owner reported in the check details.
+	Identity string `json:"identity"`
+	// PublicKey is either an SSH public key (i.e. "ssh-ed25519 AAAA...") or an
+	// armored OpenPGP public key block.
+	PublicKey string `json:"publicKey"`
+	// Fingerprint is filled in for the key which made a verified signature.
+	Fingerprint string `json:"-"`
+}
+
+// AllowedSigners is the document stored in the vault at {root}/{namespace}/allowed_signers
+// and {root}/{namespace}/{project}/allowed_signers.
+//
+// Example:
+//
+//	{
+//		"signers": [
+//			{"identity": "alice@example.com", "publicKey": "ssh-ed25519 AAAA..."},
+//			{"identity": "bob@example.com", "publicKey": "-----BEGIN PGP PUBLIC KEY BLOCK-----\n..."}
+//		]
+//	}
+type AllowedSigners struct {
+	Signers []Signer `json:"signers"`
+}
+
+type CommitSignatureCheck struct {
+	jobName        string
+	commitSha      string
+	projectDir     string
+	signers        []string
+	abortOnFail    bool
+	mfaOnFail      bool
+	vault          checks.SecretReader
+	namespaceMount string
+	projectMount   string
+	readCommit     func(ctx context.Context, dir string, commitSha string) ([]byte, error)
+}
+
+// NewCommitSignatureCheck creates a check verifying the signature of the commit checked out in
+// projectDir (the copy of the sources on the GitLab custom executor) against the allowed signers
+// stored in the vault. If signers is configured, only the allowed signers with one of those
+// identities are trusted.
+func NewCommitSignatureCheck(config config.CheckConfig, jobName string, commitSha string, projectDir string, vault checks.SecretReader, namespaceMount string, projectMount string) CommitSignatureCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return CommitSignatureCheck{
+		jobName:        jobName,
+		commitSha:      commitSha,
+		projectDir:     projectDir,
+		signers:        checks.GetStringListOption(config.Options, "signers"),
+		abortOnFail:    abortOnFail,
+		mfaOnFail:      mfaOnFail,
+		vault:          vault,
+		namespaceMount: namespaceMount,
+		projectMount:   projectMount,
+		readCommit:     readCommit,
+	}
+}
+
+func (check *CommitSignatureCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: CommitSignatureCheckName, Version: CommitSignatureCheckVersion}
+
+	signers, err := check.readSigners(ctx)
+	if err == nil && len(check.commitSha) == 0 {
+		err = ErrNoCommitSha
+	}
+
+	// Never fall back to the working directory, it is not the repository of the job
+	if err == nil && len(check.projectDir) == 0 {
+		err = ErrNoProjectDir
+	}
+
+	var commit []byte
+	if err == nil {
+		commit, err = check.readCommit(ctx, check.projectDir, check.commitSha)
+	}
+
+	if err != nil {
+		result.Error = checks.ContextError(ctx, err)
+		result.Details = CommitSignatureCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	shortSha := check.commitSha
+	if len(shortSha) > commitSignatureShortShaLength {
+		shortSha = shortSha[:commitSignatureShortShaLength]
+	}
+
+	payload, signature, err := splitSignature(commit)
+	if err != nil {
+		result.Details = fmt.Sprintf(CommitSignatureCheckNotSigned, shortSha)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	sigType, err := signatureType(signature)
+	if err != nil {
+		result.Error = err
+		result.Details = CommitSignatureCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	var signer Signer
+	if sigType == commitSignatureTypeSsh {
+		signer, err = verifySsh(payload, signature, signers)
+	} else {
+		signer, err = verifyGpg(payload, signature, signers)
+	}
+
+	if errors.Is(err, ErrUntrustedSigner) {
+		result.Details = fmt.Sprintf(CommitSignatureCheckUntrusted, sigType, shortSha)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else if err != nil {
+		result.Error = err
+		result.Details = fmt.Sprintf(CommitSignatureCheckBad, sigType, shortSha)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else {
+		result.Details = fmt.Sprintf(CommitSignatureCheckSuccess, sigType, shortSha, signer.Identity, signer.Fingerprint)
+	}
+
+	channel <- result
+}
+
+// readSigners reads the allowed signers of the namespace and project from the vault and keeps
+// those whose identity is in the configured signers (if any).
+func (check *CommitSignatureCheck) readSigners(ctx context.Context) ([]Signer, error) {
+	if check.vault == nil {
+		return nil, ErrNoSignerVault
+	}
+
+	var signers []Signer
+	for _, mount := range []string{check.namespaceMount, check.projectMount} {
+		vaultRes, err := check.vault.ReadSecret(ctx, mount+"/"+commitSignatureAllowedSigners)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(vaultRes) == 0 {
+			continue
+		}
+
+		var allowedSigners AllowedSigners
+		err = json.Unmarshal(vaultRes, &allowedSigners)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse allowed signers at %s: %s", mount+"/"+commitSignatureAllowedSigners, err.Error())
+		}
+
+		for _, signer := range allowedSigners.Signers {
+			if len(check.signers) == 0 || containsIdentity(check.signers, signer.Identity) {
+				signers = append(signers, signer)
+			}
+		}
+	}
+
+	return signers, nil
+}
+
+func containsIdentity(identities []string, identity string) bool {
+	for _, allowed := range identities {
+		if strings.EqualFold(strings.TrimSpace(allowed), strings.TrimSpace(identity)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsValidForCheckType only runs the check with the job script: the sources are not checked out
+// yet when the image is checked.
+func (check *CommitSignatureCheck) IsValidForCheckType(checkType uint) bool {
+	switch checkType {
+	case checks.All:
+		return true
+	default:
+		return checkType == checks.ScriptCheck
+	}
+}
+
+func (check *CommitSignatureCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *CommitSignatureCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -0,0 +1,565 @@
+package commitsignature
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/sshsig"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+	"golang.org/x/crypto/ssh"
+)
+
+var errTestVault = errors.New("vault unavailable")
+
+type mockVault struct {
+	secrets map[string]string
+	err     error
+}
+
+func (v *mockVault) ReadSecret(ctx context.Context, mount string) ([]byte, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	secret, exists := v.secrets[mount]
+	if !exists {
+		return nil, nil
+	}
+
+	return []byte(secret), nil
+}
+
+var testCommit = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+	"author Test User <test.user@example.com> 1700000000 +0000\n" +
+	"committer Test User <test.user@example.com> 1700000000 +0000\n" +
+	"\n" +
+	"test commit\n"
+
+// signedCommit inserts the armored signature as gpgsig header into the commit (as git does).
+func signedCommit(commit string, signature string) []byte {
+	header := "gpgsig " + strings.ReplaceAll(strings.TrimSpace(signature), "\n", "\n ") + "\n"
+	parts := strings.SplitN(commit, "\n\n", 2)
+	return []byte(parts[0] + "\n" + header + "\n" + parts[1])
+}
+
+func allowedSignersSecret(t *testing.T, signers ...Signer) string {
+	secret, err := json.Marshal(AllowedSigners{Signers: signers})
+	if err != nil {
+		t.Fatalf("unable to marshal allowed signers: %s", err.Error())
+	}
+
+	return string(secret)
+}
+
+func newSshKey(t *testing.T) (ed25519.PrivateKey, ssh.PublicKey) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ssh key: %s", err.Error())
+	}
+
+	publicKey, err := ssh.NewPublicKey(privateKey.Public())
+	if err != nil {
+		t.Fatalf("unable to create ssh public key: %s", err.Error())
+	}
+
+	return privateKey, publicKey
+}
+
+// sshSign creates an armored SSHSIG signature as created by ssh-keygen -Y sign -n git.
+func sshSign(t *testing.T, privateKey ed25519.PrivateKey, payload []byte) string {
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("unable to create ssh signer: %s", err.Error())
+	}
+
+	signature, err := sshsig.Sign(signer, payload, sshSignatureNamespace)
+	if err != nil {
+		t.Fatalf("unable to sign: %s", err.Error())
+	}
+
+	return string(signature)
+}
+
+func newGpgKey(t *testing.T) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity("Test User", "", "test.user@example.com", nil)
+	if err != nil {
+		t.Fatalf("unable to generate gpg key: %s", err.Error())
+	}
+
+	var publicKey bytes.Buffer
+	w, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("unable to armor gpg key: %s", err.Error())
+	}
+
+	err = entity.Serialize(w)
+	if err != nil {
+		t.Fatalf("unable to serialize gpg key: %s", err.Error())
+	}
+	w.Close()
+
+	return entity, publicKey.String()
+}
+
+func gpgSign(t *testing.T, entity *openpgp.Entity, payload []byte) string {
+	var signature bytes.Buffer
+	err := openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(payload), nil)
+	if err != nil {
+		t.Fatalf("unable to sign: %s", err.Error())
+	}
+
+	return signature.String()
+}
+
+func TestNewCommitSignatureCheck(t *testing.T) {
+	vault := &mockVault{}
+	newCommitSignatureCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		projectDir    string
+		expectedCheck CommitSignatureCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "commitSignature",
+			},
+			projectDir: "",
+			expectedCheck: CommitSignatureCheck{
+				jobName:        "testJob",
+				commitSha:      "0123456789abcdef",
+				projectDir:     "",
+				abortOnFail:    true,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+				projectMount:   "cicd/namespace/project",
+			},
+		},
+		{
+			name: "signers and mfaOnFail",
+			config: config.CheckConfig{
+				Name: "commitSignature",
+				Options: map[string]interface{}{
+					"signers":   []interface{}{"alice@example.com"},
+					"mfaOnFail": true,
+				},
+			},
+			projectDir: "/builds/namespace/project",
+			expectedCheck: CommitSignatureCheck{
+				jobName:        "testJob",
+				commitSha:      "0123456789abcdef",
+				projectDir:     "/builds/namespace/project",
+				signers:        []string{"alice@example.com"},
+				mfaOnFail:      true,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+				projectMount:   "cicd/namespace/project",
+			},
+		},
+	}
+
+	for testNum, test := range newCommitSignatureCheckTests {
+		check := NewCommitSignatureCheck(test.config, "testJob", "0123456789abcdef", test.projectDir, vault, "cicd/namespace", "cicd/namespace/project")
+		if check.readCommit == nil {
+			t.Errorf("\n%d) no commit reader set for %s", testNum, test.name)
+		}
+		check.readCommit = nil
+
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestCommitSignatureCheck(t *testing.T) {
+	sshKey, sshPublicKey := newSshKey(t)
+	_, otherSshPublicKey := newSshKey(t)
+	gpgEntity, gpgPublicKey := newGpgKey(t)
+	otherGpgEntity, _ := newGpgKey(t)
+
+	alice := Signer{Identity: "alice@example.com", PublicKey: string(ssh.MarshalAuthorizedKey(sshPublicKey))}
+	bob := Signer{Identity: "bob@example.com", PublicKey: gpgPublicKey}
+	carol := Signer{Identity: "carol@example.com", PublicKey: string(ssh.MarshalAuthorizedKey(otherSshPublicKey))}
+
+	sshSignedCommit := signedCommit(testCommit, sshSign(t, sshKey, []byte(testCommit)))
+	gpgSignedCommit := signedCommit(testCommit, gpgSign(t, gpgEntity, []byte(testCommit)))
+	otherGpgSignedCommit := signedCommit(testCommit, gpgSign(t, otherGpgEntity, []byte(testCommit)))
+	tamperedCommit := bytes.Replace(sshSignedCommit, []byte("test commit"), []byte("evil commit"), 1)
+
+	sshFingerprint := ssh.FingerprintSHA256(sshPublicKey)
+	gpgFingerprint := fmt.Sprintf("%X", gpgEntity.PrimaryKey.Fingerprint[:])
+
+	commitSignatureCheckTests := []struct {
+		name           string
+		commit         []byte
+		commitSha      string
+		noProjectDir   bool
+		signers        []string
+		vault          checks.SecretReader
+		abortOnFail    bool
+		mfaOnFail      bool
+		expectedResult checks.CheckResult
+		expectedError  error
+	}{
+		{
+			name:      "ssh signature by an allowed signer",
+			commit:    sshSignedCommit,
+			commitSha: "0123456789abcdef",
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/allowed_signers": allowedSignersSecret(t, alice, bob),
+			}},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:    CommitSignatureCheckName,
+				Version: CommitSignatureCheckVersion,
+				Details: fmt.Sprintf(CommitSignatureCheckSuccess, "SSH", "01234567", "alice@example.com", sshFingerprint),
+			},
+		},
+		{
+			name:      "gpg signature by a project allowed signer",
+			commit:    gpgSignedCommit,
+			commitSha: "0123456789abcdef",
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/allowed_signers":         allowedSignersSecret(t, alice),
+				"cicd/namespace/project/allowed_signers": allowedSignersSecret(t, bob),
+			}},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:    CommitSignatureCheckName,
+				Version: CommitSignatureCheckVersion,
+				Details: fmt.Sprintf(CommitSignatureCheckSuccess, "GPG", "01234567", "bob@example.com", gpgFingerprint),
+			},
+		},
+		{
+			name:      "ssh signature by an unknown key",
+			commit:    sshSignedCommit,
+			commitSha: "0123456789abcdef",
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/allowed_signers": allowedSignersSecret(t, carol),
+			}},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  fmt.Sprintf(CommitSignatureCheckUntrusted, "SSH", "01234567"),
+			},
+		},
+		{
+			name:      "ssh signature by a signer not in the configured signers",
+			commit:    sshSignedCommit,
+			commitSha: "0123456789abcdef",
+			signers:   []string{"bob@example.com"},
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/allowed_signers": allowedSignersSecret(t, alice, bob),
+			}},
+			mfaOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  fmt.Sprintf(CommitSignatureCheckUntrusted, "SSH", "01234567"),
+			},
+		},
+		{
+			name:      "gpg signature by an unknown key",
+			commit:    otherGpgSignedCommit,
+			commitSha: "0123456789abcdef",
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/allowed_signers": allowedSignersSecret(t, bob),
+			}},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  fmt.Sprintf(CommitSignatureCheckUntrusted, "GPG", "01234567"),
+			},
+		},
+		{
+			name:      "tampered commit",
+			commit:    tamperedCommit,
+			commitSha: "0123456789abcdef",
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/allowed_signers": allowedSignersSecret(t, alice),
+			}},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  fmt.Sprintf(CommitSignatureCheckBad, "SSH", "01234567"),
+			},
+			expectedError: ErrBadSignature,
+		},
+		{
+			name:      "unsigned commit",
+			commit:    []byte(testCommit),
+			commitSha: "0123456789abcdef",
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/allowed_signers": allowedSignersSecret(t, alice),
+			}},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  fmt.Sprintf(CommitSignatureCheckNotSigned, "01234567"),
+			},
+		},
+		{
+			name:        "no commit sha",
+			commit:      sshSignedCommit,
+			vault:       &mockVault{},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Error:    ErrNoCommitSha,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  CommitSignatureCheckError,
+			},
+			expectedError: ErrNoCommitSha,
+		},
+		{
+			name:         "no project directory",
+			commit:       sshSignedCommit,
+			commitSha:    "0123456789abcdef",
+			noProjectDir: true,
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/allowed_signers": allowedSignersSecret(t, alice),
+			}},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Error:    ErrNoProjectDir,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  CommitSignatureCheckError,
+			},
+			expectedError: ErrNoProjectDir,
+		},
+		{
+			name:        "vault error",
+			commit:      sshSignedCommit,
+			commitSha:   "0123456789abcdef",
+			vault:       &mockVault{err: errTestVault},
+			abortOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Error:    errTestVault,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  CommitSignatureCheckError,
+			},
+			expectedError: errTestVault,
+		},
+	}
+
+	for testNum, test := range commitSignatureCheckTests {
+		commit := test.commit
+		projectDir := "/builds/namespace/project"
+		if test.noProjectDir {
+			projectDir = ""
+		}
+
+		check := CommitSignatureCheck{
+			jobName:        "testJob",
+			commitSha:      test.commitSha,
+			projectDir:     projectDir,
+			signers:        test.signers,
+			abortOnFail:    test.abortOnFail,
+			mfaOnFail:      test.mfaOnFail,
+			vault:          test.vault,
+			namespaceMount: "cicd/namespace",
+			projectMount:   "cicd/namespace/project",
+			readCommit: func(ctx context.Context, dir string, commitSha string) ([]byte, error) {
+				return commit, nil
+			},
+		}
+
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !errors.Is(result.Error, test.expectedError) {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedError, result.Error)
+		}
+
+		// errors are wrapped, compare them above
+		result.Error = test.expectedResult.Error
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestCommitSignatureCheckTimeout(t *testing.T) {
+	check := NewCommitSignatureCheck(config.CheckConfig{Name: "commitSignature", Options: map[string]interface{}{"mfaOnFail": true}},
+		"testJob", "0123456789abcdef", ".", &mockVault{}, "cicd/namespace", "cicd/namespace/project")
+	check.readCommit = func(ctx context.Context, dir string, commitSha string) ([]byte, error) {
+		<-ctx.Done()
+		return nil, errors.New("signal: killed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go check.Check(ctx, channel, &wg, whitelist.Whitelist{})
+	wg.Wait()
+
+	result := <-channel
+
+	if !errors.Is(result.Error, checks.ErrCheckTimeout) || result.Outcome != checks.OutcomeMfa || result.Details != CommitSignatureCheckError {
+		t.Errorf("unexpected result for a timed out check -\nexpected: (%+v, %s)\ngot: (%+v)", checks.ErrCheckTimeout, checks.OutcomeMfa, result)
+	}
+}
+
+func TestCommitSignatureCheckGitRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not installed")
+	}
+
+	sshKey, sshPublicKey := newSshKey(t)
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "signing_key")
+
+	keyBlock, err := ssh.MarshalPrivateKey(sshKey, "")
+	if err != nil {
+		t.Fatalf("unable to marshal ssh key: %s", err.Error())
+	}
+
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(keyBlock), 0600)
+	if err != nil {
+		t.Fatalf("unable to write ssh key: %s", err.Error())
+	}
+
+	repo := filepath.Join(dir, "repo")
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %s", strings.Join(args, " "), out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	err = os.Mkdir(repo, 0700)
+	if err != nil {
+		t.Fatalf("unable to create repository: %s", err.Error())
+	}
+
+	git("init", "-q")
+	git("config", "user.name", "Test User")
+	git("config", "user.email", "test.user@example.com")
+	git("config", "gpg.format", "ssh")
+	git("config", "user.signingkey", keyFile)
+	git("commit", "-q", "--allow-empty", "-m", "unsigned commit")
+	unsignedSha := git("rev-parse", "HEAD")
+	git("commit", "-q", "--allow-empty", "-S", "-m", "signed commit")
+	signedSha := git("rev-parse", "HEAD")
+
+	vault := &mockVault{secrets: map[string]string{
+		"cicd/namespace/allowed_signers": allowedSignersSecret(t, Signer{Identity: "test.user@example.com", PublicKey: string(ssh.MarshalAuthorizedKey(sshPublicKey))}),
+	}}
+
+	gitRepositoryTests := []struct {
+		name           string
+		commitSha      string
+		expectedResult checks.CheckResult
+	}{
+		{
+			name:      "signed commit",
+			commitSha: signedSha,
+			expectedResult: checks.CheckResult{
+				Name:    CommitSignatureCheckName,
+				Version: CommitSignatureCheckVersion,
+				Details: fmt.Sprintf(CommitSignatureCheckSuccess, "SSH", signedSha[:8], "test.user@example.com", ssh.FingerprintSHA256(sshPublicKey)),
+			},
+		},
+		{
+			name:      "unsigned commit",
+			commitSha: unsignedSha,
+			expectedResult: checks.CheckResult{
+				Name:     CommitSignatureCheckName,
+				Version:  CommitSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  fmt.Sprintf(CommitSignatureCheckNotSigned, unsignedSha[:8]),
+			},
+		},
+	}
+
+	for testNum, test := range gitRepositoryTests {
+		check := NewCommitSignatureCheck(config.CheckConfig{Name: "commitSignature"}, "testJob", test.commitSha, repo, vault, "cicd/namespace", "cicd/namespace/project")
+
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestCommitSignatureCheckIsValidForCheckType(t *testing.T) {
+	check := CommitSignatureCheck{}
+	if !check.IsValidForCheckType(checks.All) || !check.IsValidForCheckType(checks.ScriptCheck) {
+		t.Errorf("commit signature check should be valid for script and all checks")
+	}
+
+	if check.IsValidForCheckType(checks.ImageCheck) {
+		t.Errorf("commit signature check should not be valid for image checks")
+	}
+}
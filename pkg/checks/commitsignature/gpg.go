@@ -0,0 +1,50 @@
+package commitsignature
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+)
+
+// verifyGpg verifies an armored OpenPGP signature over the payload and returns the signer
+// whose key made it.
+func verifyGpg(payload []byte, armored []byte, signers []Signer) (Signer, error) {
+	var keyring openpgp.EntityList
+	keySigners := make(map[uint64]Signer)
+
+	for _, signer := range signers {
+		if !strings.Contains(signer.PublicKey, "BEGIN PGP PUBLIC KEY BLOCK") {
+			continue
+		}
+
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(signer.PublicKey))
+		if err != nil {
+			continue
+		}
+
+		for _, entity := range entities {
+			keySigners[entity.PrimaryKey.KeyId] = signer
+			for _, subkey := range entity.Subkeys {
+				keySigners[subkey.PublicKey.KeyId] = signer
+			}
+		}
+
+		keyring = append(keyring, entities...)
+	}
+
+	entity, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(payload), bytes.NewReader(armored), nil)
+	if errors.Is(err, pgperrors.ErrUnknownIssuer) {
+		return Signer{}, fmt.Errorf("%w: unknown gpg key", ErrUntrustedSigner)
+	} else if err != nil {
+		return Signer{}, fmt.Errorf("%w: %s", ErrBadSignature, err.Error())
+	}
+
+	signer := keySigners[entity.PrimaryKey.KeyId]
+	signer.Fingerprint = fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint[:])
+
+	return signer, nil
+}
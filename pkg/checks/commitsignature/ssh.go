@@ -0,0 +1,40 @@
+package commitsignature
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/sshsig"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	ErrInvalidSshSignature = errors.New("invalid ssh signature")
+	sshSignatureNamespace  = "git"
+)
+
+// verifySsh verifies an armored SSH signature (as created by ssh-keygen -Y sign -n git)
+// over the payload and returns the signer whose key made it.
+func verifySsh(payload []byte, armored []byte, signers []Signer) (Signer, error) {
+	publicKey, err := sshsig.Verify(payload, armored, sshSignatureNamespace)
+	if errors.Is(err, sshsig.ErrInvalidSignature) {
+		return Signer{}, fmt.Errorf("%w: %s", ErrInvalidSshSignature, err.Error())
+	} else if err != nil {
+		return Signer{}, fmt.Errorf("%w: %s", ErrBadSignature, err.Error())
+	}
+
+	for _, signer := range signers {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(signer.PublicKey))
+		if err != nil {
+			continue
+		}
+
+		if bytes.Equal(key.Marshal(), publicKey.Marshal()) {
+			signer.Fingerprint = ssh.FingerprintSHA256(publicKey)
+			return signer, nil
+		}
+	}
+
+	return Signer{}, fmt.Errorf("%w: %s", ErrUntrustedSigner, ssh.FingerprintSHA256(publicKey))
+}
@@ -0,0 +1,405 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checkrunner"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	CompositeCheckName          = "Composite Check"
+	CompositeCheckVersion       = "1.0.0"
+	CompositeCheckPassed        = "Policy passed"
+	CompositeCheckFailed        = "Policy failed"
+	CompositeCheckNotApplicable = "No nested check applies"
+	CompositeCheckError         = "---ERROR---"
+	CompositeExpressionPassed   = "passed"
+	CompositeExpressionFailed   = "failed"
+	CompositeExpressionError    = "error"
+	ErrInvalidExpression        = errors.New("invalid composite expression")
+	ErrNestedCheckError         = errors.New("a nested check could not be evaluated")
+	ErrUnknownCheck             = errors.New("unknown check name")
+	operatorAllOf               = "allOf"
+	operatorAnyOf               = "anyOf"
+	operatorNot                 = "not"
+)
+
+func init() {
+	checks.Register("composite", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewCompositeCheck(config, job)
+		return &check
+	},
+		checks.Option{Name: operatorAllOf, Type: checks.ListOption},
+		checks.Option{Name: operatorAnyOf, Type: checks.ListOption},
+		checks.Option{Name: operatorNot, Type: checks.ObjectOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+// expression is a node of the composite policy: either a nested check or an operator
+// (allOf, anyOf, not) over nested expressions.
+type expression struct {
+	operator string
+	check    checks.Check
+	children []*expression
+}
+
+// applicability tells whether an expression can be evaluated for a check type and platform.
+type applicability uint
+
+const (
+	applicable applicability = iota
+	// otherCheckType expressions are evaluated when the checks of another type are run.
+	otherCheckType
+	// otherPlatform expressions never apply to the job.
+	otherPlatform
+)
+
+// verdict is the three valued result of an expression: a nested check which errors is neither
+// passed nor failed, so that an error is never turned into a pass by not or anyOf.
+type verdict uint
+
+const (
+	passed verdict = iota
+	failed
+	errored
+)
+
+type CompositeCheck struct {
+	jobName     string
+	checkType   uint
+	platform    string
+	root        *expression
+	abortOnFail bool
+	mfaOnFail   bool
+	configErr   error
+}
+
+// NewCompositeCheck creates a check combining nested checks with allOf, anyOf and not. The
+// options must hold exactly one of the operators, whose operands are check configurations
+// ({"name": ..., "options": ...}) or nested expressions ({"allOf": [...]}, {"anyOf": [...]},
+// {"not": {...}}). A nested check passes only if its outcome is a pass, whatever its own
+// abortOnFail and mfaOnFail options: a warning fails. A nested check which errors makes the
+// expressions it decides error (see evaluate). Only the abortOnFail and mfaOnFail options of the
+// composite check decide the outcome.
+//
+// Example (image whitelisted and either script whitelisted or user in release-managers):
+//
+//	{
+//		"allOf": [
+//			{"name": "imageHash", "options": {"abortOnFail": true}},
+//			{"anyOf": [
+//				{"name": "scriptHash", "options": {"abortOnFail": true}},
+//				{"name": "userAllowlist", "options": {"groups": ["release-managers"]}}
+//			]}
+//		]
+//	}
+func NewCompositeCheck(config config.CheckConfig, job checks.JobInfo) CompositeCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	root, err := parseExpression(config.Options, job)
+
+	return CompositeCheck{
+		jobName:     job.JobName,
+		checkType:   job.CheckType,
+		platform:    job.CiPlatform,
+		root:        root,
+		abortOnFail: abortOnFail,
+		mfaOnFail:   mfaOnFail,
+		configErr:   err,
+	}
+}
+
+// parseExpression parses an expression holding exactly one operator.
+func parseExpression(options map[string]interface{}, job checks.JobInfo) (*expression, error) {
+	var operators []string
+	for _, operator := range []string{operatorAllOf, operatorAnyOf, operatorNot} {
+		if _, exists := options[operator]; exists {
+			operators = append(operators, operator)
+		}
+	}
+
+	if len(operators) != 1 {
+		return nil, fmt.Errorf("%w: expected exactly one of allOf, anyOf or not, got [%s]", ErrInvalidExpression, strings.Join(operators, ", "))
+	}
+
+	node := &expression{operator: operators[0]}
+	value := options[node.operator]
+
+	if node.operator == operatorNot {
+		child, err := parseOperand(value, job)
+		if err != nil {
+			return nil, err
+		}
+
+		node.children = []*expression{child}
+		return node, nil
+	}
+
+	operands, isList := value.([]interface{})
+	if !isList || len(operands) == 0 {
+		return nil, fmt.Errorf("%w: %s must be a non empty list", ErrInvalidExpression, node.operator)
+	}
+
+	for _, operand := range operands {
+		child, err := parseOperand(operand, job)
+		if err != nil {
+			return nil, err
+		}
+
+		node.children = append(node.children, child)
+	}
+
+	return node, nil
+}
+
+// parseOperand parses a check configuration or a nested expression.
+func parseOperand(value interface{}, job checks.JobInfo) (*expression, error) {
+	operand, isObject := value.(map[string]interface{})
+	if !isObject {
+		return nil, fmt.Errorf("%w: operands must be checks or expressions, got %v", ErrInvalidExpression, value)
+	}
+
+	if _, isCheck := operand["name"]; !isCheck {
+		return parseExpression(operand, job)
+	}
+
+	name, isString := operand["name"].(string)
+	if !isString {
+		return nil, fmt.Errorf("%w: check name must be a string, got %v", ErrInvalidExpression, operand["name"])
+	}
+
+	options, isObject := operand["options"].(map[string]interface{})
+	if !isObject && operand["options"] != nil {
+		return nil, fmt.Errorf("%w: options of %s must be an object", ErrInvalidExpression, name)
+	}
+
+	registration, exists := checks.Lookup(name)
+	if !exists {
+		return nil, fmt.Errorf("%w %q (registered checks: %s)", ErrUnknownCheck, name, strings.Join(checks.RegisteredNames(), ", "))
+	}
+
+	err := registration.ValidateOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	check := registration.Constructor(config.CheckConfig{Name: name, Options: options}, job)
+	return &expression{check: check}, nil
+}
+
+// prune returns the expression reduced to the nested checks which apply to the check type and
+// platform. Checks of other platforms are left out and so are the checks of another check type
+// in an allOf, as they are evaluated with the checks of their type. An anyOf or not mixing check
+// types cannot be decided before all of them run: it is evaluated as a whole with the script
+// checks, which run last.
+func (e *expression) prune(checkType uint, platform string) (*expression, applicability) {
+	if e.check != nil {
+		if !e.check.IsValidForPlatform(platform) {
+			return nil, otherPlatform
+		} else if !e.check.IsValidForCheckType(checkType) {
+			return nil, otherCheckType
+		}
+
+		return e, applicable
+	}
+
+	pruned := &expression{operator: e.operator}
+	deferred := false
+	for _, child := range e.children {
+		prunedChild, childApplicability := child.prune(checkType, platform)
+		switch childApplicability {
+		case applicable:
+			pruned.children = append(pruned.children, prunedChild)
+		case otherCheckType:
+			deferred = true
+		}
+	}
+
+	if len(pruned.children) == 0 && deferred {
+		return nil, otherCheckType
+	} else if len(pruned.children) == 0 {
+		return nil, otherPlatform
+	} else if deferred && e.operator != operatorAllOf {
+		if checkType != checks.ScriptCheck {
+			return nil, otherCheckType
+		}
+		return e.prune(checks.All, platform)
+	}
+
+	return pruned, applicable
+}
+
+// nestedChecks returns the nested checks of the expression.
+func (e *expression) nestedChecks() []checks.Check {
+	if e.check != nil {
+		return []checks.Check{e.check}
+	}
+
+	var nested []checks.Check
+	for _, child := range e.children {
+		nested = append(nested, child.nestedChecks()...)
+	}
+
+	return nested
+}
+
+// evaluate returns the verdict of the expression and its result with the nested results. The
+// results of failed or errored operators get the outcome of a failed composite check. Errors
+// propagate the way unknown values do in a three valued logic: allOf fails if a nested
+// expression fails and errors otherwise, anyOf passes if a nested expression passes and errors
+// otherwise, not errors if its nested expression errors.
+func (e *expression) evaluate(results map[checks.Check]checks.CheckResult, abortOnFail bool, mfaOnFail bool) (verdict, checks.CheckResult) {
+	if e.check != nil {
+		result := results[e.check]
+		if result.Error != nil || result.Outcome == checks.OutcomeError {
+			return errored, result
+		} else if result.Outcome != checks.OutcomePass {
+			return failed, result
+		}
+
+		return passed, result
+	}
+
+	result := checks.CheckResult{Name: e.operator}
+
+	counts := make(map[verdict]int)
+	for _, child := range e.children {
+		childVerdict, childResult := child.evaluate(results, abortOnFail, mfaOnFail)
+		counts[childVerdict]++
+
+		result.Children = append(result.Children, childResult)
+	}
+
+	var operatorVerdict verdict
+	switch e.operator {
+	case operatorAllOf:
+		operatorVerdict = passed
+		if counts[failed] != 0 {
+			operatorVerdict = failed
+		} else if counts[errored] != 0 {
+			operatorVerdict = errored
+		}
+	case operatorAnyOf:
+		operatorVerdict = failed
+		if counts[passed] != 0 {
+			operatorVerdict = passed
+		} else if counts[errored] != 0 {
+			operatorVerdict = errored
+		}
+	case operatorNot:
+		operatorVerdict = errored
+		if counts[passed] != 0 {
+			operatorVerdict = failed
+		} else if counts[failed] != 0 {
+			operatorVerdict = passed
+		}
+	}
+
+	switch operatorVerdict {
+	case passed:
+		result.Details = CompositeExpressionPassed
+	case failed:
+		result.Details = CompositeExpressionFailed
+		result.Fail(abortOnFail, mfaOnFail)
+	case errored:
+		result.Error = ErrNestedCheckError
+		result.Details = CompositeExpressionError
+		result.Fail(abortOnFail, mfaOnFail)
+	}
+
+	return operatorVerdict, result
+}
+
+func (check *CompositeCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: CompositeCheckName, Version: CompositeCheckVersion}
+
+	if check.configErr != nil {
+		result.Error = check.configErr
+		result.Details = CompositeCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	root, rootApplicability := check.root.prune(check.checkType, check.platform)
+	if rootApplicability != applicable {
+		result.Details = CompositeCheckNotApplicable
+		channel <- result
+		return
+	}
+
+	nested := root.nestedChecks()
+	results := runChecks(ctx, nested, w)
+
+	rootVerdict, rootResult := root.evaluate(results, check.abortOnFail, check.mfaOnFail)
+	result.Children = []checks.CheckResult{rootResult}
+
+	if rootVerdict == passed {
+		result.Details = CompositeCheckPassed
+	} else {
+		result.Details = CompositeCheckFailed
+		if rootVerdict == errored {
+			result.Error = ErrNestedCheckError
+			result.Details = CompositeCheckError
+		}
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+
+		var diffs []string
+		for _, nestedCheck := range nested {
+			if diff := results[nestedCheck].Diff; len(diff) != 0 {
+				diffs = append(diffs, diff)
+			}
+		}
+		result.Diff = strings.Join(diffs, "")
+	}
+
+	channel <- result
+}
+
+// runChecks runs the nested checks and returns the result of each check. The nested checks have
+// no timeout of their own, they share the one of the composite check.
+func runChecks(ctx context.Context, nested []checks.Check, w whitelist.Whitelist) map[checks.Check]checks.CheckResult {
+	results := make(map[checks.Check]checks.CheckResult, len(nested))
+	for i, result := range checkrunner.Run(ctx, nested, w, 0) {
+		results[nested[i]] = result
+	}
+
+	return results
+}
+
+// IsValidForCheckType returns whether some of the nested checks are evaluated with the checks
+// of the check type.
+func (check *CompositeCheck) IsValidForCheckType(checkType uint) bool {
+	if check.configErr != nil {
+		return true
+	}
+
+	_, rootApplicability := check.root.prune(checkType, check.platform)
+	return rootApplicability == applicable
+}
+
+// IsValidForPlatform returns whether some of the nested checks apply to the platform.
+func (check *CompositeCheck) IsValidForPlatform(ciPlatform string) bool {
+	if check.configErr != nil {
+		return true
+	}
+
+	_, rootApplicability := check.root.prune(checks.All, ciPlatform)
+	return rootApplicability == applicable
+}
+
+func (check *CompositeCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -0,0 +1,581 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checkrunner"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var errTest = errors.New("test error")
+
+// compositeTestCheck reports the outcome given in its options, for the check type and
+// platform given in its options.
+type compositeTestCheck struct {
+	name      string
+	outcome   string
+	checkType uint
+	platform  string
+}
+
+func (check *compositeTestCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: check.name, Version: "1.0.0", Details: check.outcome}
+	switch check.outcome {
+	case "abort":
+		result.SetOutcome(checks.OutcomeAbort)
+		result.Diff = "+ " + check.name + "\n"
+	case "warn":
+		result.SetOutcome(checks.OutcomeWarn)
+	case "mfa":
+		result.SetOutcome(checks.OutcomeMfa)
+	case "error":
+		result.Error = errTest
+	case "silent":
+		return
+	case "hang":
+		<-ctx.Done()
+		return
+	}
+
+	channel <- result
+}
+
+func (check *compositeTestCheck) IsValidForCheckType(checkType uint) bool {
+	return check.checkType == checks.All || checkType == checks.All || check.checkType == checkType
+}
+
+func (check *compositeTestCheck) IsValidForPlatform(ciPlatform string) bool {
+	return len(check.platform) == 0 || check.platform == ciPlatform
+}
+
+func (check *compositeTestCheck) FailOptions() (bool, bool) {
+	return true, false
+}
+
+func init() {
+	checks.Register("compositeTestCheck", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		checkType := checks.All
+		switch checks.GetStringOption(config.Options, "checkType", "") {
+		case "image":
+			checkType = checks.ImageCheck
+		case "script":
+			checkType = checks.ScriptCheck
+		}
+
+		return &compositeTestCheck{
+			name:      checks.GetStringOption(config.Options, "name", "Test Check"),
+			outcome:   checks.GetStringOption(config.Options, "outcome", "pass"),
+			checkType: uint(checkType),
+			platform:  checks.GetStringOption(config.Options, "platform", ""),
+		}
+	},
+		checks.Option{Name: "name", Type: checks.StringOption},
+		checks.Option{Name: "outcome", Type: checks.StringOption},
+		checks.Option{Name: "checkType", Type: checks.StringOption},
+		checks.Option{Name: "platform", Type: checks.StringOption},
+	)
+}
+
+// testCheck returns the configuration of a nested test check.
+func testCheck(name string, outcome string, extra ...string) map[string]interface{} {
+	options := map[string]interface{}{"name": name, "outcome": outcome}
+	for i := 0; i+1 < len(extra); i += 2 {
+		options[extra[i]] = extra[i+1]
+	}
+
+	return map[string]interface{}{"name": "compositeTestCheck", "options": options}
+}
+
+func TestCompositeCheck(t *testing.T) {
+	compositeCheckTests := []struct {
+		name           string
+		options        map[string]interface{}
+		checkType      uint
+		platform       string
+		expectedResult checks.CheckResult
+	}{
+		{
+			name: "all of passing checks",
+			options: map[string]interface{}{
+				"allOf": []interface{}{testCheck("A", "pass"), testCheck("B", "pass")},
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:    CompositeCheckName,
+				Version: CompositeCheckVersion,
+				Details: CompositeCheckPassed,
+				Children: []checks.CheckResult{{
+					Name:    operatorAllOf,
+					Details: CompositeExpressionPassed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Details: "pass"},
+						{Name: "B", Version: "1.0.0", Details: "pass"},
+					},
+				}},
+			},
+		},
+		{
+			name: "all of with a failing check",
+			options: map[string]interface{}{
+				"allOf": []interface{}{testCheck("A", "pass"), testCheck("B", "abort")},
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:     CompositeCheckName,
+				Version:  CompositeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  CompositeCheckFailed,
+				Diff:     "+ B\n",
+				Children: []checks.CheckResult{{
+					Name:     operatorAllOf,
+					Outcome:  checks.OutcomeAbort,
+					Severity: checks.SeverityHigh,
+					Details:  CompositeExpressionFailed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Details: "pass"},
+						{Name: "B", Version: "1.0.0", Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: "abort", Diff: "+ B\n"},
+					},
+				}},
+			},
+		},
+		{
+			name: "all of with a warning fails",
+			options: map[string]interface{}{
+				"allOf": []interface{}{testCheck("A", "pass"), testCheck("B", "warn")},
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:     CompositeCheckName,
+				Version:  CompositeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  CompositeCheckFailed,
+				Children: []checks.CheckResult{{
+					Name:     operatorAllOf,
+					Outcome:  checks.OutcomeAbort,
+					Severity: checks.SeverityHigh,
+					Details:  CompositeExpressionFailed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Details: "pass"},
+						{Name: "B", Version: "1.0.0", Outcome: checks.OutcomeWarn, Severity: checks.SeverityLow, Details: "warn"},
+					},
+				}},
+			},
+		},
+		{
+			name: "any of with one passing check requires no mfa",
+			options: map[string]interface{}{
+				"anyOf":     []interface{}{testCheck("A", "mfa"), testCheck("B", "pass")},
+				"mfaOnFail": true,
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:    CompositeCheckName,
+				Version: CompositeCheckVersion,
+				Details: CompositeCheckPassed,
+				Children: []checks.CheckResult{{
+					Name:    operatorAnyOf,
+					Details: CompositeExpressionPassed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Outcome: checks.OutcomeMfa, Severity: checks.SeverityMedium, Details: "mfa"},
+						{Name: "B", Version: "1.0.0", Details: "pass"},
+					},
+				}},
+			},
+		},
+		{
+			name: "any of with failing checks requires mfa",
+			options: map[string]interface{}{
+				"anyOf":     []interface{}{testCheck("A", "abort"), testCheck("B", "warn")},
+				"mfaOnFail": true,
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:     CompositeCheckName,
+				Version:  CompositeCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  CompositeCheckFailed,
+				Diff:     "+ A\n",
+				Children: []checks.CheckResult{{
+					Name:     operatorAnyOf,
+					Outcome:  checks.OutcomeMfa,
+					Severity: checks.SeverityMedium,
+					Details:  CompositeExpressionFailed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: "abort", Diff: "+ A\n"},
+						{Name: "B", Version: "1.0.0", Outcome: checks.OutcomeWarn, Severity: checks.SeverityLow, Details: "warn"},
+					},
+				}},
+			},
+		},
+		{
+			name: "any of with failing and erroring checks errors",
+			options: map[string]interface{}{
+				"anyOf":     []interface{}{testCheck("A", "abort"), testCheck("B", "silent")},
+				"mfaOnFail": true,
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:     CompositeCheckName,
+				Version:  CompositeCheckVersion,
+				Error:    ErrNestedCheckError,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  CompositeCheckError,
+				Diff:     "+ A\n",
+				Children: []checks.CheckResult{{
+					Name:     operatorAnyOf,
+					Error:    ErrNestedCheckError,
+					Outcome:  checks.OutcomeMfa,
+					Severity: checks.SeverityMedium,
+					Details:  CompositeExpressionError,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: "abort", Diff: "+ A\n"},
+						{Name: "compositeTestCheck", Error: checkrunner.ErrNoResult, Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: checkrunner.CheckRunnerError},
+					},
+				}},
+			},
+		},
+		{
+			name: "any of with passing and erroring checks passes",
+			options: map[string]interface{}{
+				"anyOf": []interface{}{testCheck("A", "error"), testCheck("B", "pass")},
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:    CompositeCheckName,
+				Version: CompositeCheckVersion,
+				Details: CompositeCheckPassed,
+				Children: []checks.CheckResult{{
+					Name:    operatorAnyOf,
+					Details: CompositeExpressionPassed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Error: errTest, Details: "error"},
+						{Name: "B", Version: "1.0.0", Details: "pass"},
+					},
+				}},
+			},
+		},
+		{
+			name: "not of a failing check passes",
+			options: map[string]interface{}{
+				"not": testCheck("A", "abort"),
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:    CompositeCheckName,
+				Version: CompositeCheckVersion,
+				Details: CompositeCheckPassed,
+				Children: []checks.CheckResult{{
+					Name:    operatorNot,
+					Details: CompositeExpressionPassed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: "abort", Diff: "+ A\n"},
+					},
+				}},
+			},
+		},
+		{
+			name: "not of an erroring check errors",
+			options: map[string]interface{}{
+				"not": map[string]interface{}{
+					"allOf": []interface{}{testCheck("A", "pass"), testCheck("B", "error")},
+				},
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:     CompositeCheckName,
+				Version:  CompositeCheckVersion,
+				Error:    ErrNestedCheckError,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  CompositeCheckError,
+				Children: []checks.CheckResult{{
+					Name:     operatorNot,
+					Error:    ErrNestedCheckError,
+					Outcome:  checks.OutcomeAbort,
+					Severity: checks.SeverityHigh,
+					Details:  CompositeExpressionError,
+					Children: []checks.CheckResult{{
+						Name:     operatorAllOf,
+						Error:    ErrNestedCheckError,
+						Outcome:  checks.OutcomeAbort,
+						Severity: checks.SeverityHigh,
+						Details:  CompositeExpressionError,
+						Children: []checks.CheckResult{
+							{Name: "A", Version: "1.0.0", Details: "pass"},
+							{Name: "B", Version: "1.0.0", Error: errTest, Details: "error"},
+						},
+					}},
+				}},
+			},
+		},
+		{
+			name: "nested any of in all of",
+			options: map[string]interface{}{
+				"allOf": []interface{}{
+					testCheck("A", "pass"),
+					map[string]interface{}{
+						"anyOf": []interface{}{testCheck("B", "abort"), testCheck("C", "pass")},
+					},
+				},
+			},
+			checkType: checks.All,
+			expectedResult: checks.CheckResult{
+				Name:    CompositeCheckName,
+				Version: CompositeCheckVersion,
+				Details: CompositeCheckPassed,
+				Children: []checks.CheckResult{{
+					Name:    operatorAllOf,
+					Details: CompositeExpressionPassed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Details: "pass"},
+						{
+							Name:    operatorAnyOf,
+							Details: CompositeExpressionPassed,
+							Children: []checks.CheckResult{
+								{Name: "B", Version: "1.0.0", Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: "abort", Diff: "+ B\n"},
+								{Name: "C", Version: "1.0.0", Details: "pass"},
+							},
+						},
+					},
+				}},
+			},
+		},
+		{
+			name: "all of leaves out checks of another check type",
+			options: map[string]interface{}{
+				"allOf": []interface{}{testCheck("A", "pass", "checkType", "image"), testCheck("B", "abort", "checkType", "script")},
+			},
+			checkType: checks.ImageCheck,
+			expectedResult: checks.CheckResult{
+				Name:    CompositeCheckName,
+				Version: CompositeCheckVersion,
+				Details: CompositeCheckPassed,
+				Children: []checks.CheckResult{{
+					Name:    operatorAllOf,
+					Details: CompositeExpressionPassed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Details: "pass"},
+					},
+				}},
+			},
+		},
+		{
+			name: "any of with checks of another check type is deferred",
+			options: map[string]interface{}{
+				"anyOf": []interface{}{testCheck("A", "abort", "checkType", "image"), testCheck("B", "pass", "checkType", "script")},
+			},
+			checkType: checks.ImageCheck,
+			expectedResult: checks.CheckResult{
+				Name:    CompositeCheckName,
+				Version: CompositeCheckVersion,
+				Details: CompositeCheckNotApplicable,
+			},
+		},
+		{
+			name: "any of with checks of several check types is evaluated with the script checks",
+			options: map[string]interface{}{
+				"anyOf": []interface{}{testCheck("A", "abort", "checkType", "image"), testCheck("B", "pass", "checkType", "script")},
+			},
+			checkType: checks.ScriptCheck,
+			expectedResult: checks.CheckResult{
+				Name:    CompositeCheckName,
+				Version: CompositeCheckVersion,
+				Details: CompositeCheckPassed,
+				Children: []checks.CheckResult{{
+					Name:    operatorAnyOf,
+					Details: CompositeExpressionPassed,
+					Children: []checks.CheckResult{
+						{Name: "A", Version: "1.0.0", Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: "abort", Diff: "+ A\n"},
+						{Name: "B", Version: "1.0.0", Details: "pass"},
+					},
+				}},
+			},
+		},
+		{
+			name: "checks of another platform are left out",
+			options: map[string]interface{}{
+				"anyOf": []interface{}{testCheck("A", "abort", "platform", "github"), testCheck("B", "abort")},
+			},
+			checkType: checks.All,
+			platform:  "gitlab",
+			expectedResult: checks.CheckResult{
+				Name:     CompositeCheckName,
+				Version:  CompositeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  CompositeCheckFailed,
+				Diff:     "+ B\n",
+				Children: []checks.CheckResult{{
+					Name:     operatorAnyOf,
+					Outcome:  checks.OutcomeAbort,
+					Severity: checks.SeverityHigh,
+					Details:  CompositeExpressionFailed,
+					Children: []checks.CheckResult{
+						{Name: "B", Version: "1.0.0", Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: "abort", Diff: "+ B\n"},
+					},
+				}},
+			},
+		},
+	}
+
+	for testNum, test := range compositeCheckTests {
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		job := checks.JobInfo{JobName: "testJob", CheckType: test.checkType, CiPlatform: test.platform}
+		check := NewCompositeCheck(config.CheckConfig{Name: "composite", Options: test.options}, job)
+
+		wg.Add(1)
+		go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+		wg.Wait()
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) unexpected result for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestCompositeCheckConfigError(t *testing.T) {
+	configErrorTests := []struct {
+		name          string
+		options       map[string]interface{}
+		expectedError error
+	}{
+		{
+			name:          "no operator",
+			options:       map[string]interface{}{"abortOnFail": true},
+			expectedError: ErrInvalidExpression,
+		},
+		{
+			name: "several operators",
+			options: map[string]interface{}{
+				"allOf": []interface{}{testCheck("A", "pass")},
+				"anyOf": []interface{}{testCheck("B", "pass")},
+			},
+			expectedError: ErrInvalidExpression,
+		},
+		{
+			name:          "empty operand list",
+			options:       map[string]interface{}{"allOf": []interface{}{}},
+			expectedError: ErrInvalidExpression,
+		},
+		{
+			name:          "operand which is not an object",
+			options:       map[string]interface{}{"anyOf": []interface{}{"imageHash"}},
+			expectedError: ErrInvalidExpression,
+		},
+		{
+			name: "unknown nested check",
+			options: map[string]interface{}{
+				"not": map[string]interface{}{"name": "unknownCheck"},
+			},
+			expectedError: ErrUnknownCheck,
+		},
+		{
+			name: "invalid nested check option",
+			options: map[string]interface{}{
+				"allOf": []interface{}{
+					map[string]interface{}{"name": "compositeTestCheck", "options": map[string]interface{}{"outcome": true}},
+				},
+			},
+			expectedError: checks.ErrInvalidCheckOption,
+		},
+	}
+
+	for testNum, test := range configErrorTests {
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		check := NewCompositeCheck(config.CheckConfig{Name: "composite", Options: test.options}, checks.JobInfo{JobName: "testJob"})
+
+		if !check.IsValidForCheckType(checks.ImageCheck) || !check.IsValidForPlatform("github") {
+			t.Errorf("\n%d) misconfigured check for %s should report its error for every job", testNum, test.name)
+		}
+
+		wg.Add(1)
+		go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+		wg.Wait()
+
+		result := <-channel
+
+		if !errors.Is(result.Error, test.expectedError) || result.Outcome != checks.OutcomeAbort || result.Details != CompositeCheckError {
+			t.Errorf("\n%d) unexpected result for %s -\nexpected error: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedError, result)
+		}
+	}
+}
+
+func TestCompositeCheckIsValid(t *testing.T) {
+	options := map[string]interface{}{
+		"allOf": []interface{}{
+			testCheck("A", "pass", "checkType", "image", "platform", "gitlab"),
+			map[string]interface{}{
+				"anyOf": []interface{}{testCheck("B", "pass", "checkType", "image"), testCheck("C", "pass", "checkType", "script")},
+			},
+		},
+	}
+
+	isValidTests := []struct {
+		checkType uint
+		platform  string
+		expected  bool
+	}{
+		{checkType: checks.ImageCheck, platform: "gitlab", expected: true},
+		{checkType: checks.ScriptCheck, platform: "gitlab", expected: true},
+		{checkType: checks.ImageCheck, platform: "github", expected: false},
+		{checkType: checks.ScriptCheck, platform: "github", expected: true},
+		{checkType: checks.All, platform: "github", expected: true},
+	}
+
+	for testNum, test := range isValidTests {
+		check := NewCompositeCheck(config.CheckConfig{Name: "composite", Options: options}, checks.JobInfo{JobName: "testJob", CiPlatform: test.platform})
+
+		valid := check.IsValidForPlatform(test.platform) && check.IsValidForCheckType(test.checkType)
+		if valid != test.expected {
+			t.Errorf("\n%d) unexpected validity for check type %d on %s -\nexpected: (%t)\ngot: (%t)", testNum, test.checkType, test.platform, test.expected, valid)
+		}
+	}
+}
+
+func TestCompositeCheckTimeout(t *testing.T) {
+	options := map[string]interface{}{
+		"anyOf":     []interface{}{testCheck("A", "hang")},
+		"mfaOnFail": true,
+	}
+
+	check := NewCompositeCheck(config.CheckConfig{Name: "composite", Options: options}, checks.JobInfo{JobName: "testJob", CheckType: checks.All})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go check.Check(ctx, channel, &wg, whitelist.Whitelist{})
+	wg.Wait()
+
+	result := <-channel
+
+	if result.Error != ErrNestedCheckError || result.Outcome != checks.OutcomeMfa || len(result.Children) != 1 || len(result.Children[0].Children) != 1 {
+		t.Fatalf("unexpected result for a timed out nested check: (%+v)", result)
+	}
+
+	nested := result.Children[0].Children[0]
+	if !errors.Is(nested.Error, checks.ErrCheckTimeout) || !errors.Is(nested.Error, checkrunner.ErrNoResult) || nested.Outcome != checks.OutcomeAbort {
+		t.Errorf("unexpected nested result -\nexpected: (%+v)\ngot: (%+v)", checks.ErrCheckTimeout, nested)
+	}
+}
@@ -0,0 +1,295 @@
+package containerruntime
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	ContainerRuntimeCheckName         = "Container Runtime Check"
+	ContainerRuntimeCheckVersion      = "1.0.0"
+	ContainerRuntimeCheckSuccess      = "The docker run arguments %q follow the runtime policy"
+	ContainerRuntimeCheckFound        = "Container Runtime Policy Violated:"
+	ContainerRuntimeCheckPrivileged   = "\n - %q runs the container privileged"
+	ContainerRuntimeCheckHostNetwork  = "\n - %q shares the host network namespace"
+	ContainerRuntimeCheckHostPid      = "\n - %q shares the host PID namespace"
+	ContainerRuntimeCheckHostNs       = "\n - %q shares the host %s namespace"
+	ContainerRuntimeCheckUnconfined   = "\n - %q disables the %s confinement"
+	ContainerRuntimeCheckDevice       = "\n - %q gives the container access to a host device"
+	ContainerRuntimeCheckDockerSocket = "\n - %q mounts the docker socket"
+	ContainerRuntimeCheckCapability   = "\n - %q adds the capability %s"
+)
+
+// valueFlags are the docker run flags whose value is checked, their value is either given
+// after "=" or as the next argument.
+var valueFlags = map[string]bool{
+	"--network":      true,
+	"--net":          true,
+	"--pid":          true,
+	"--userns":       true,
+	"--ipc":          true,
+	"--uts":          true,
+	"--volume":       true,
+	"-v":             true,
+	"--mount":        true,
+	"--cap-add":      true,
+	"--security-opt": true,
+	"--device":       true,
+}
+
+// hostNamespaces are the namespaces other than the network and PID ones which a privileged
+// container may share with the host, by docker run flag.
+var hostNamespaces = map[string]string{
+	"--userns": "user",
+	"--ipc":    "IPC",
+	"--uts":    "UTS",
+}
+
+// confinements are the security options which disable a confinement of the container when
+// set to unconfined (i.e. seccomp=unconfined, or seccomp:unconfined with the legacy syntax).
+var confinements = []string{"seccomp", "apparmor", "systempaths"}
+
+// dockerSocketDirs are the directories which expose the docker socket when mounted.
+var dockerSocketDirs = []string{"/", "/var", "/var/run", "/run"}
+
+func init() {
+	checks.Register("containerRuntime", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewContainerRuntimeCheck(config, job.JobName, job.DockerRunArgs)
+		return &check
+	},
+		checks.Option{Name: "allowPrivileged", Type: checks.BoolOption},
+		checks.Option{Name: "allowHostNetwork", Type: checks.BoolOption},
+		checks.Option{Name: "allowHostPid", Type: checks.BoolOption},
+		checks.Option{Name: "allowDockerSocket", Type: checks.BoolOption},
+		checks.Option{Name: "allowedCapabilities", Type: checks.ListOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+type ContainerRuntimeCheck struct {
+	jobName             string
+	dockerRunArgs       string
+	allowPrivileged     bool
+	allowHostNetwork    bool
+	allowHostPid        bool
+	allowDockerSocket   bool
+	allowedCapabilities []string
+	abortOnFail         bool
+	mfaOnFail           bool
+}
+
+// NewContainerRuntimeCheck creates a check of the docker run arguments of the job container.
+// The services of GitLab jobs are not checked: the custom executor does not start them and
+// GitLab does not tell it which services the job has, CUSTOM_ENV_CI_SERVICES would be a job
+// variable the job is free to set.
+func NewContainerRuntimeCheck(config config.CheckConfig, jobName string, dockerRunArgs string) ContainerRuntimeCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return ContainerRuntimeCheck{
+		jobName:             jobName,
+		dockerRunArgs:       dockerRunArgs,
+		allowPrivileged:     checks.GetBoolOption(config.Options, "allowPrivileged", false),
+		allowHostNetwork:    checks.GetBoolOption(config.Options, "allowHostNetwork", false),
+		allowHostPid:        checks.GetBoolOption(config.Options, "allowHostPid", false),
+		allowDockerSocket:   checks.GetBoolOption(config.Options, "allowDockerSocket", false),
+		allowedCapabilities: checks.GetStringListOption(config.Options, "allowedCapabilities"),
+		abortOnFail:         abortOnFail,
+		mfaOnFail:           mfaOnFail,
+	}
+}
+
+// RunArgument is a docker run flag with its value, Arg is the flag as given on the command
+// line (i.e. "--network host").
+type RunArgument struct {
+	Flag  string
+	Value string
+	Arg   string
+}
+
+// ParseRunArguments splits the docker run arguments on whitespace, as the unquoted expansion
+// of $DOCKER_RUN_ARGS in prepare.sh does, and pairs the flags with their values. Arguments
+// which are not flags are skipped.
+func ParseRunArguments(dockerRunArgs string) []RunArgument {
+	var arguments []RunArgument
+
+	fields := strings.Fields(dockerRunArgs)
+	for i := 0; i < len(fields); i++ {
+		if !strings.HasPrefix(fields[i], "-") {
+			continue
+		}
+
+		argument := RunArgument{Flag: fields[i], Arg: fields[i]}
+		if flag, value, found := strings.Cut(fields[i], "="); found {
+			argument.Flag = flag
+			argument.Value = value
+		} else if strings.HasPrefix(fields[i], "-v") && len(fields[i]) > 2 {
+			argument.Flag = "-v"
+			argument.Value = fields[i][2:]
+		} else if valueFlags[fields[i]] && i+1 < len(fields) {
+			i++
+			argument.Value = fields[i]
+			argument.Arg += " " + fields[i]
+		}
+
+		arguments = append(arguments, argument)
+	}
+
+	return arguments
+}
+
+func (check *ContainerRuntimeCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: ContainerRuntimeCheckName, Version: ContainerRuntimeCheckVersion}
+
+	violations := check.violations()
+	if len(violations) == 0 {
+		result.Details = fmt.Sprintf(ContainerRuntimeCheckSuccess, strings.Join(strings.Fields(check.dockerRunArgs), " "))
+	} else {
+		result.Details = ContainerRuntimeCheckFound + strings.Join(violations, "")
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+// violations returns the run arguments the policy does not allow. Sharing the user, IPC or UTS
+// namespace of the host, unconfined security options and host devices weaken the isolation of
+// the container like --privileged does and are only allowed along with it.
+func (check *ContainerRuntimeCheck) violations() []string {
+	var violations []string
+
+	for _, argument := range ParseRunArguments(check.dockerRunArgs) {
+		switch argument.Flag {
+		case "--privileged":
+			if !check.allowPrivileged && argument.Value != "false" {
+				violations = append(violations, fmt.Sprintf(ContainerRuntimeCheckPrivileged, argument.Arg))
+			}
+		case "--network", "--net":
+			if !check.allowHostNetwork && argument.Value == "host" {
+				violations = append(violations, fmt.Sprintf(ContainerRuntimeCheckHostNetwork, argument.Arg))
+			}
+		case "--pid":
+			if !check.allowHostPid && argument.Value == "host" {
+				violations = append(violations, fmt.Sprintf(ContainerRuntimeCheckHostPid, argument.Arg))
+			}
+		case "--userns", "--ipc", "--uts":
+			if !check.allowPrivileged && argument.Value == "host" {
+				violations = append(violations, fmt.Sprintf(ContainerRuntimeCheckHostNs, argument.Arg, hostNamespaces[argument.Flag]))
+			}
+		case "--security-opt":
+			if confinement := unconfined(argument.Value); !check.allowPrivileged && len(confinement) != 0 {
+				violations = append(violations, fmt.Sprintf(ContainerRuntimeCheckUnconfined, argument.Arg, confinement))
+			}
+		case "--device":
+			if !check.allowPrivileged {
+				violations = append(violations, fmt.Sprintf(ContainerRuntimeCheckDevice, argument.Arg))
+			}
+		case "--volume", "-v", "--mount":
+			if !check.allowDockerSocket && isDockerSocket(mountSource(argument)) {
+				violations = append(violations, fmt.Sprintf(ContainerRuntimeCheckDockerSocket, argument.Arg))
+			}
+		case "--cap-add":
+			if capability := normalizeCapability(argument.Value); !check.isAllowedCapability(capability) {
+				violations = append(violations, fmt.Sprintf(ContainerRuntimeCheckCapability, argument.Arg, capability))
+			}
+		}
+	}
+
+	return violations
+}
+
+func (check *ContainerRuntimeCheck) isAllowedCapability(capability string) bool {
+	for _, allowed := range check.allowedCapabilities {
+		if normalizeCapability(allowed) == capability {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeCapability returns the capability in the form docker accepts it in without the
+// CAP_ prefix (i.e. "cap_net_admin" is "NET_ADMIN").
+func normalizeCapability(capability string) string {
+	return strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(capability)), "CAP_")
+}
+
+// unconfined returns the confinement a security option disables (i.e. "seccomp" for
+// seccomp=unconfined) or an empty string.
+func unconfined(securityOpt string) string {
+	for _, confinement := range confinements {
+		for _, separator := range []string{"=", ":"} {
+			if strings.EqualFold(securityOpt, confinement+separator+"unconfined") {
+				return confinement
+			}
+		}
+	}
+
+	return ""
+}
+
+// mountSource returns the host path of a volume (source:target[:options]) or of a mount
+// (type=bind,source=...,target=...).
+func mountSource(argument RunArgument) string {
+	if argument.Flag != "--mount" {
+		source, _, _ := strings.Cut(argument.Value, ":")
+		return source
+	}
+
+	for _, field := range strings.Split(argument.Value, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		if key == "source" || key == "src" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// isDockerSocket returns whether the host path is the docker socket or one of the
+// directories it is in.
+func isDockerSocket(source string) bool {
+	if !strings.HasPrefix(source, "/") {
+		return false
+	}
+
+	source = path.Clean(source)
+	if path.Base(source) == "docker.sock" {
+		return true
+	}
+
+	for _, dir := range dockerSocketDirs {
+		if source == dir {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (check *ContainerRuntimeCheck) IsValidForCheckType(checkType uint) bool {
+	switch checkType {
+	case checks.All:
+		return true
+	default:
+		return checkType == checks.ImageCheck
+	}
+}
+
+func (check *ContainerRuntimeCheck) IsValidForPlatform(ciPlatform string) bool {
+	return strings.EqualFold(ciPlatform, "gitlab")
+}
+
+func (check *ContainerRuntimeCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -0,0 +1,209 @@
+package containerruntime
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+func TestNewContainerRuntimeCheck(t *testing.T) {
+	newContainerRuntimeCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck ContainerRuntimeCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "containerRuntime",
+			},
+			expectedCheck: ContainerRuntimeCheck{
+				jobName:       "testJob",
+				dockerRunArgs: "--volume git_repo:/gitrepo",
+				abortOnFail:   true,
+			},
+		},
+		{
+			name: "allowances and mfaOnFail",
+			config: config.CheckConfig{
+				Name: "containerRuntime",
+				Options: map[string]interface{}{
+					"allowPrivileged":     true,
+					"allowHostNetwork":    true,
+					"allowHostPid":        true,
+					"allowDockerSocket":   true,
+					"allowedCapabilities": []interface{}{"NET_ADMIN"},
+					"mfaOnFail":           true,
+				},
+			},
+			expectedCheck: ContainerRuntimeCheck{
+				jobName:             "testJob",
+				dockerRunArgs:       "--volume git_repo:/gitrepo",
+				allowPrivileged:     true,
+				allowHostNetwork:    true,
+				allowHostPid:        true,
+				allowDockerSocket:   true,
+				allowedCapabilities: []string{"NET_ADMIN"},
+				mfaOnFail:           true,
+			},
+		},
+	}
+
+	for testNum, test := range newContainerRuntimeCheckTests {
+		check := NewContainerRuntimeCheck(test.config, "testJob", "--volume git_repo:/gitrepo")
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestParseRunArguments(t *testing.T) {
+	dockerRunArgs := "--privileged --network host\t--pid=host -v/var/run/docker.sock:/var/run/docker.sock  --mount type=bind,src=/run,dst=/host-run --cap-add SYS_ADMIN image"
+	expectedArguments := []RunArgument{
+		{Flag: "--privileged", Arg: "--privileged"},
+		{Flag: "--network", Value: "host", Arg: "--network host"},
+		{Flag: "--pid", Value: "host", Arg: "--pid=host"},
+		{Flag: "-v", Value: "/var/run/docker.sock:/var/run/docker.sock", Arg: "-v/var/run/docker.sock:/var/run/docker.sock"},
+		{Flag: "--mount", Value: "type=bind,src=/run,dst=/host-run", Arg: "--mount type=bind,src=/run,dst=/host-run"},
+		{Flag: "--cap-add", Value: "SYS_ADMIN", Arg: "--cap-add SYS_ADMIN"},
+	}
+
+	arguments := ParseRunArguments(dockerRunArgs)
+	if !reflect.DeepEqual(arguments, expectedArguments) {
+		t.Errorf("arguments not equal -\nexpected: (%+v)\ngot: (%+v)", expectedArguments, arguments)
+	}
+}
+
+func TestContainerRuntimeCheck(t *testing.T) {
+	containerRuntimeCheckTests := []struct {
+		name           string
+		options        map[string]interface{}
+		dockerRunArgs  string
+		expectedResult checks.CheckResult
+	}{
+		{
+			name:          "unprivileged container",
+			dockerRunArgs: "--volume git_repo:/gitrepo  --network ci --cap-drop ALL --security-opt seccomp=/etc/docker/seccomp.json --ipc=private",
+			expectedResult: checks.CheckResult{
+				Name:    ContainerRuntimeCheckName,
+				Version: ContainerRuntimeCheckVersion,
+				Details: "The docker run arguments \"--volume git_repo:/gitrepo --network ci --cap-drop ALL --security-opt seccomp=/etc/docker/seccomp.json --ipc=private\" follow the runtime policy",
+			},
+		},
+		{
+			name:          "privileged container with host namespaces - abort",
+			dockerRunArgs: "--privileged --net=host --pid host",
+			expectedResult: checks.CheckResult{
+				Name:     ContainerRuntimeCheckName,
+				Version:  ContainerRuntimeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details: "Container Runtime Policy Violated:" +
+					"\n - \"--privileged\" runs the container privileged" +
+					"\n - \"--net=host\" shares the host network namespace" +
+					"\n - \"--pid host\" shares the host PID namespace",
+			},
+		},
+		{
+			name:          "docker socket mounts - mfa",
+			options:       map[string]interface{}{"mfaOnFail": true},
+			dockerRunArgs: "-v /var/run/docker.sock:/var/run/docker.sock --volume=/run:/host-run:ro --mount type=bind,source=/var/run/docker.sock,target=/docker.sock -v cache:/cache",
+			expectedResult: checks.CheckResult{
+				Name:     ContainerRuntimeCheckName,
+				Version:  ContainerRuntimeCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details: "Container Runtime Policy Violated:" +
+					"\n - \"-v /var/run/docker.sock:/var/run/docker.sock\" mounts the docker socket" +
+					"\n - \"--volume=/run:/host-run:ro\" mounts the docker socket" +
+					"\n - \"--mount type=bind,source=/var/run/docker.sock,target=/docker.sock\" mounts the docker socket",
+			},
+		},
+		{
+			name:          "capabilities not allowed - abort",
+			options:       map[string]interface{}{"allowedCapabilities": []interface{}{"CAP_NET_ADMIN"}},
+			dockerRunArgs: "--cap-add=net_admin --cap-add SYS_ADMIN --cap-add ALL",
+			expectedResult: checks.CheckResult{
+				Name:     ContainerRuntimeCheckName,
+				Version:  ContainerRuntimeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details: "Container Runtime Policy Violated:" +
+					"\n - \"--cap-add SYS_ADMIN\" adds the capability SYS_ADMIN" +
+					"\n - \"--cap-add ALL\" adds the capability ALL",
+			},
+		},
+		{
+			name:          "host namespaces, unconfined security options and devices - abort",
+			dockerRunArgs: "--userns=host --ipc host --uts=host --security-opt seccomp=unconfined --security-opt=apparmor:unconfined --device /dev/kvm",
+			expectedResult: checks.CheckResult{
+				Name:     ContainerRuntimeCheckName,
+				Version:  ContainerRuntimeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details: "Container Runtime Policy Violated:" +
+					"\n - \"--userns=host\" shares the host user namespace" +
+					"\n - \"--ipc host\" shares the host IPC namespace" +
+					"\n - \"--uts=host\" shares the host UTS namespace" +
+					"\n - \"--security-opt seccomp=unconfined\" disables the seccomp confinement" +
+					"\n - \"--security-opt=apparmor:unconfined\" disables the apparmor confinement" +
+					"\n - \"--device /dev/kvm\" gives the container access to a host device",
+			},
+		},
+		{
+			name: "explicitly allowed",
+			options: map[string]interface{}{
+				"allowPrivileged":     true,
+				"allowHostNetwork":    true,
+				"allowHostPid":        true,
+				"allowDockerSocket":   true,
+				"allowedCapabilities": []interface{}{"SYS_ADMIN"},
+			},
+			dockerRunArgs: "--privileged --network=host --pid=host --ipc=host --security-opt seccomp=unconfined --device /dev/fuse -v /var/run/docker.sock:/var/run/docker.sock --cap-add SYS_ADMIN",
+			expectedResult: checks.CheckResult{
+				Name:    ContainerRuntimeCheckName,
+				Version: ContainerRuntimeCheckVersion,
+				Details: "The docker run arguments \"--privileged --network=host --pid=host --ipc=host --security-opt seccomp=unconfined --device /dev/fuse -v /var/run/docker.sock:/var/run/docker.sock --cap-add SYS_ADMIN\" follow the runtime policy",
+			},
+		},
+	}
+
+	for testNum, test := range containerRuntimeCheckTests {
+		check := NewContainerRuntimeCheck(config.CheckConfig{Name: "containerRuntime", Options: test.options}, "testJob", test.dockerRunArgs)
+
+		result := runCheck(&check)
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestContainerRuntimeCheckIsValid(t *testing.T) {
+	check := ContainerRuntimeCheck{}
+
+	if !check.IsValidForPlatform("gitlab") || check.IsValidForPlatform("github") {
+		t.Errorf("the check must only be valid for GitLab")
+	}
+
+	if !check.IsValidForCheckType(checks.ImageCheck) || !check.IsValidForCheckType(checks.All) || check.IsValidForCheckType(checks.ScriptCheck) {
+		t.Errorf("the check must only be valid for image checks")
+	}
+}
+
+func runCheck(check *ContainerRuntimeCheck) checks.CheckResult {
+	var wg sync.WaitGroup
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+	wg.Wait()
+	close(channel)
+
+	return <-channel
+}
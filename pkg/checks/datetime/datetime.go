@@ -1,22 +1,32 @@
 package datetime
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	// embed the timezone database so the timezone option works on runners without one
+	_ "time/tzdata"
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/cron"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
 )
 
 var (
 	DateTimeCheckName           = "Date Time Check"
-	DateTimeCheckVersion        = "1.0.0"
+	DateTimeCheckVersion        = "1.1.0"
 	DateTimeCheckTimeNotAllowed = "Current Time Not Within Allowed Time"
 	DateTimeCheckDateNotAllowed = "Current Date Not Within Allowed Date"
 	DateTimeCheckSuccess        = "Successful Datetime Check"
+	DateTimeCheckError          = "---ERROR---"
+	ErrInvalidTimezone          = errors.New("invalid timezone")
+	ErrInvalidWindow            = errors.New("invalid time window. Expected: {\"start\": \"HH:MM[:SS]\", \"end\": \"HH:MM[:SS]\"}")
+	ErrInvalidTime              = errors.New("invalid time. Expected: \"HH:MM:SS\"")
 )
 
 const (
@@ -26,6 +36,29 @@ const (
 	yearly  = 3
 )
 
+func init() {
+	checks.Register("dateTimeCheck", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewDateTimeCheck(config, job.JobName)
+		return &check
+	},
+		checks.Option{Name: "scale", Type: checks.StringOption},
+		checks.Option{Name: "intervals", Type: checks.ListOption},
+		checks.Option{Name: "tolerance", Type: checks.NumberOption},
+		checks.Option{Name: "time", Type: checks.StringOption},
+		checks.Option{Name: "cron", Type: checks.ListOption},
+		checks.Option{Name: "windows", Type: checks.ListOption},
+		checks.Option{Name: "timezone", Type: checks.StringOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+// window is a time of day range, the end may be before the start for windows spanning midnight.
+type window struct {
+	start time.Duration
+	end   time.Duration
+}
+
 type DateTimeCheck struct {
 	jobName     string
 	timeScale   uint
@@ -36,9 +69,27 @@ type DateTimeCheck struct {
 	hours       int
 	minutes     int
 	seconds     int
+	schedules   []cron.Schedule
+	windows     []window
+	location    *time.Location
+	now         func() time.Time
+	configErr   error
 }
 
 func NewDateTimeCheck(config config.CheckConfig, jobName string) DateTimeCheck {
+	return NewDateTimeCheckWithClock(config, jobName, time.Now)
+}
+
+// NewDateTimeCheckWithClock creates a date time check which reads the current time from the
+// given clock instead of time.Now.
+//
+// The allowed execution time is given (in order of precedence) by:
+//   - cron: cron expressions starting a window of tolerance seconds (scale and intervals are ignored)
+//   - windows: time of day {start, end} ranges on the days given by scale and intervals
+//   - time: the start of a window of tolerance seconds on the days given by scale and intervals
+//
+// All times are in the IANA timezone option (the local timezone if not set).
+func NewDateTimeCheckWithClock(config config.CheckConfig, jobName string, now func() time.Time) DateTimeCheck {
 	timeScale := uint(daily)
 	scale, exists := config.Options["scale"].(string)
 	if exists {
@@ -56,14 +107,41 @@ func NewDateTimeCheck(config config.CheckConfig, jobName string) DateTimeCheck {
 		}
 	}
 
-	intervals, exists := config.Options["intervals"].([]int)
-	if !exists {
+	intervals := checks.GetIntListOption(config.Options, "intervals")
+	if len(intervals) == 0 {
 		intervals = []int{0}
 	}
 
-	tolerance, exists := config.Options["tolerance"].(int)
-	if !exists {
-		tolerance = 300
+	tolerance := checks.GetIntOption(config.Options, "tolerance", 300)
+
+	var configErr error
+	location := time.Local
+	timezone, exists := config.Options["timezone"].(string)
+	if exists {
+		location, configErr = time.LoadLocation(timezone)
+		if configErr != nil {
+			location = time.Local
+			configErr = fmt.Errorf("%w %q: %s", ErrInvalidTimezone, timezone, configErr.Error())
+		}
+	}
+
+	var schedules []cron.Schedule
+	cronExpressions := checks.GetStringListOption(config.Options, "cron")
+	if expression, exists := config.Options["cron"].(string); exists {
+		cronExpressions = []string{expression}
+	}
+
+	for _, expression := range cronExpressions {
+		schedule, err := cron.Parse(expression)
+		if err != nil && configErr == nil {
+			configErr = err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	windows, err := parseWindows(config.Options["windows"])
+	if err != nil && configErr == nil {
+		configErr = err
 	}
 
 	abortOnFail, exists := config.Options["abortOnFail"].(bool)
@@ -76,33 +154,20 @@ func NewDateTimeCheck(config config.CheckConfig, jobName string) DateTimeCheck {
 		mfaOnFail = false
 	}
 
-	hours := time.Now().Hour()
-	minutes := time.Now().Minute()
-	seconds := time.Now().Second()
-	var err error
+	current := now().In(location)
+	hours := current.Hour()
+	minutes := current.Minute()
+	seconds := current.Second()
 
 	timeString, exists := config.Options["time"].(string)
 	if exists {
-		timeIntervals := strings.Split(timeString, ":")
-		if len(timeIntervals) != 3 {
-			hours = time.Now().Hour()
-			minutes = time.Now().Minute()
-			seconds = time.Now().Second()
-		} else {
-			hours, err = strconv.Atoi(timeIntervals[0])
-			if err != nil {
-				hours = time.Now().Hour()
-			}
-
-			minutes, err = strconv.Atoi(timeIntervals[1])
-			if err != nil {
-				minutes = time.Now().Minute()
-			}
-
-			seconds, err = strconv.Atoi(timeIntervals[2])
-			if err != nil {
-				seconds = time.Now().Minute()
-			}
+		timeOfDay, err := parseTime(timeString)
+		if err == nil {
+			hours = int(timeOfDay / time.Hour)
+			minutes = int(timeOfDay % time.Hour / time.Minute)
+			seconds = int(timeOfDay % time.Minute / time.Second)
+		} else if configErr == nil {
+			configErr = err
 		}
 	}
 
@@ -116,25 +181,131 @@ func NewDateTimeCheck(config config.CheckConfig, jobName string) DateTimeCheck {
 		hours:       hours,
 		minutes:     minutes,
 		seconds:     seconds,
+		schedules:   schedules,
+		windows:     windows,
+		location:    location,
+		now:         now,
+		configErr:   configErr,
 	}
 
 	return dateTimeCheck
 }
 
-func (check *DateTimeCheck) Check(channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+// parseWindows parses the windows option, a list of {"start": "HH:MM[:SS]", "end": "HH:MM[:SS]"}.
+func parseWindows(option interface{}) ([]window, error) {
+	values, ok := option.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var windows []window
+	for _, value := range values {
+		rangeOption, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidWindow
+		}
+
+		startString, _ := rangeOption["start"].(string)
+		endString, _ := rangeOption["end"].(string)
+
+		start, err := parseTimeOfDay(startString)
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseTimeOfDay(endString)
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, window{start: start, end: end})
+	}
+
+	return windows, nil
+}
+
+// parseTime parses the "HH:MM:SS" time option.
+func parseTime(timeString string) (time.Duration, error) {
+	if len(strings.Split(timeString, ":")) != 3 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidTime, timeString)
+	}
+
+	timeOfDay, ok := parseClock(timeString)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidTime, timeString)
+	}
+
+	return timeOfDay, nil
+}
+
+func parseTimeOfDay(timeString string) (time.Duration, error) {
+	timeOfDay, ok := parseClock(timeString)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidWindow, timeString)
+	}
+
+	return timeOfDay, nil
+}
+
+// parseClock parses a "HH:MM" or "HH:MM:SS" time of day and returns whether it is valid.
+func parseClock(timeString string) (time.Duration, bool) {
+	parts := strings.Split(timeString, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return 0, false
+	}
+
+	limits := []int{23, 59, 59}
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+
+	var timeOfDay time.Duration
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 || value > limits[i] {
+			return 0, false
+		}
+		timeOfDay += time.Duration(value) * units[i]
+	}
+
+	return timeOfDay, true
+}
+
+func (check *DateTimeCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
 	defer wg.Done()
 
 	result := checks.CheckResult{Name: DateTimeCheckName, Version: DateTimeCheckVersion, Details: ""}
 
-	if !check.checkWithinTime() {
+	if check.configErr != nil {
+		result.Error = check.configErr
+		result.Details = DateTimeCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	if len(check.schedules) != 0 {
+		if !check.checkCron() {
+			result.Details = DateTimeCheckTimeNotAllowed
+			result.Fail(check.abortOnFail, check.mfaOnFail)
+		} else {
+			result.Details = DateTimeCheckSuccess
+		}
+		channel <- result
+		return
+	}
+
+	withinTime := false
+	if len(check.windows) != 0 {
+		withinTime = check.checkWithinWindows()
+	} else {
+		withinTime = check.checkWithinTime()
+	}
+
+	if !withinTime {
 		result.Details = DateTimeCheckTimeNotAllowed
+		result.Fail(check.abortOnFail, check.mfaOnFail)
 
-		if check.abortOnFail {
-			result.Abort = true
-			channel <- result
-			return
-		} else if check.mfaOnFail {
-			result.Mfa = true
+		// a warning goes on to report whether the day is allowed as well
+		if result.Outcome != checks.OutcomeWarn {
 			channel <- result
 			return
 		}
@@ -142,16 +313,7 @@ func (check *DateTimeCheck) Check(channel chan<- checks.CheckResult, wg *sync.Wa
 
 	if !check.checkOnRightDay() {
 		result.Details += DateTimeCheckDateNotAllowed
-
-		if check.abortOnFail {
-			result.Abort = true
-			channel <- result
-			return
-		} else if check.mfaOnFail {
-			result.Mfa = true
-			channel <- result
-			return
-		}
+		result.Fail(check.abortOnFail, check.mfaOnFail)
 	}
 
 	if len(result.Details) == 0 {
@@ -161,51 +323,75 @@ func (check *DateTimeCheck) Check(channel chan<- checks.CheckResult, wg *sync.Wa
 	channel <- result
 }
 
+// currentTime returns the current time of the check's clock in the check's timezone.
+func (check *DateTimeCheck) currentTime() time.Time {
+	now := check.now
+	if now == nil {
+		now = time.Now
+	}
+
+	location := check.location
+	if location == nil {
+		location = time.Local
+	}
+
+	return now().In(location)
+}
+
 func (check *DateTimeCheck) checkWithinTime() bool {
-	now := time.Now()
+	now := check.currentTime()
 
-	startTime := time.Date(now.Year(), now.Month(), now.Day(), check.hours, check.minutes, check.seconds, 0, time.Local)
+	startTime := time.Date(now.Year(), now.Month(), now.Day(), check.hours, check.minutes, check.seconds, 0, now.Location())
+	endTime := startTime.Add(time.Duration(check.tolerance) * time.Second)
 
-	toleranceSeconds := check.seconds + check.tolerance
-	addedMintues := toleranceSeconds / 60
-	endSeconds := toleranceSeconds % 60
+	return !now.Before(startTime) && !now.After(endTime)
+}
 
-	toleranceMinutes := check.minutes + addedMintues
-	addedHours := toleranceMinutes / 60
-	endMinutes := toleranceMinutes % 60
+func (check *DateTimeCheck) checkWithinWindows() bool {
+	now := check.currentTime()
+	timeOfDay := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second
 
-	toleranceHours := check.hours + addedHours
-	addedDays := toleranceHours / 24
-	endHours := toleranceHours % 60
+	for _, w := range check.windows {
+		if w.start <= w.end && timeOfDay >= w.start && timeOfDay <= w.end {
+			return true
+		}
 
-	endTime := time.Date(now.Year(), now.Month(), now.Day()+addedDays, endHours, endMinutes, endSeconds, 0, time.Local)
+		// the window spans midnight
+		if w.start > w.end && (timeOfDay >= w.start || timeOfDay <= w.end) {
+			return true
+		}
+	}
 
-	checkTime := time.Date(now.Year(), now.Month(), now.Day(), time.Now().Hour(), time.Now().Minute(), time.Now().Second(), 0, time.Local)
+	return false
+}
 
-	beforeStart := startTime.Before(checkTime)
-	afterEnd := endTime.After(checkTime)
+// checkCron returns whether one of the cron schedules fired within the last tolerance seconds.
+func (check *DateTimeCheck) checkCron() bool {
+	now := check.currentTime()
+	tolerance := time.Duration(check.tolerance) * time.Second
+
+	for _, schedule := range check.schedules {
+		if _, found := schedule.Previous(now, tolerance); found {
+			return true
+		}
+	}
 
-	return (beforeStart && afterEnd) || (startTime.Equal(checkTime) || endTime.Equal(checkTime))
+	return false
 }
 
 func (check *DateTimeCheck) checkOnRightDay() bool {
+	now := check.currentTime()
 	for _, interval := range check.intervals {
 		success := false
 		switch check.timeScale {
 		case daily:
 			success = true
 		case weekly:
-			success = int(time.Now().Weekday()) == interval
+			success = int(now.Weekday()) == interval
 		case monthly:
-			success = time.Now().Day() == interval
+			success = now.Day() == interval
 		case yearly:
-			totalDays := 0
-			currentMonth := int(time.Now().Month())
-			for month := 1; month < currentMonth; month++ {
-				totalDays += time.Date(time.Now().Year(), time.Month(month), 0, 0, 0, 0, 0, time.UTC).Day()
-			}
-			totalDays += time.Now().Day()
-			success = totalDays == interval
+			success = now.YearDay() == interval
 		default:
 			success = false
 		}
@@ -225,3 +411,7 @@ func (check *DateTimeCheck) IsValidForCheckType(checkType uint) bool {
 func (check *DateTimeCheck) IsValidForPlatform(ciPlatform string) bool {
 	return true
 }
+
+func (check *DateTimeCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -1,6 +1,8 @@
 package datetime
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"sync"
 	"testing"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/cron"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
 )
 
@@ -194,7 +197,7 @@ func TestNewDateTimeCheck(t *testing.T) {
 			minutes:     30,
 		},
 		{
-			name: "test new date time bad time fall-back",
+			name: "test new date time bad time (time not set)",
 			config: config.CheckConfig{
 				Name: "datetimeCheck",
 				Options: map[string]interface{}{
@@ -388,13 +391,12 @@ func TestCheckWithinTime(t *testing.T) {
 }
 func TestCheckOnRightDay(t *testing.T) {
 	getCurrentDayYear := func() int {
-		totalDays := 0
-		currentMonth := int(time.Now().Month())
-		for month := 1; month < currentMonth; month++ {
-			totalDays += time.Date(time.Now().Year(), time.Month(month), 0, 0, 0, 0, 0, time.UTC).Day()
-		}
-		totalDays += time.Now().Day()
-		return totalDays
+		return time.Now().YearDay()
+	}
+
+	// 2024 is a leap year, 1st of March is its 61st day
+	firstOfMarch := func() time.Time {
+		return time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
 	}
 
 	checkOnRightDayTests := []struct {
@@ -458,6 +460,26 @@ func TestCheckOnRightDay(t *testing.T) {
 			},
 			rightDay: false,
 		},
+		{
+			name: "test on right day (yearly, leap year)",
+			dateTimeCheck: DateTimeCheck{
+				intervals: []int{61},
+				timeScale: yearly,
+				location:  time.UTC,
+				now:       firstOfMarch,
+			},
+			rightDay: true,
+		},
+		{
+			name: "test on wrong day (yearly, leap year)",
+			dateTimeCheck: DateTimeCheck{
+				intervals: []int{60},
+				timeScale: yearly,
+				location:  time.UTC,
+				now:       firstOfMarch,
+			},
+			rightDay: false,
+		},
 	}
 
 	for testNum, test := range checkOnRightDayTests {
@@ -490,8 +512,6 @@ func TestDateTimeCheck(t *testing.T) {
 				Name:    DateTimeCheckName,
 				Version: DateTimeCheckVersion,
 				Error:   nil,
-				Abort:   false,
-				Mfa:     false,
 				Details: DateTimeCheckSuccess,
 			},
 		},
@@ -509,12 +529,12 @@ func TestDateTimeCheck(t *testing.T) {
 				seconds:     time.Now().Second(),
 			},
 			expectedResult: checks.CheckResult{
-				Name:    DateTimeCheckName,
-				Version: DateTimeCheckVersion,
-				Error:   nil,
-				Abort:   true,
-				Mfa:     false,
-				Details: DateTimeCheckTimeNotAllowed,
+				Name:     DateTimeCheckName,
+				Version:  DateTimeCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  DateTimeCheckTimeNotAllowed,
 			},
 		},
 		{
@@ -531,12 +551,12 @@ func TestDateTimeCheck(t *testing.T) {
 				seconds:     time.Now().Second(),
 			},
 			expectedResult: checks.CheckResult{
-				Name:    DateTimeCheckName,
-				Version: DateTimeCheckVersion,
-				Error:   nil,
-				Abort:   false,
-				Mfa:     true,
-				Details: DateTimeCheckTimeNotAllowed,
+				Name:     DateTimeCheckName,
+				Version:  DateTimeCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  DateTimeCheckTimeNotAllowed,
 			},
 		},
 		{
@@ -553,12 +573,12 @@ func TestDateTimeCheck(t *testing.T) {
 				seconds:     time.Now().Second(),
 			},
 			expectedResult: checks.CheckResult{
-				Name:    DateTimeCheckName,
-				Version: DateTimeCheckVersion,
-				Error:   nil,
-				Abort:   true,
-				Mfa:     false,
-				Details: DateTimeCheckDateNotAllowed,
+				Name:     DateTimeCheckName,
+				Version:  DateTimeCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  DateTimeCheckDateNotAllowed,
 			},
 		},
 		{
@@ -575,12 +595,12 @@ func TestDateTimeCheck(t *testing.T) {
 				seconds:     time.Now().Second(),
 			},
 			expectedResult: checks.CheckResult{
-				Name:    DateTimeCheckName,
-				Version: DateTimeCheckVersion,
-				Error:   nil,
-				Abort:   false,
-				Mfa:     true,
-				Details: DateTimeCheckDateNotAllowed,
+				Name:     DateTimeCheckName,
+				Version:  DateTimeCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  DateTimeCheckDateNotAllowed,
 			},
 		},
 	}
@@ -591,7 +611,7 @@ func TestDateTimeCheck(t *testing.T) {
 		channel := make(chan checks.CheckResult, 1)
 
 		wg.Add(1)
-		go test.dateTimeCheck.Check(channel, &wg, whitelist)
+		go test.dateTimeCheck.Check(context.Background(), channel, &wg, whitelist)
 
 		wg.Wait()
 		close(channel)
@@ -603,3 +623,378 @@ func TestDateTimeCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestNewDateTimeCheckJsonOptions(t *testing.T) {
+	zurich, err := time.LoadLocation("Europe/Zurich")
+	if err != nil {
+		t.Fatalf("unable to load timezone: %s", err.Error())
+	}
+
+	now := func() time.Time {
+		return time.Date(2024, time.January, 15, 9, 30, 0, 0, time.UTC)
+	}
+
+	newDateTimeCheckJsonOptionsTests := []struct {
+		name      string
+		config    config.CheckConfig
+		intervals []int
+		tolerance int
+		location  *time.Location
+		hours     int
+		windows   []window
+		schedules int
+		err       error
+	}{
+		{
+			name: "json decoded intervals and tolerance",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"scale":     "weekly",
+					"intervals": []interface{}{float64(1), float64(5)},
+					"tolerance": float64(600),
+				},
+			},
+			intervals: []int{1, 5},
+			tolerance: 600,
+			location:  time.Local,
+			hours:     now().In(time.Local).Hour(),
+		},
+		{
+			name: "timezone",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"timezone": "Europe/Zurich",
+				},
+			},
+			intervals: []int{0},
+			tolerance: 300,
+			location:  zurich,
+			hours:     10,
+		},
+		{
+			name: "windows and cron",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"timezone": "Europe/Zurich",
+					"windows": []interface{}{
+						map[string]interface{}{"start": "08:00", "end": "10:30:15"},
+						map[string]interface{}{"start": "22:00", "end": "02:00"},
+					},
+					"cron": []interface{}{"0 2 * * mon-fri", "30 * * * sat"},
+				},
+			},
+			intervals: []int{0},
+			tolerance: 300,
+			location:  zurich,
+			hours:     10,
+			windows: []window{
+				{start: 8 * time.Hour, end: 10*time.Hour + 30*time.Minute + 15*time.Second},
+				{start: 22 * time.Hour, end: 2 * time.Hour},
+			},
+			schedules: 2,
+		},
+		{
+			name: "invalid timezone",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"timezone": "Europe/Atlantis",
+				},
+			},
+			intervals: []int{0},
+			tolerance: 300,
+			location:  time.Local,
+			hours:     now().In(time.Local).Hour(),
+			err:       ErrInvalidTimezone,
+		},
+		{
+			name: "invalid window",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"windows": []interface{}{
+						map[string]interface{}{"start": "25:00", "end": "10:00"},
+					},
+				},
+			},
+			intervals: []int{0},
+			tolerance: 300,
+			location:  time.Local,
+			hours:     now().In(time.Local).Hour(),
+			err:       ErrInvalidWindow,
+		},
+		{
+			name: "invalid cron expression",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"cron": "0 2 * *",
+				},
+			},
+			intervals: []int{0},
+			tolerance: 300,
+			location:  time.Local,
+			hours:     now().In(time.Local).Hour(),
+			schedules: 1,
+			err:       cron.ErrInvalidExpression,
+		},
+		{
+			name: "time without seconds",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"time": "04:04",
+				},
+			},
+			intervals: []int{0},
+			tolerance: 300,
+			location:  time.Local,
+			hours:     now().In(time.Local).Hour(),
+			err:       ErrInvalidTime,
+		},
+		{
+			name: "time with letters",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"time": "HH:MM:SS",
+				},
+			},
+			intervals: []int{0},
+			tolerance: 300,
+			location:  time.Local,
+			hours:     now().In(time.Local).Hour(),
+			err:       ErrInvalidTime,
+		},
+		{
+			name: "time out of range",
+			config: config.CheckConfig{
+				Name: "dateTimeCheck",
+				Options: map[string]interface{}{
+					"time": "24:00:00",
+				},
+			},
+			intervals: []int{0},
+			tolerance: 300,
+			location:  time.Local,
+			hours:     now().In(time.Local).Hour(),
+			err:       ErrInvalidTime,
+		},
+	}
+
+	for testNum, test := range newDateTimeCheckJsonOptionsTests {
+		check := NewDateTimeCheckWithClock(test.config, "testJob", now)
+
+		if !reflect.DeepEqual(check.intervals, test.intervals) {
+			t.Errorf("\n%d) unexpected intervals for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.intervals, check.intervals)
+		}
+
+		if check.tolerance != test.tolerance {
+			t.Errorf("\n%d) unexpected tolerance for %s -\nexpected: (%d)\ngot: (%d)", testNum, test.name, test.tolerance, check.tolerance)
+		}
+
+		if check.location.String() != test.location.String() {
+			t.Errorf("\n%d) unexpected timezone for %s -\nexpected: (%s)\ngot: (%s)", testNum, test.name, test.location, check.location)
+		}
+
+		if check.hours != test.hours {
+			t.Errorf("\n%d) unexpected hours for %s -\nexpected: (%d)\ngot: (%d)", testNum, test.name, test.hours, check.hours)
+		}
+
+		if !reflect.DeepEqual(check.windows, test.windows) {
+			t.Errorf("\n%d) unexpected windows for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.windows, check.windows)
+		}
+
+		if len(check.schedules) != test.schedules {
+			t.Errorf("\n%d) unexpected number of cron schedules for %s -\nexpected: (%d)\ngot: (%d)", testNum, test.name, test.schedules, len(check.schedules))
+		}
+
+		if !errors.Is(check.configErr, test.err) {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.err, check.configErr)
+		}
+	}
+}
+
+func TestDateTimeCheckWithClock(t *testing.T) {
+	// Monday 2024-01-15 07:30 UTC is 08:30 in Zurich and 02:30 in New York
+	now := func() time.Time {
+		return time.Date(2024, time.January, 15, 7, 30, 0, 0, time.UTC)
+	}
+
+	dateTimeCheckWithClockTests := []struct {
+		name           string
+		options        map[string]interface{}
+		expectedResult checks.CheckResult
+	}{
+		{
+			name: "eu maintenance window",
+			options: map[string]interface{}{
+				"timezone": "Europe/Zurich",
+				"windows": []interface{}{
+					map[string]interface{}{"start": "08:00", "end": "09:00"},
+				},
+			},
+			expectedResult: checks.CheckResult{
+				Name:    DateTimeCheckName,
+				Version: DateTimeCheckVersion,
+				Details: DateTimeCheckSuccess,
+			},
+		},
+		{
+			name: "us maintenance window",
+			options: map[string]interface{}{
+				"timezone": "America/New_York",
+				"windows": []interface{}{
+					map[string]interface{}{"start": "08:00", "end": "09:00"},
+				},
+			},
+			expectedResult: checks.CheckResult{
+				Name:     DateTimeCheckName,
+				Version:  DateTimeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  DateTimeCheckTimeNotAllowed,
+			},
+		},
+		{
+			name: "us window spanning midnight",
+			options: map[string]interface{}{
+				"timezone": "America/New_York",
+				"windows": []interface{}{
+					map[string]interface{}{"start": "08:00", "end": "09:00"},
+					map[string]interface{}{"start": "22:00", "end": "03:00"},
+				},
+			},
+			expectedResult: checks.CheckResult{
+				Name:    DateTimeCheckName,
+				Version: DateTimeCheckVersion,
+				Details: DateTimeCheckSuccess,
+			},
+		},
+		{
+			name: "window on the wrong day",
+			options: map[string]interface{}{
+				"timezone":  "Europe/Zurich",
+				"scale":     "weekly",
+				"intervals": []interface{}{float64(2), float64(4)},
+				"windows": []interface{}{
+					map[string]interface{}{"start": "08:00", "end": "09:00"},
+				},
+				"mfaOnFail":   true,
+				"abortOnFail": false,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     DateTimeCheckName,
+				Version:  DateTimeCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  DateTimeCheckDateNotAllowed,
+			},
+		},
+		{
+			name: "legacy time and tolerance in a timezone",
+			options: map[string]interface{}{
+				"timezone":  "Europe/Zurich",
+				"time":      "08:00:00",
+				"tolerance": float64(3600),
+			},
+			expectedResult: checks.CheckResult{
+				Name:    DateTimeCheckName,
+				Version: DateTimeCheckVersion,
+				Details: DateTimeCheckSuccess,
+			},
+		},
+		{
+			name: "cron window",
+			options: map[string]interface{}{
+				"timezone":  "Europe/Zurich",
+				"cron":      []interface{}{"0 8 * * mon-fri"},
+				"tolerance": float64(3600),
+			},
+			expectedResult: checks.CheckResult{
+				Name:    DateTimeCheckName,
+				Version: DateTimeCheckVersion,
+				Details: DateTimeCheckSuccess,
+			},
+		},
+		{
+			name: "cron window closed",
+			options: map[string]interface{}{
+				"timezone":  "Europe/Zurich",
+				"cron":      []interface{}{"0 8 * * mon-fri"},
+				"tolerance": float64(1200),
+			},
+			expectedResult: checks.CheckResult{
+				Name:     DateTimeCheckName,
+				Version:  DateTimeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  DateTimeCheckTimeNotAllowed,
+			},
+		},
+		{
+			name: "cron on the weekend only",
+			options: map[string]interface{}{
+				"timezone":  "America/New_York",
+				"cron":      "0 2 * * sat,sun",
+				"tolerance": float64(3600),
+			},
+			expectedResult: checks.CheckResult{
+				Name:     DateTimeCheckName,
+				Version:  DateTimeCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  DateTimeCheckTimeNotAllowed,
+			},
+		},
+	}
+
+	whitelist := whitelist.Whitelist{}
+	for testNum, test := range dateTimeCheckWithClockTests {
+		dateTimeCheck := NewDateTimeCheckWithClock(config.CheckConfig{Name: "dateTimeCheck", Options: test.options}, "testJob", now)
+
+		wg := sync.WaitGroup{}
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go dateTimeCheck.Check(context.Background(), channel, &wg, whitelist)
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestDateTimeCheckConfigError(t *testing.T) {
+	dateTimeCheck := NewDateTimeCheck(config.CheckConfig{
+		Name: "dateTimeCheck",
+		Options: map[string]interface{}{
+			"timezone":    "Europe/Atlantis",
+			"abortOnFail": false,
+			"mfaOnFail":   true,
+		},
+	}, "testJob")
+
+	wg := sync.WaitGroup{}
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go dateTimeCheck.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+	wg.Wait()
+	close(channel)
+
+	result := <-channel
+
+	if !errors.Is(result.Error, ErrInvalidTimezone) || result.Outcome != checks.OutcomeMfa || result.Details != DateTimeCheckError {
+		t.Errorf("unexpected result for an invalid timezone - got: (%+v)", result)
+	}
+}
@@ -0,0 +1,247 @@
+package freezewindow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/glob"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	FreezeWindowCheckName      = "Freeze Window Check"
+	FreezeWindowCheckVersion   = "1.0.0"
+	FreezeWindowCheckNoFreeze  = "No active change freeze"
+	FreezeWindowCheckFrozen    = "Change freeze until %s: %s"
+	FreezeWindowCheckExempt    = "Job %s is exempt from the change freeze until %s: %s"
+	FreezeWindowCheckError     = "---ERROR---"
+	ErrNoFreezeVault           = errors.New("no vault available to read the freeze windows")
+	ErrInvalidFreeze           = errors.New("invalid freeze window")
+	freezeWindowMount          = "freeze_windows"
+	freezeWindowNoReason       = "no reason given"
+	freezeWindowDateTimeLayout = "2006-01-02T15:04:05"
+	freezeWindowDateLayout     = "2006-01-02"
+)
+
+func init() {
+	checks.Register("freezeWindow", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewFreezeWindowCheck(config, job.JobName, job.Vault, job.NamespaceMount)
+		return &check
+	},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+// Freeze is a blackout period during which jobs may not run.
+type Freeze struct {
+	// Start and End are ISO-8601 timestamps (i.e. "2024-12-20T18:00:00+01:00"). Timestamps
+	// without an offset and dates (i.e. "2024-12-20") are in the runner's local timezone.
+	// The freeze starts at Start and lasts until (but not including) End.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Reason is reported in the check details (i.e. "End of year release freeze").
+	Reason string `json:"reason"`
+	// ExemptJobs are glob patterns of the job names allowed to run during the freeze.
+	ExemptJobs []string `json:"exemptJobs"`
+}
+
+// FreezeWindows is the document stored in the vault at {root}/{namespace}/freeze_windows.
+//
+// Example:
+//
+//	{
+//		"freezes": [
+//			{
+//				"start": "2024-12-20T18:00:00+01:00",
+//				"end": "2025-01-06",
+//				"reason": "End of year release freeze",
+//				"exemptJobs": ["hotfix-*"]
+//			}
+//		]
+//	}
+type FreezeWindows struct {
+	Freezes []Freeze `json:"freezes"`
+}
+
+type FreezeWindowCheck struct {
+	jobName        string
+	abortOnFail    bool
+	mfaOnFail      bool
+	vault          checks.SecretReader
+	namespaceMount string
+	now            func() time.Time
+}
+
+// NewFreezeWindowCheck creates a check failing while one of the freezes stored in the vault
+// of the namespace is active, unless the job is exempt from it.
+func NewFreezeWindowCheck(config config.CheckConfig, jobName string, vault checks.SecretReader, namespaceMount string) FreezeWindowCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return FreezeWindowCheck{
+		jobName:        jobName,
+		abortOnFail:    abortOnFail,
+		mfaOnFail:      mfaOnFail,
+		vault:          vault,
+		namespaceMount: namespaceMount,
+		now:            time.Now,
+	}
+}
+
+func (check *FreezeWindowCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: FreezeWindowCheckName, Version: FreezeWindowCheckVersion}
+
+	freezes, err := check.readFreezes(ctx)
+	var active, exempt []activeFreeze
+	if err == nil {
+		active, exempt, err = check.activeFreezes(freezes)
+	}
+
+	if err != nil {
+		result.Error = checks.ContextError(ctx, err)
+		result.Details = FreezeWindowCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	if len(active) != 0 {
+		result.Details = fmt.Sprintf(FreezeWindowCheckFrozen, lastEnd(active), reasons(active))
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else if len(exempt) != 0 {
+		result.Details = fmt.Sprintf(FreezeWindowCheckExempt, check.jobName, lastEnd(exempt), reasons(exempt))
+	} else {
+		result.Details = FreezeWindowCheckNoFreeze
+	}
+
+	channel <- result
+}
+
+type activeFreeze struct {
+	end    time.Time
+	reason string
+}
+
+// activeFreezes returns the freezes active now which apply to the job and the ones the job is
+// exempt from. An invalid freeze is an error so that a typo never silently lifts a freeze.
+func (check *FreezeWindowCheck) activeFreezes(freezes FreezeWindows) ([]activeFreeze, []activeFreeze, error) {
+	now := check.now()
+
+	var active, exempt []activeFreeze
+	for i, freeze := range freezes.Freezes {
+		start, err := parseTimestamp(freeze.Start)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w %d: start: %s", ErrInvalidFreeze, i, err.Error())
+		}
+
+		end, err := parseTimestamp(freeze.End)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w %d: end: %s", ErrInvalidFreeze, i, err.Error())
+		}
+
+		if !end.After(start) {
+			return nil, nil, fmt.Errorf("%w %d: end %s is not after start %s", ErrInvalidFreeze, i, freeze.End, freeze.Start)
+		}
+
+		if now.Before(start) || !now.Before(end) {
+			continue
+		}
+
+		reason := strings.TrimSpace(freeze.Reason)
+		if len(reason) == 0 {
+			reason = freezeWindowNoReason
+		}
+
+		if glob.MatchAny(freeze.ExemptJobs, check.jobName) {
+			exempt = append(exempt, activeFreeze{end: end, reason: reason})
+		} else {
+			active = append(active, activeFreeze{end: end, reason: reason})
+		}
+	}
+
+	return active, exempt, nil
+}
+
+func (check *FreezeWindowCheck) readFreezes(ctx context.Context) (FreezeWindows, error) {
+	freezes := FreezeWindows{}
+
+	if check.vault == nil {
+		return freezes, ErrNoFreezeVault
+	}
+
+	mount := check.namespaceMount + "/" + freezeWindowMount
+	vaultRes, err := check.vault.ReadSecret(ctx, mount)
+	if err != nil {
+		return freezes, err
+	}
+
+	if len(vaultRes) == 0 {
+		return freezes, nil
+	}
+
+	err = json.Unmarshal(vaultRes, &freezes)
+	if err != nil {
+		return freezes, fmt.Errorf("unable to parse freeze windows at %s: %s", mount, err.Error())
+	}
+
+	return freezes, nil
+}
+
+// parseTimestamp parses an ISO-8601 timestamp with or without offset or an ISO-8601 date.
+func parseTimestamp(timestamp string) (time.Time, error) {
+	timestamp = strings.TrimSpace(timestamp)
+
+	if parsed, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		return parsed, nil
+	}
+
+	for _, layout := range []string{freezeWindowDateTimeLayout, freezeWindowDateLayout} {
+		if parsed, err := time.ParseInLocation(layout, timestamp, time.Local); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not an ISO-8601 date or timestamp", timestamp)
+}
+
+func lastEnd(freezes []activeFreeze) string {
+	end := freezes[0].end
+	for _, freeze := range freezes[1:] {
+		if freeze.end.After(end) {
+			end = freeze.end
+		}
+	}
+
+	return end.Format(time.RFC3339)
+}
+
+func reasons(freezes []activeFreeze) string {
+	reasons := make([]string, 0, len(freezes))
+	for _, freeze := range freezes {
+		reasons = append(reasons, freeze.reason)
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+func (check *FreezeWindowCheck) IsValidForCheckType(checkType uint) bool {
+	return true
+}
+
+func (check *FreezeWindowCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *FreezeWindowCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -0,0 +1,304 @@
+package freezewindow
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var errTestVault = errors.New("vault unavailable")
+
+type mockVault struct {
+	secrets map[string]string
+	err     error
+}
+
+func (v *mockVault) ReadSecret(ctx context.Context, mount string) ([]byte, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	secret, exists := v.secrets[mount]
+	if !exists {
+		return nil, nil
+	}
+
+	return []byte(secret), nil
+}
+
+func TestNewFreezeWindowCheck(t *testing.T) {
+	vault := &mockVault{}
+	newFreezeWindowCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck FreezeWindowCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "freezeWindow",
+			},
+			expectedCheck: FreezeWindowCheck{
+				jobName:        "testJob",
+				abortOnFail:    true,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+			},
+		},
+		{
+			name: "mfaOnFail",
+			config: config.CheckConfig{
+				Name: "freezeWindow",
+				Options: map[string]interface{}{
+					"mfaOnFail": true,
+				},
+			},
+			expectedCheck: FreezeWindowCheck{
+				jobName:        "testJob",
+				mfaOnFail:      true,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+			},
+		},
+	}
+
+	for testNum, test := range newFreezeWindowCheckTests {
+		check := NewFreezeWindowCheck(test.config, "testJob", vault, "cicd/namespace")
+		check.now = nil
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestFreezeWindowCheck(t *testing.T) {
+	now := func() time.Time {
+		return time.Date(2024, time.December, 24, 12, 0, 0, 0, time.UTC)
+	}
+
+	holidayFreeze := `{"freezes": [
+		{"start": "2024-12-20T18:00:00+01:00", "end": "2025-01-06T08:00:00+01:00", "reason": "End of year freeze", "exemptJobs": ["hotfix-*"]},
+		{"start": "2024-06-01T00:00:00Z", "end": "2024-06-02T00:00:00Z", "reason": "Datacenter move"}
+	]}`
+
+	freezeWindowCheckTests := []struct {
+		name           string
+		jobName        string
+		vault          checks.SecretReader
+		mfaOnFail      bool
+		expectedResult checks.CheckResult
+	}{
+		{
+			name:    "active freeze",
+			jobName: "deploy",
+			vault:   &mockVault{secrets: map[string]string{"cicd/namespace/freeze_windows": holidayFreeze}},
+			expectedResult: checks.CheckResult{
+				Name:     FreezeWindowCheckName,
+				Version:  FreezeWindowCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Change freeze until 2025-01-06T08:00:00+01:00: End of year freeze",
+			},
+		},
+		{
+			name:      "active freeze with mfaOnFail",
+			jobName:   "deploy",
+			vault:     &mockVault{secrets: map[string]string{"cicd/namespace/freeze_windows": holidayFreeze}},
+			mfaOnFail: true,
+			expectedResult: checks.CheckResult{
+				Name:     FreezeWindowCheckName,
+				Version:  FreezeWindowCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Change freeze until 2025-01-06T08:00:00+01:00: End of year freeze",
+			},
+		},
+		{
+			name:    "exempt job",
+			jobName: "hotfix-payments",
+			vault:   &mockVault{secrets: map[string]string{"cicd/namespace/freeze_windows": holidayFreeze}},
+			expectedResult: checks.CheckResult{
+				Name:    FreezeWindowCheckName,
+				Version: FreezeWindowCheckVersion,
+				Details: "Job hotfix-payments is exempt from the change freeze until 2025-01-06T08:00:00+01:00: End of year freeze",
+			},
+		},
+		{
+			name:    "overlapping freezes",
+			jobName: "deploy",
+			vault: &mockVault{secrets: map[string]string{"cicd/namespace/freeze_windows": `{"freezes": [
+				{"start": "2024-12-24T00:00:00Z", "end": "2024-12-27T00:00:00Z", "reason": "Holidays"},
+				{"start": "2024-12-01T00:00:00Z", "end": "2024-12-31T00:00:00Z"}
+			]}`}},
+			expectedResult: checks.CheckResult{
+				Name:     FreezeWindowCheckName,
+				Version:  FreezeWindowCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Change freeze until 2024-12-31T00:00:00Z: Holidays; no reason given",
+			},
+		},
+		{
+			name:    "freeze ended",
+			jobName: "deploy",
+			vault: &mockVault{secrets: map[string]string{"cicd/namespace/freeze_windows": `{"freezes": [
+				{"start": "2024-12-20T00:00:00Z", "end": "2024-12-24T12:00:00Z", "reason": "Release freeze"}
+			]}`}},
+			expectedResult: checks.CheckResult{
+				Name:    FreezeWindowCheckName,
+				Version: FreezeWindowCheckVersion,
+				Details: FreezeWindowCheckNoFreeze,
+			},
+		},
+		{
+			name:    "no freeze windows",
+			jobName: "deploy",
+			vault:   &mockVault{},
+			expectedResult: checks.CheckResult{
+				Name:    FreezeWindowCheckName,
+				Version: FreezeWindowCheckVersion,
+				Details: FreezeWindowCheckNoFreeze,
+			},
+		},
+		{
+			name:    "invalid timestamp",
+			jobName: "deploy",
+			vault: &mockVault{secrets: map[string]string{"cicd/namespace/freeze_windows": `{"freezes": [
+				{"start": "24.12.2024", "end": "2024-12-27", "reason": "Holidays"}
+			]}`}},
+			expectedResult: checks.CheckResult{
+				Name:     FreezeWindowCheckName,
+				Version:  FreezeWindowCheckVersion,
+				Error:    ErrInvalidFreeze,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  FreezeWindowCheckError,
+			},
+		},
+		{
+			name:    "end before start",
+			jobName: "deploy",
+			vault: &mockVault{secrets: map[string]string{"cicd/namespace/freeze_windows": `{"freezes": [
+				{"start": "2024-12-27", "end": "2024-12-20", "reason": "Holidays"}
+			]}`}},
+			expectedResult: checks.CheckResult{
+				Name:     FreezeWindowCheckName,
+				Version:  FreezeWindowCheckVersion,
+				Error:    ErrInvalidFreeze,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  FreezeWindowCheckError,
+			},
+		},
+		{
+			name:    "vault error",
+			jobName: "deploy",
+			vault:   &mockVault{err: errTestVault},
+			expectedResult: checks.CheckResult{
+				Name:     FreezeWindowCheckName,
+				Version:  FreezeWindowCheckVersion,
+				Error:    errTestVault,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  FreezeWindowCheckError,
+			},
+		},
+		{
+			name:    "no vault",
+			jobName: "deploy",
+			expectedResult: checks.CheckResult{
+				Name:     FreezeWindowCheckName,
+				Version:  FreezeWindowCheckVersion,
+				Error:    ErrNoFreezeVault,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  FreezeWindowCheckError,
+			},
+		},
+	}
+
+	for testNum, test := range freezeWindowCheckTests {
+		check := FreezeWindowCheck{
+			jobName:        test.jobName,
+			abortOnFail:    !test.mfaOnFail,
+			mfaOnFail:      test.mfaOnFail,
+			vault:          test.vault,
+			namespaceMount: "cicd/namespace",
+			now:            now,
+		}
+
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !errors.Is(result.Error, test.expectedResult.Error) {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult.Error, result.Error)
+		}
+
+		// errors are wrapped, compare them above
+		result.Error = test.expectedResult.Error
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	parseTimestampTests := []struct {
+		name      string
+		timestamp string
+		expected  time.Time
+		valid     bool
+	}{
+		{
+			name:      "timestamp with offset",
+			timestamp: "2024-12-20T18:00:00+01:00",
+			expected:  time.Date(2024, time.December, 20, 17, 0, 0, 0, time.UTC),
+			valid:     true,
+		},
+		{
+			name:      "utc timestamp",
+			timestamp: "2024-12-20T18:00:00Z",
+			expected:  time.Date(2024, time.December, 20, 18, 0, 0, 0, time.UTC),
+			valid:     true,
+		},
+		{
+			name:      "local timestamp",
+			timestamp: "2024-12-20T18:00:00",
+			expected:  time.Date(2024, time.December, 20, 18, 0, 0, 0, time.Local),
+			valid:     true,
+		},
+		{
+			name:      "local date",
+			timestamp: " 2024-12-20 ",
+			expected:  time.Date(2024, time.December, 20, 0, 0, 0, 0, time.Local),
+			valid:     true,
+		},
+		{
+			name:      "invalid date",
+			timestamp: "2024-13-20",
+			valid:     false,
+		},
+	}
+
+	for testNum, test := range parseTimestampTests {
+		parsed, err := parseTimestamp(test.timestamp)
+		if (err == nil) != test.valid || (test.valid && !parsed.Equal(test.expected)) {
+			t.Errorf("\n%d) unexpected timestamp for %s -\nexpected: (%s, %t)\ngot: (%s, %+v)", testNum, test.name, test.expected, test.valid, parsed, err)
+		}
+	}
+}
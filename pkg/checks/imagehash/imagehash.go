@@ -1,6 +1,8 @@
 package imagehash
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -12,18 +14,41 @@ import (
 var (
 	ImageHashCheckFailedMfaRequired = "Unknown Image MFA Required"
 	ImageHashCheckFailedAbort       = "Unknown Image Aborting"
+	ImageHashCheckFailedWarn        = "Unknown Image"
 	ImageHashCheckSuccess           = "Successful Image Hash Check"
+	ImageHashCheckServicesFailed    = "Unknown Service Image"
 	ImageHashCheckError             = "---ERROR---"
+	ImageHashCheckServiceName       = "Service Image Hash Check"
+	ImageHashCheckServiceDetails    = "%s: %s"
+	ImageHashCheckApprovalDetails   = " (%s)"
 )
 
+func init() {
+	checks.Register("imageHash", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewImageHashCheck(config, job.JobName, job.Image, job.Services)
+		return &check
+	},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "strict", Type: checks.BoolOption},
+	)
+}
+
 type ImageHashCheck struct {
 	jobName     string
 	abortOnFail bool
 	mfaOnFail   bool
 	image       string
+	services    []string
+	strict      bool
 }
 
-func NewImageHashCheck(config config.CheckConfig, jobName string, image string) ImageHashCheck {
+// NewImageHashCheck creates an image hash check of the job image which also checks the images
+// of the job's service containers (i.e. a database or docker:dind), each service is reported in
+// a result of its own nested in the image hash check result. Only GitHub jobs have services, the
+// services of GitLab jobs are not known to the custom executor. In strict mode, the repository of
+// the image must match the repository of the whitelist entry as well as its digest.
+func NewImageHashCheck(config config.CheckConfig, jobName string, image string, services []string) ImageHashCheck {
 	abortOnFail, exists := config.Options["abortOnFail"].(bool)
 	if !exists {
 		abortOnFail = false
@@ -39,44 +64,69 @@ func NewImageHashCheck(config config.CheckConfig, jobName string, image string)
 		mfaOnFail:   mfaOnFail,
 		jobName:     jobName,
 		image:       image,
+		services:    services,
+		strict:      checks.GetBoolOption(config.Options, "strict", false),
 	}
 }
 
-func (check *ImageHashCheck) Check(channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+func (check *ImageHashCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
 	defer wg.Done()
 
+	result := check.checkImage(check.image, w)
+
+	for _, service := range check.services {
+		serviceResult := check.checkImage(service, w)
+		serviceResult.Name = ImageHashCheckServiceName
+		serviceResult.Details = fmt.Sprintf(ImageHashCheckServiceDetails, serviceResult.Details, service)
+		result.Children = append(result.Children, serviceResult)
+	}
+
+	if worst := checks.WorstOutcome(result.Children); worst > result.Outcome {
+		if result.Outcome == checks.OutcomePass {
+			result.Details = ImageHashCheckServicesFailed
+		}
+		result.SetOutcome(worst)
+	}
+
+	channel <- result
+}
+
+// checkImage checks a single image against the whitelist.
+func (check *ImageHashCheck) checkImage(image string, w whitelist.Whitelist) checks.CheckResult {
 	result := checks.CheckResult{Name: "Image Hash Check", Version: "1.0.0"}
 	details := ""
 
-	foundImg, err := w.ContainsImage(check.image)
+	entry, foundImg, err := w.Image(image, check.jobName, check.strict)
 	if err != nil {
 		result.Error = err
 		details = ImageHashCheckError
-		if check.abortOnFail {
-			result.Abort = true
-		} else if check.mfaOnFail {
-			result.Mfa = true
-		}
+		result.Fail(check.abortOnFail, check.mfaOnFail)
 		result.Details = details
-		channel <- result
-		return
+		return result
 	}
 
 	result.Error = nil
 
 	if !foundImg && check.abortOnFail {
-		result.Abort = true
 		details = ImageHashCheckFailedAbort
 	} else if !foundImg && check.mfaOnFail {
-		result.Mfa = true
 		details = ImageHashCheckFailedMfaRequired
-	} else if foundImg {
+	} else if !foundImg {
+		details = ImageHashCheckFailedWarn
+	} else {
 		details = ImageHashCheckSuccess
+		if approval := entry.Approval(); len(approval) != 0 {
+			details += fmt.Sprintf(ImageHashCheckApprovalDetails, approval)
+		}
+	}
+
+	if !foundImg {
+		result.Fail(check.abortOnFail, check.mfaOnFail)
 	}
 
 	result.Details = strings.Clone(details)
 
-	channel <- result
+	return result
 }
 
 func (check *ImageHashCheck) IsValidForCheckType(checkType uint) bool {
@@ -91,3 +141,7 @@ func (check *ImageHashCheck) IsValidForCheckType(checkType uint) bool {
 func (check *ImageHashCheck) IsValidForPlatform(ciPlatform string) bool {
 	return true
 }
+
+func (check *ImageHashCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -1,9 +1,11 @@
 package imagehash
 
 import (
+	"context"
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
@@ -48,6 +50,22 @@ func TestNewImageHashCheck(t *testing.T) {
 				image:       "",
 			},
 		},
+		{
+			name: "test strict",
+			config: config.CheckConfig{
+				Name: "imageHash",
+				Options: map[string]interface{}{
+					"abortOnFail": true,
+					"strict":      true,
+				},
+			},
+			expectedCheck: ImageHashCheck{
+				jobName:     "testJob",
+				abortOnFail: true,
+				image:       "",
+				strict:      true,
+			},
+		},
 		{
 			name: "test abortOnFail missing and mfaOnFail missing",
 			config: config.CheckConfig{
@@ -66,7 +84,7 @@ func TestNewImageHashCheck(t *testing.T) {
 	jobName := "testJob"
 	image := ""
 	for _, test := range newImageHashCheckTests {
-		imageHashCheck := NewImageHashCheck(test.config, jobName, image)
+		imageHashCheck := NewImageHashCheck(test.config, jobName, image, nil)
 		if !reflect.DeepEqual(imageHashCheck, test.expectedCheck) {
 			t.Errorf("checks not equal - expected: (%+v) - got: (%+v)", test.expectedCheck, imageHashCheck)
 		}
@@ -88,16 +106,14 @@ func TestImageHashCheck(t *testing.T) {
 				image:       "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748",
 			},
 			whitelist: whitelist.Whitelist{
-				AllowedImages: []string{
-					"alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748",
+				AllowedImages: []whitelist.Entry{
+					{Value: "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748"},
 				},
 			},
 			expectedResult: checks.CheckResult{
 				Name:    "Image Hash Check",
 				Version: "1.0.0",
 				Error:   nil,
-				Abort:   false,
-				Mfa:     false,
 				Details: ImageHashCheckSuccess,
 			},
 		},
@@ -110,17 +126,39 @@ func TestImageHashCheck(t *testing.T) {
 				image:       "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748",
 			},
 			whitelist: whitelist.Whitelist{
-				AllowedImages: []string{
-					"alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9fxyz",
+				AllowedImages: []whitelist.Entry{
+					{Value: "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9fxyz"},
 				},
 			},
 			expectedResult: checks.CheckResult{
-				Name:    "Image Hash Check",
-				Version: "1.0.0",
-				Error:   nil,
-				Abort:   false,
-				Mfa:     true,
-				Details: ImageHashCheckFailedMfaRequired,
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Error:    nil,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  ImageHashCheckFailedMfaRequired,
+			},
+		},
+		{
+			name: "test image not in whitelist - warn only",
+			imageHashCheck: ImageHashCheck{
+				jobName:     "testJob",
+				abortOnFail: false,
+				mfaOnFail:   false,
+				image:       "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748",
+			},
+			whitelist: whitelist.Whitelist{
+				AllowedImages: []whitelist.Entry{
+					{Value: "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9fxyz"},
+				},
+			},
+			expectedResult: checks.CheckResult{
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Error:    nil,
+				Outcome:  checks.OutcomeWarn,
+				Severity: checks.SeverityLow,
+				Details:  ImageHashCheckFailedWarn,
 			},
 		},
 		{
@@ -132,17 +170,17 @@ func TestImageHashCheck(t *testing.T) {
 				image:       "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748",
 			},
 			whitelist: whitelist.Whitelist{
-				AllowedImages: []string{
-					"alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9fxyz",
+				AllowedImages: []whitelist.Entry{
+					{Value: "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9fxyz"},
 				},
 			},
 			expectedResult: checks.CheckResult{
-				Name:    "Image Hash Check",
-				Version: "1.0.0",
-				Error:   nil,
-				Abort:   true,
-				Mfa:     false,
-				Details: ImageHashCheckFailedAbort,
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Error:    nil,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageHashCheckFailedAbort,
 			},
 		},
 		{
@@ -154,17 +192,17 @@ func TestImageHashCheck(t *testing.T) {
 				image:       "",
 			},
 			whitelist: whitelist.Whitelist{
-				AllowedImages: []string{
-					"alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748",
+				AllowedImages: []whitelist.Entry{
+					{Value: "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748"},
 				},
 			},
 			expectedResult: checks.CheckResult{
-				Name:    "Image Hash Check",
-				Version: "1.0.0",
-				Error:   whitelist.ErrShaNotPresent,
-				Abort:   true,
-				Mfa:     false,
-				Details: ImageHashCheckError,
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Error:    whitelist.ErrShaNotPresent,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageHashCheckError,
 			},
 		},
 		{
@@ -176,17 +214,17 @@ func TestImageHashCheck(t *testing.T) {
 				image:       "testImage@sha256:XYZ",
 			},
 			whitelist: whitelist.Whitelist{
-				AllowedImages: []string{
-					"alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748",
+				AllowedImages: []whitelist.Entry{
+					{Value: "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748"},
 				},
 			},
 			expectedResult: checks.CheckResult{
-				Name:    "Image Hash Check",
-				Version: "1.0.0",
-				Error:   whitelist.ErrShaNotValidSha,
-				Abort:   false,
-				Mfa:     true,
-				Details: ImageHashCheckError,
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Error:    whitelist.ErrShaNotValidSha,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  ImageHashCheckError,
 			},
 		},
 	}
@@ -196,7 +234,7 @@ func TestImageHashCheck(t *testing.T) {
 		channel := make(chan checks.CheckResult, 1)
 
 		wg.Add(1)
-		go test.imageHashCheck.Check(channel, &wg, test.whitelist)
+		go test.imageHashCheck.Check(context.Background(), channel, &wg, test.whitelist)
 
 		wg.Wait()
 		close(channel)
@@ -208,6 +246,189 @@ func TestImageHashCheck(t *testing.T) {
 		}
 	}
 }
+func TestImageHashCheckServices(t *testing.T) {
+	alpine := "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748"
+	postgres := "postgres:15@sha256:a2282ad0db623c27f03bab803975c9e3942a24e974f07142d5d69b6b8eaaf9e2"
+	redis := "redis:7@sha256:1f1bd4adf5dabf173b235ba373faef55f3ad53394791d1473763bf5a2181780d"
+	w := whitelist.Whitelist{AllowedImages: []whitelist.Entry{{Value: alpine}, {Value: postgres}}}
+
+	imageHashCheckServicesTests := []struct {
+		name           string
+		imageHashCheck ImageHashCheck
+		expectedResult checks.CheckResult
+	}{
+		{
+			name: "job image and services in whitelist - success",
+			imageHashCheck: ImageHashCheck{
+				abortOnFail: true,
+				image:       alpine,
+				services:    []string{postgres},
+			},
+			expectedResult: checks.CheckResult{
+				Name:    "Image Hash Check",
+				Version: "1.0.0",
+				Details: ImageHashCheckSuccess,
+				Children: []checks.CheckResult{
+					{Name: ImageHashCheckServiceName, Version: "1.0.0", Details: ImageHashCheckSuccess + ": " + postgres},
+				},
+			},
+		},
+		{
+			name: "service not in whitelist - abortOnFail",
+			imageHashCheck: ImageHashCheck{
+				abortOnFail: true,
+				image:       alpine,
+				services:    []string{postgres, redis},
+			},
+			expectedResult: checks.CheckResult{
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageHashCheckServicesFailed,
+				Children: []checks.CheckResult{
+					{Name: ImageHashCheckServiceName, Version: "1.0.0", Details: ImageHashCheckSuccess + ": " + postgres},
+					{Name: ImageHashCheckServiceName, Version: "1.0.0", Outcome: checks.OutcomeAbort, Severity: checks.SeverityHigh, Details: ImageHashCheckFailedAbort + ": " + redis},
+				},
+			},
+		},
+		{
+			name: "service without hash - mfaOnFail",
+			imageHashCheck: ImageHashCheck{
+				mfaOnFail: true,
+				image:     alpine,
+				services:  []string{"redis:7"},
+			},
+			expectedResult: checks.CheckResult{
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  ImageHashCheckServicesFailed,
+				Children: []checks.CheckResult{
+					{Name: ImageHashCheckServiceName, Version: "1.0.0", Error: whitelist.ErrShaNotPresent, Outcome: checks.OutcomeMfa, Severity: checks.SeverityMedium, Details: ImageHashCheckError + ": redis:7"},
+				},
+			},
+		},
+		{
+			name: "job image not in whitelist keeps its details - abortOnFail",
+			imageHashCheck: ImageHashCheck{
+				abortOnFail: true,
+				image:       redis,
+				services:    []string{postgres},
+			},
+			expectedResult: checks.CheckResult{
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageHashCheckFailedAbort,
+				Children: []checks.CheckResult{
+					{Name: ImageHashCheckServiceName, Version: "1.0.0", Details: ImageHashCheckSuccess + ": " + postgres},
+				},
+			},
+		},
+	}
+
+	for testNum, test := range imageHashCheckServicesTests {
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go test.imageHashCheck.Check(context.Background(), channel, &wg, w)
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+func TestImageHashCheckEntries(t *testing.T) {
+	image := "alpine:3.13@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748"
+
+	imageHashCheckEntriesTests := []struct {
+		name           string
+		entry          whitelist.Entry
+		strict         bool
+		expectedResult checks.CheckResult
+	}{
+		{
+			name:  "approval of the entry in the details",
+			entry: whitelist.Entry{Value: image, ApprovedBy: "alice", Reason: "base image", Jobs: []string{"testJob"}},
+			expectedResult: checks.CheckResult{
+				Name:    "Image Hash Check",
+				Version: "1.0.0",
+				Details: ImageHashCheckSuccess + " (approved by alice: base image)",
+			},
+		},
+		{
+			name:  "expired entry - abortOnFail",
+			entry: whitelist.Entry{Value: image, ApprovedBy: "alice", ExpiresAt: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+			expectedResult: checks.CheckResult{
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageHashCheckFailedAbort,
+			},
+		},
+		{
+			name:   "entry of another repository - strict",
+			entry:  whitelist.Entry{Value: "evil/alpine@sha256:def822f9851ca422481ec6fee59a9966f12b351c62ccb9aca841526ffaa9f748"},
+			strict: true,
+			expectedResult: checks.CheckResult{
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageHashCheckFailedAbort,
+			},
+		},
+		{
+			name:  "any digest of the repository",
+			entry: whitelist.Entry{Repository: "docker.io/library/alpine", ApprovedBy: "alice"},
+			expectedResult: checks.CheckResult{
+				Name:    "Image Hash Check",
+				Version: "1.0.0",
+				Details: ImageHashCheckSuccess + " (approved by alice)",
+			},
+		},
+		{
+			name:  "entry of another job - abortOnFail",
+			entry: whitelist.Entry{Value: image, Jobs: []string{"deploy"}},
+			expectedResult: checks.CheckResult{
+				Name:     "Image Hash Check",
+				Version:  "1.0.0",
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageHashCheckFailedAbort,
+			},
+		},
+	}
+
+	for testNum, test := range imageHashCheckEntriesTests {
+		check := ImageHashCheck{jobName: "testJob", abortOnFail: true, image: image, strict: test.strict}
+
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{AllowedImages: []whitelist.Entry{test.entry}})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
 func TestIsValidForCheckType(t *testing.T) {
 	isValidForCheckTypeTests := []struct {
 		name      string
@@ -0,0 +1,159 @@
+package imagesignature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/imageref"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/ociregistry"
+)
+
+var (
+	ErrNoSignature       = errors.New("no signature found")
+	ErrInvalidPublicKey  = errors.New("invalid public key")
+	ErrInvalidPayload    = errors.New("invalid signature payload")
+	cosignSignatureType  = "cosign container image signature"
+	cosignPayloadType    = "application/vnd.dev.cosign.simplesigning.v1+json"
+	cosignSignatureLabel = "dev.cosignproject.cosign/signature"
+	cosignSignatureTag   = "%s-%s.sig"
+)
+
+// payload is the simple signing payload cosign signs for an image.
+type payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// signature is a signature attached to an image together with the payload it signs.
+type signature struct {
+	payload   []byte
+	signature []byte
+}
+
+// signatureTag returns the tag cosign stores the signatures of the image digest under
+// (i.e. sha256:abc... -> sha256-abc....sig).
+func signatureTag(digest string) string {
+	algorithm, hash, _ := strings.Cut(digest, ":")
+	return fmt.Sprintf(cosignSignatureTag, algorithm, hash)
+}
+
+// fetchSignatures reads the cosign signatures of the image digest from the registry.
+func fetchSignatures(ctx context.Context, client *ociregistry.Client, reference imageref.Reference, digest string) ([]signature, error) {
+	manifest, err := client.Manifest(ctx, reference, signatureTag(digest))
+	if errors.Is(err, ociregistry.ErrNotFound) {
+		return nil, ErrNoSignature
+	} else if err != nil {
+		return nil, err
+	}
+
+	var signatures []signature
+	for _, layer := range manifest.Layers {
+		encoded, exists := layer.Annotations[cosignSignatureLabel]
+		if layer.MediaType != cosignPayloadType || !exists {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode signature of %s: %s", layer.Digest, err.Error())
+		}
+
+		content, err := client.Blob(ctx, reference, layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		signatures = append(signatures, signature{payload: content, signature: decoded})
+	}
+
+	if len(signatures) == 0 {
+		return nil, ErrNoSignature
+	}
+
+	return signatures, nil
+}
+
+// verifyPayload checks that the signed payload is a cosign image signature for the digest in the
+// repository of the reference, as cosign verify does.
+func verifyPayload(content []byte, reference imageref.Reference, digest string) error {
+	var signed payload
+	err := json.Unmarshal(content, &signed)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPayload, err.Error())
+	}
+
+	if signed.Critical.Type != cosignSignatureType {
+		return fmt.Errorf("%w: unexpected type %q", ErrInvalidPayload, signed.Critical.Type)
+	}
+
+	if signed.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("%w: signed digest %s does not match %s", ErrInvalidPayload, signed.Critical.Image.DockerManifestDigest, digest)
+	}
+
+	signedReference, err := imageref.Parse(signed.Critical.Identity.DockerReference)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPayload, err.Error())
+	}
+
+	if signedReference.Name() != reference.Name() {
+		return fmt.Errorf("%w: signed reference %s does not match %s", ErrInvalidPayload, signedReference.Name(), reference.Name())
+	}
+
+	return nil
+}
+
+// parsePublicKey parses a PEM encoded PKIX public key (ECDSA, Ed25519 or RSA) as written by
+// "cosign generate-key-pair".
+func parsePublicKey(publicKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(publicKey)))
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block", ErrInvalidPublicKey)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPublicKey, err.Error())
+	}
+
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey, *rsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %T", ErrInvalidPublicKey, key)
+	}
+}
+
+// verifySignature verifies the signature of the payload with the public key using the
+// algorithms cosign uses by default (SHA-256 digests, ASN.1 ECDSA and PKCS #1 v1.5 RSA signatures).
+func verifySignature(publicKey crypto.PublicKey, content []byte, sig []byte) bool {
+	digest := sha256.Sum256(content)
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest[:], sig)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, content, sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,248 @@
+package imagesignature
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/imageref"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/ociregistry"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	ImageSignatureCheckName      = "Image Signature Check"
+	ImageSignatureCheckVersion   = "1.0.0"
+	ImageSignatureCheckSuccess   = "Image %s signed by %s"
+	ImageSignatureCheckNotSigned = "Image %s is not signed"
+	ImageSignatureCheckUntrusted = "Image %s is not signed by a trusted key"
+	ImageSignatureCheckNotPinned = "Image %s is not pinned to a digest, the image docker pulls for a tag may not be the one verified"
+	ImageSignatureCheckError     = "---ERROR---"
+	ErrNoKeyVault                = errors.New("no vault available to read the image signing keys")
+	ErrNoTrustedKeys             = errors.New("no trusted image signing keys")
+	imageSignatureKeysMount      = "image_signing_keys"
+)
+
+func init() {
+	checks.Register("imageSignature", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewImageSignatureCheck(config, job.JobName, job.Image, job.Vault, job.NamespaceMount, job.ProjectMount)
+		return &check
+	},
+		checks.Option{Name: "keys", Type: checks.ListOption},
+		checks.Option{Name: "registryUrl", Type: checks.StringOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+// SigningKey is a public key trusted to sign images.
+type SigningKey struct {
+	// Name identifies the key in the check details (i.e. "build-pipeline").
+	Name string `json:"name"`
+	// PublicKey is a PEM encoded public key as written by "cosign generate-key-pair"
+	// (i.e. "-----BEGIN PUBLIC KEY-----\n...").
+	PublicKey string `json:"publicKey"`
+}
+
+// SigningKeys is the document stored in the vault at {root}/{namespace}/image_signing_keys
+// and {root}/{namespace}/{project}/image_signing_keys.
+//
+// Example:
+//
+//	{
+//		"keys": [
+//			{"name": "build-pipeline", "publicKey": "-----BEGIN PUBLIC KEY-----\n..."}
+//		]
+//	}
+type SigningKeys struct {
+	Keys []SigningKey `json:"keys"`
+}
+
+type trustedKey struct {
+	name      string
+	publicKey crypto.PublicKey
+}
+
+type ImageSignatureCheck struct {
+	jobName        string
+	image          string
+	keys           []string
+	registryUrl    string
+	abortOnFail    bool
+	mfaOnFail      bool
+	vault          checks.SecretReader
+	namespaceMount string
+	projectMount   string
+}
+
+// NewImageSignatureCheck creates a check verifying the cosign signatures of the job image
+// against the signing keys stored in the vault. If keys is configured, only the signing keys
+// with one of those names are trusted. The signatures are read from the registry of the image,
+// or from registryUrl if it is set (i.e. a local registry or a mirror). The image must be pinned
+// to a digest: a tag could resolve to another manifest when docker pulls it.
+func NewImageSignatureCheck(config config.CheckConfig, jobName string, image string, vault checks.SecretReader, namespaceMount string, projectMount string) ImageSignatureCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return ImageSignatureCheck{
+		jobName:        jobName,
+		image:          image,
+		keys:           checks.GetStringListOption(config.Options, "keys"),
+		registryUrl:    checks.GetStringOption(config.Options, "registryUrl", ""),
+		abortOnFail:    abortOnFail,
+		mfaOnFail:      mfaOnFail,
+		vault:          vault,
+		namespaceMount: namespaceMount,
+		projectMount:   projectMount,
+	}
+}
+
+func (check *ImageSignatureCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: ImageSignatureCheckName, Version: ImageSignatureCheckVersion}
+
+	reference, err := imageref.Parse(check.image)
+	if err == nil && len(reference.Digest) == 0 {
+		result.Details = fmt.Sprintf(ImageSignatureCheckNotPinned, reference.String())
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	var keys []trustedKey
+	if err == nil {
+		keys, err = check.readKeys(ctx)
+	}
+
+	client := ociregistry.NewClient(check.registryUrl)
+
+	var digest string
+	if err == nil {
+		digest, err = client.Resolve(ctx, reference)
+	}
+
+	var signatures []signature
+	if err == nil {
+		signatures, err = fetchSignatures(ctx, client, reference, digest)
+	}
+
+	image := reference.Name() + "@" + digest
+
+	if errors.Is(err, ErrNoSignature) {
+		result.Details = fmt.Sprintf(ImageSignatureCheckNotSigned, image)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	} else if err != nil {
+		result.Error = checks.ContextError(ctx, err)
+		result.Details = ImageSignatureCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	signer, signed := verify(signatures, keys, reference, digest)
+	if signed {
+		result.Details = fmt.Sprintf(ImageSignatureCheckSuccess, image, signer)
+	} else {
+		result.Details = fmt.Sprintf(ImageSignatureCheckUntrusted, image)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+// verify returns the name of the first trusted key which signed the image digest.
+func verify(signatures []signature, keys []trustedKey, reference imageref.Reference, digest string) (string, bool) {
+	for _, sig := range signatures {
+		if verifyPayload(sig.payload, reference, digest) != nil {
+			continue
+		}
+
+		for _, key := range keys {
+			if verifySignature(key.publicKey, sig.payload, sig.signature) {
+				return key.name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// readKeys reads the signing keys from the vault, keeping only the configured keys if any.
+func (check *ImageSignatureCheck) readKeys(ctx context.Context) ([]trustedKey, error) {
+	if check.vault == nil {
+		return nil, ErrNoKeyVault
+	}
+
+	var keys []trustedKey
+	for _, mount := range []string{check.namespaceMount, check.projectMount} {
+		vaultRes, err := check.vault.ReadSecret(ctx, mount+"/"+imageSignatureKeysMount)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(vaultRes) == 0 {
+			continue
+		}
+
+		var signingKeys SigningKeys
+		err = json.Unmarshal(vaultRes, &signingKeys)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse image signing keys at %s: %s", mount+"/"+imageSignatureKeysMount, err.Error())
+		}
+
+		for _, key := range signingKeys.Keys {
+			if len(check.keys) != 0 && !containsName(check.keys, key.Name) {
+				continue
+			}
+
+			publicKey, err := parsePublicKey(key.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("image signing key %s: %w", key.Name, err)
+			}
+
+			keys = append(keys, trustedKey{name: key.Name, publicKey: publicKey})
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoTrustedKeys
+	}
+
+	return keys, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, allowedName := range names {
+		if strings.EqualFold(strings.TrimSpace(allowedName), name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (check *ImageSignatureCheck) IsValidForCheckType(checkType uint) bool {
+	switch checkType {
+	case checks.All:
+		return true
+	default:
+		return checkType == checks.ImageCheck
+	}
+}
+
+func (check *ImageSignatureCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *ImageSignatureCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -0,0 +1,401 @@
+package imagesignature
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var errTestVault = errors.New("vault unavailable")
+
+type mockVault struct {
+	secrets map[string]string
+	err     error
+}
+
+func (v *mockVault) ReadSecret(ctx context.Context, mount string) ([]byte, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	secret, exists := v.secrets[mount]
+	if !exists {
+		return nil, nil
+	}
+
+	return []byte(secret), nil
+}
+
+// testRegistry is a minimal OCI registry serving manifests by tag or digest and blobs.
+type testRegistry struct {
+	manifests map[string]string
+	blobs     map[string]string
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{manifests: make(map[string]string), blobs: make(map[string]string)}
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, "/v2/team/app/")
+	if manifest, exists := r.manifests[strings.TrimPrefix(path, "manifests/")]; exists && strings.HasPrefix(path, "manifests/") {
+		w.Header().Set("Docker-Content-Digest", digestOf(manifest))
+		fmt.Fprint(w, manifest)
+	} else if blob, exists := r.blobs[strings.TrimPrefix(path, "blobs/")]; exists && strings.HasPrefix(path, "blobs/") {
+		fmt.Fprint(w, blob)
+	} else {
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// pushImage stores an image manifest under the tag and returns its digest.
+func (r *testRegistry) pushImage(tag string) string {
+	manifest := fmt.Sprintf(`{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", "annotations": {"tag": "%s"}}`, tag)
+	digest := digestOf(manifest)
+	r.manifests[tag] = manifest
+	r.manifests[digest] = manifest
+	return digest
+}
+
+// pushSignature attaches a cosign signature of the signed reference and digest made with sign to
+// the image digest.
+func (r *testRegistry) pushSignature(digest string, signedReference string, signedDigest string, sign func([]byte) []byte) {
+	payload := fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"%s"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, signedReference, signedDigest)
+	r.blobs[digestOf(payload)] = payload
+
+	manifest, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"layers": []map[string]interface{}{{
+			"mediaType":   cosignPayloadType,
+			"digest":      digestOf(payload),
+			"size":        len(payload),
+			"annotations": map[string]string{cosignSignatureLabel: base64.StdEncoding.EncodeToString(sign([]byte(payload)))},
+		}},
+	})
+	r.manifests[signatureTag(digest)] = string(manifest)
+}
+
+func digestOf(content string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+}
+
+func publicKeyPem(t *testing.T, publicKey interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("unable to marshal public key: %s", err.Error())
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func signingKeys(t *testing.T, keys map[string]string) string {
+	var document SigningKeys
+	for _, name := range []string{"build-pipeline", "release"} {
+		if publicKey, exists := keys[name]; exists {
+			document.Keys = append(document.Keys, SigningKey{Name: name, PublicKey: publicKey})
+		}
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("unable to marshal signing keys: %s", err.Error())
+	}
+
+	return string(encoded)
+}
+
+func TestNewImageSignatureCheck(t *testing.T) {
+	vault := &mockVault{}
+	newImageSignatureCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck ImageSignatureCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "imageSignature",
+			},
+			expectedCheck: ImageSignatureCheck{
+				jobName:        "testJob",
+				image:          "alpine:3.18",
+				abortOnFail:    true,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+				projectMount:   "cicd/namespace/project",
+			},
+		},
+		{
+			name: "keys, registry url and mfaOnFail",
+			config: config.CheckConfig{
+				Name: "imageSignature",
+				Options: map[string]interface{}{
+					"keys":        []interface{}{"build-pipeline"},
+					"registryUrl": "http://localhost:5000",
+					"mfaOnFail":   true,
+				},
+			},
+			expectedCheck: ImageSignatureCheck{
+				jobName:        "testJob",
+				image:          "alpine:3.18",
+				keys:           []string{"build-pipeline"},
+				registryUrl:    "http://localhost:5000",
+				mfaOnFail:      true,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+				projectMount:   "cicd/namespace/project",
+			},
+		},
+	}
+
+	for testNum, test := range newImageSignatureCheckTests {
+		check := NewImageSignatureCheck(test.config, "testJob", "alpine:3.18", vault, "cicd/namespace", "cicd/namespace/project")
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestImageSignatureCheck(t *testing.T) {
+	pipelineKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ecdsa key: %s", err.Error())
+	}
+
+	releasePublicKey, releaseKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ed25519 key: %s", err.Error())
+	}
+
+	signPipeline := func(payload []byte) []byte {
+		digest := sha256.Sum256(payload)
+		signature, err := ecdsa.SignASN1(rand.Reader, pipelineKey, digest[:])
+		if err != nil {
+			t.Fatalf("unable to sign payload: %s", err.Error())
+		}
+		return signature
+	}
+
+	signRelease := func(payload []byte) []byte {
+		return ed25519.Sign(releaseKey, payload)
+	}
+
+	registry := newTestRegistry()
+	signedDigest := registry.pushImage("1.0")
+	registry.pushSignature(signedDigest, "registry.internal/team/app", signedDigest, signPipeline)
+	releaseDigest := registry.pushImage("2.0")
+	registry.pushSignature(releaseDigest, "registry.internal/team/app", releaseDigest, signRelease)
+	unsignedDigest := registry.pushImage("unsigned")
+	replayedDigest := registry.pushImage("replayed")
+	registry.pushSignature(replayedDigest, "registry.internal/team/app", signedDigest, signPipeline)
+	otherRepositoryDigest := registry.pushImage("other")
+	registry.pushSignature(otherRepositoryDigest, "registry.internal/team/other", otherRepositoryDigest, signPipeline)
+
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	keys := &mockVault{secrets: map[string]string{
+		"cicd/namespace/image_signing_keys":         signingKeys(t, map[string]string{"build-pipeline": publicKeyPem(t, &pipelineKey.PublicKey)}),
+		"cicd/namespace/project/image_signing_keys": signingKeys(t, map[string]string{"release": publicKeyPem(t, releasePublicKey)}),
+	}}
+
+	imageSignatureCheckTests := []struct {
+		name           string
+		image          string
+		keys           []string
+		mfaOnFail      bool
+		vault          checks.SecretReader
+		expectedResult checks.CheckResult
+	}{
+		{
+			name:  "image signed by the pipeline key",
+			image: "registry.internal/team/app@" + signedDigest,
+			vault: keys,
+			expectedResult: checks.CheckResult{
+				Name:    ImageSignatureCheckName,
+				Version: ImageSignatureCheckVersion,
+				Details: "Image registry.internal/team/app@" + signedDigest + " signed by build-pipeline",
+			},
+		},
+		{
+			name:  "pinned image signed by the project key",
+			image: "registry.internal/team/app@" + releaseDigest,
+			vault: keys,
+			expectedResult: checks.CheckResult{
+				Name:    ImageSignatureCheckName,
+				Version: ImageSignatureCheckVersion,
+				Details: "Image registry.internal/team/app@" + releaseDigest + " signed by release",
+			},
+		},
+		{
+			name:  "image signed by a key which is not trusted for the job",
+			image: "registry.internal/team/app@" + releaseDigest,
+			keys:  []string{"build-pipeline"},
+			vault: keys,
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Image registry.internal/team/app@" + releaseDigest + " is not signed by a trusted key",
+			},
+		},
+		{
+			name:      "unsigned image with mfaOnFail",
+			image:     "registry.internal/team/app@" + unsignedDigest,
+			mfaOnFail: true,
+			vault:     keys,
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Image registry.internal/team/app@" + unsignedDigest + " is not signed",
+			},
+		},
+		{
+			name:  "signature of another image",
+			image: "registry.internal/team/app@" + replayedDigest,
+			vault: keys,
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Image registry.internal/team/app@" + replayedDigest + " is not signed by a trusted key",
+			},
+		},
+		{
+			name:  "signature of another repository",
+			image: "registry.internal/team/app@" + otherRepositoryDigest,
+			vault: keys,
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Image registry.internal/team/app@" + otherRepositoryDigest + " is not signed by a trusted key",
+			},
+		},
+		{
+			name:  "image referenced by a tag",
+			image: "registry.internal/team/app:1.0",
+			vault: keys,
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Image registry.internal/team/app:1.0 is not pinned to a digest, the image docker pulls for a tag may not be the one verified",
+			},
+		},
+		{
+			name:  "unknown repository",
+			image: "registry.internal/team/missing@" + signedDigest,
+			vault: keys,
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Image registry.internal/team/missing@" + signedDigest + " is not signed",
+			},
+		},
+		{
+			name:  "no signing keys",
+			image: "registry.internal/team/app@" + signedDigest,
+			vault: &mockVault{},
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Error:    ErrNoTrustedKeys,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageSignatureCheckError,
+			},
+		},
+		{
+			name:  "invalid signing key",
+			image: "registry.internal/team/app@" + signedDigest,
+			vault: &mockVault{secrets: map[string]string{
+				"cicd/namespace/image_signing_keys": `{"keys": [{"name": "build-pipeline", "publicKey": "ssh-ed25519 AAAA"}]}`,
+			}},
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Error:    ErrInvalidPublicKey,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageSignatureCheckError,
+			},
+		},
+		{
+			name:  "vault error",
+			image: "registry.internal/team/app@" + signedDigest,
+			vault: &mockVault{err: errTestVault},
+			expectedResult: checks.CheckResult{
+				Name:     ImageSignatureCheckName,
+				Version:  ImageSignatureCheckVersion,
+				Error:    errTestVault,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageSignatureCheckError,
+			},
+		},
+	}
+
+	for testNum, test := range imageSignatureCheckTests {
+		check := ImageSignatureCheck{
+			jobName:        "testJob",
+			image:          test.image,
+			keys:           test.keys,
+			registryUrl:    server.URL,
+			abortOnFail:    !test.mfaOnFail,
+			mfaOnFail:      test.mfaOnFail,
+			vault:          test.vault,
+			namespaceMount: "cicd/namespace",
+			projectMount:   "cicd/namespace/project",
+		}
+
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !errors.Is(result.Error, test.expectedResult.Error) {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult.Error, result.Error)
+		}
+
+		// errors are wrapped, compare them above
+		result.Error = test.expectedResult.Error
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package imagesource
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/glob"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/imageref"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	ImageSourceCheckName       = "Image Source Check"
+	ImageSourceCheckVersion    = "1.0.0"
+	ImageSourceCheckDenied     = "Image %s is denied"
+	ImageSourceCheckNotAllowed = "Image %s is not from an allowed source"
+	ImageSourceCheckSuccess    = "Image %s is from an allowed source"
+	ImageSourceCheckError      = "---ERROR---"
+)
+
+func init() {
+	checks.Register("imageSource", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewImageSourceCheck(config, job.JobName, job.Image)
+		return &check
+	},
+		checks.Option{Name: "allow", Type: checks.ListOption},
+		checks.Option{Name: "deny", Type: checks.ListOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+type ImageSourceCheck struct {
+	jobName     string
+	image       string
+	allow       []string
+	deny        []string
+	abortOnFail bool
+	mfaOnFail   bool
+}
+
+func NewImageSourceCheck(config config.CheckConfig, jobName string, image string) ImageSourceCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return ImageSourceCheck{
+		jobName:     jobName,
+		image:       image,
+		allow:       checks.GetStringListOption(config.Options, "allow"),
+		deny:        checks.GetStringListOption(config.Options, "deny"),
+		abortOnFail: abortOnFail,
+		mfaOnFail:   mfaOnFail,
+	}
+}
+
+func (check *ImageSourceCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: ImageSourceCheckName, Version: ImageSourceCheckVersion}
+
+	reference, err := imageref.Parse(check.image)
+	if err != nil {
+		result.Error = err
+		result.Details = ImageSourceCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	name := reference.Name()
+	if glob.MatchAny(check.deny, name) {
+		result.Details = fmt.Sprintf(ImageSourceCheckDenied, name)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else if len(check.allow) != 0 && !glob.MatchAny(check.allow, name) {
+		result.Details = fmt.Sprintf(ImageSourceCheckNotAllowed, name)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else {
+		result.Details = fmt.Sprintf(ImageSourceCheckSuccess, name)
+	}
+
+	channel <- result
+}
+
+func (check *ImageSourceCheck) IsValidForCheckType(checkType uint) bool {
+	switch checkType {
+	case checks.All:
+		return true
+	default:
+		return checkType == checks.ImageCheck
+	}
+}
+
+func (check *ImageSourceCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *ImageSourceCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
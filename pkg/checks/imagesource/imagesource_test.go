@@ -0,0 +1,170 @@
+package imagesource
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/imageref"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+func TestNewImageSourceCheck(t *testing.T) {
+	newImageSourceCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck ImageSourceCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "imageSource",
+			},
+			expectedCheck: ImageSourceCheck{
+				jobName:     "testJob",
+				image:       "alpine",
+				abortOnFail: true,
+				mfaOnFail:   false,
+			},
+		},
+		{
+			name: "allow, deny and mfaOnFail",
+			config: config.CheckConfig{
+				Name: "imageSource",
+				Options: map[string]interface{}{
+					"allow":     []interface{}{"registry.internal/*/base-*"},
+					"deny":      []interface{}{"docker.io/**"},
+					"mfaOnFail": true,
+				},
+			},
+			expectedCheck: ImageSourceCheck{
+				jobName:     "testJob",
+				image:       "alpine",
+				allow:       []string{"registry.internal/*/base-*"},
+				deny:        []string{"docker.io/**"},
+				abortOnFail: false,
+				mfaOnFail:   true,
+			},
+		},
+	}
+
+	for testNum, test := range newImageSourceCheckTests {
+		check := NewImageSourceCheck(test.config, "testJob", "alpine")
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestImageSourceCheck(t *testing.T) {
+	imageSourceCheckTests := []struct {
+		name           string
+		check          ImageSourceCheck
+		expectedResult checks.CheckResult
+	}{
+		{
+			name: "allowed internal image",
+			check: ImageSourceCheck{
+				image:       "registry.internal/team/base-alpine:3.18@sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978",
+				allow:       []string{"registry.internal/*/base-*"},
+				deny:        []string{"docker.io/**"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    ImageSourceCheckName,
+				Version: ImageSourceCheckVersion,
+				Details: "Image registry.internal/team/base-alpine is from an allowed source",
+			},
+		},
+		{
+			name: "denied docker hub image",
+			check: ImageSourceCheck{
+				image:       "alpine:3.18",
+				allow:       []string{"**"},
+				deny:        []string{"docker.io/**"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ImageSourceCheckName,
+				Version:  ImageSourceCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Image docker.io/library/alpine is denied",
+			},
+		},
+		{
+			name: "image not matching the allowed patterns - mfa",
+			check: ImageSourceCheck{
+				image:     "registry.internal/team/tools:1.0",
+				allow:     []string{"registry.internal/*/base-*"},
+				mfaOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ImageSourceCheckName,
+				Version:  ImageSourceCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Image registry.internal/team/tools is not from an allowed source",
+			},
+		},
+		{
+			name: "no allow list only denies",
+			check: ImageSourceCheck{
+				image:       "registry.internal/team/tools:1.0",
+				deny:        []string{"docker.io/**"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    ImageSourceCheckName,
+				Version: ImageSourceCheckVersion,
+				Details: "Image registry.internal/team/tools is from an allowed source",
+			},
+		},
+		{
+			name: "no image",
+			check: ImageSourceCheck{
+				image:       "",
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ImageSourceCheckName,
+				Version:  ImageSourceCheckVersion,
+				Error:    imageref.ErrEmptyReference,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  ImageSourceCheckError,
+			},
+		},
+	}
+
+	for testNum, test := range imageSourceCheckTests {
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go test.check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestImageSourceCheckIsValidForCheckType(t *testing.T) {
+	check := ImageSourceCheck{}
+	if !check.IsValidForCheckType(checks.All) || !check.IsValidForCheckType(checks.ImageCheck) {
+		t.Errorf("image source check should be valid for image and all checks")
+	}
+
+	if check.IsValidForCheckType(checks.ScriptCheck) {
+		t.Errorf("image source check should not be valid for script checks")
+	}
+}
@@ -1,6 +1,7 @@
 package mfarequired
 
 import (
+	"context"
 	"strings"
 	"sync"
 
@@ -15,6 +16,15 @@ var (
 	MfaRequiredCheckDetails = "Mfa Required"
 )
 
+func init() {
+	checks.Register("mfaRequired", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewMfaRequiredCheck(config, job.JobName)
+		return &check
+	},
+		checks.Option{Name: "checkType", Type: checks.StringOption},
+	)
+}
+
 type MfaRequiredCheck struct {
 	jobName        string
 	validCheckType uint
@@ -38,10 +48,10 @@ func NewMfaRequiredCheck(config config.CheckConfig, jobName string) MfaRequiredC
 	}
 }
 
-func (check *MfaRequiredCheck) Check(channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+func (check *MfaRequiredCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
 	defer wg.Done()
 
-	result := checks.CheckResult{Name: MfaRequiredCheckName, Version: MfaRequiredCheckVersion, Mfa: true, Details: MfaRequiredCheckDetails}
+	result := checks.CheckResult{Name: MfaRequiredCheckName, Version: MfaRequiredCheckVersion, Outcome: checks.OutcomeMfa, Details: MfaRequiredCheckDetails}
 
 	channel <- result
 }
@@ -63,3 +73,8 @@ func (check *MfaRequiredCheck) IsValidForCheckType(checkType uint) bool {
 func (check *MfaRequiredCheck) IsValidForPlatform(ciPlatform string) bool {
 	return true
 }
+
+// FailOptions returns that a failure of the check requires MFA, as the check itself does.
+func (check *MfaRequiredCheck) FailOptions() (bool, bool) {
+	return false, true
+}
@@ -1,6 +1,7 @@
 package mfarequired
 
 import (
+	"context"
 	"reflect"
 	"sync"
 	"testing"
@@ -80,7 +81,7 @@ func TestMfaRequiredCheck(t *testing.T) {
 				Name:    MfaRequiredCheckName,
 				Version: MfaRequiredCheckVersion,
 				Error:   nil,
-				Mfa:     true,
+				Outcome: checks.OutcomeMfa,
 				Details: MfaRequiredCheckDetails,
 			},
 		},
@@ -92,7 +93,7 @@ func TestMfaRequiredCheck(t *testing.T) {
 		channel := make(chan checks.CheckResult, 1)
 
 		wg.Add(1)
-		go test.mfaRequiredCheck.Check(channel, &wg, whitelist)
+		go test.mfaRequiredCheck.Check(context.Background(), channel, &wg, whitelist)
 
 		wg.Wait()
 		close(channel)
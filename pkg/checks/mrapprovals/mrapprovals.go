@@ -0,0 +1,219 @@
+package mrapprovals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/gitlabapi"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	MrApprovalsCheckName           = "Merge Request Approvals Check"
+	MrApprovalsCheckVersion        = "1.0.0"
+	MrApprovalsCheckApproved       = "Merge request !%d has %d of %d required approvals (%s)"
+	MrApprovalsCheckNotApproved    = "Merge request !%d has %d of %d required approvals"
+	MrApprovalsCheckNoMergeRequest = "No merge request found for commit %s"
+	MrApprovalsCheckWrongCommit    = "Merge request !%d is not for commit %s"
+	MrApprovalsCheckError          = "---ERROR---"
+	ErrNoGitLabConfig              = errors.New("no GitLab url and token in the namespace config")
+	ErrNoCommit                    = errors.New("no commit to look the merge request up")
+	ErrWrongCommit                 = errors.New("merge request not for the commit being built")
+	mergeRequestStates             = []string{"merged", "opened"}
+)
+
+func init() {
+	checks.Register("mrApprovals", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewMrApprovalsCheck(config, job.JobName, job.ProjectPath, job.MergeRequestIID, job.CommitSha, job.GitLab)
+		return &check
+	},
+		checks.Option{Name: "minApprovals", Type: checks.NumberOption},
+		checks.Option{Name: "approvers", Type: checks.ListOption},
+		checks.Option{Name: "groups", Type: checks.ListOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+type MrApprovalsCheck struct {
+	jobName         string
+	projectPath     string
+	mergeRequestIID int
+	commitSha       string
+	gitLab          config.GitLabConfig
+	minApprovals    int
+	approvers       []string
+	groups          []string
+	abortOnFail     bool
+	mfaOnFail       bool
+}
+
+func NewMrApprovalsCheck(config config.CheckConfig, jobName string, projectPath string, mergeRequestIID int, commitSha string, gitLab config.GitLabConfig) MrApprovalsCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return MrApprovalsCheck{
+		jobName:         jobName,
+		projectPath:     projectPath,
+		mergeRequestIID: mergeRequestIID,
+		commitSha:       commitSha,
+		gitLab:          gitLab,
+		minApprovals:    checks.GetIntOption(config.Options, "minApprovals", 1),
+		approvers:       checks.GetStringListOption(config.Options, "approvers"),
+		groups:          checks.GetStringListOption(config.Options, "groups"),
+		abortOnFail:     abortOnFail,
+		mfaOnFail:       mfaOnFail,
+	}
+}
+
+func (check *MrApprovalsCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: MrApprovalsCheckName, Version: MrApprovalsCheckVersion}
+
+	iid, approvers, err := check.readApprovals(ctx)
+	if errors.Is(err, ErrWrongCommit) {
+		result.Details = fmt.Sprintf(MrApprovalsCheckWrongCommit, check.mergeRequestIID, check.commitSha)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	} else if err != nil {
+		result.Error = checks.ContextError(ctx, err)
+		result.Details = MrApprovalsCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	if iid == 0 {
+		result.Details = fmt.Sprintf(MrApprovalsCheckNoMergeRequest, check.commitSha)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else if len(approvers) >= check.minApprovals {
+		result.Details = fmt.Sprintf(MrApprovalsCheckApproved, iid, len(approvers), check.minApprovals, strings.Join(approvers, ", "))
+	} else {
+		result.Details = fmt.Sprintf(MrApprovalsCheckNotApproved, iid, len(approvers), check.minApprovals)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+// readApprovals returns the IID of the merge request (zero if there is none) and the usernames
+// of its approvers counting towards minApprovals.
+func (check *MrApprovalsCheck) readApprovals(ctx context.Context) (int, []string, error) {
+	if len(check.gitLab.URL) == 0 || len(check.gitLab.Token) == 0 {
+		return 0, nil, ErrNoGitLabConfig
+	}
+
+	client := gitlabapi.NewClient(check.gitLab.URL, check.gitLab.Token)
+
+	iid, err := check.mergeRequest(ctx, client)
+	if err != nil || iid == 0 {
+		return 0, nil, err
+	}
+
+	approvals, err := client.MergeRequestApprovals(ctx, check.projectPath, iid)
+	if err != nil {
+		return iid, nil, err
+	}
+
+	approvers, err := check.countedApprovers(ctx, client, approvals)
+	return iid, approvers, err
+}
+
+// mergeRequest returns the IID of the merge request of the pipeline or, outside of merge
+// request pipelines, of the merge request the commit is part of (preferring merged merge
+// requests). It returns zero if the commit is not part of a merge request.
+//
+// The IID of the pipeline's merge request can be overridden by the job, the merge request
+// must then be for the commit being built (ErrWrongCommit otherwise).
+func (check *MrApprovalsCheck) mergeRequest(ctx context.Context, client *gitlabapi.Client) (int, error) {
+	if len(check.commitSha) == 0 {
+		return 0, ErrNoCommit
+	}
+
+	if check.mergeRequestIID != 0 {
+		mergeRequest, err := client.MergeRequest(ctx, check.projectPath, check.mergeRequestIID)
+		if err != nil {
+			return 0, err
+		}
+
+		if mergeRequest.SHA != check.commitSha {
+			return 0, ErrWrongCommit
+		}
+
+		return mergeRequest.IID, nil
+	}
+
+	mergeRequests, err := client.CommitMergeRequests(ctx, check.projectPath, check.commitSha)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, state := range mergeRequestStates {
+		for _, mergeRequest := range mergeRequests {
+			if mergeRequest.State == state {
+				return mergeRequest.IID, nil
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// countedApprovers returns the usernames of the approvers counting towards minApprovals: every
+// approver unless approvers or groups are configured, otherwise only the configured approvers
+// and the members of the configured groups.
+func (check *MrApprovalsCheck) countedApprovers(ctx context.Context, client *gitlabapi.Client, approvals gitlabapi.Approvals) ([]string, error) {
+	var approvers []string
+	for _, approval := range approvals.ApprovedBy {
+		counted, err := check.isCountedApprover(ctx, client, approval.User)
+		if err != nil {
+			return nil, err
+		}
+
+		if counted {
+			approvers = append(approvers, approval.User.Username)
+		}
+	}
+
+	return approvers, nil
+}
+
+func (check *MrApprovalsCheck) isCountedApprover(ctx context.Context, client *gitlabapi.Client, user gitlabapi.User) (bool, error) {
+	if len(check.approvers) == 0 && len(check.groups) == 0 {
+		return true, nil
+	}
+
+	for _, approver := range check.approvers {
+		if strings.EqualFold(approver, user.Username) {
+			return true, nil
+		}
+	}
+
+	for _, group := range check.groups {
+		member, err := client.IsGroupMember(ctx, group, user.ID)
+		if err != nil || member {
+			return member, err
+		}
+	}
+
+	return false, nil
+}
+
+func (check *MrApprovalsCheck) IsValidForCheckType(checkType uint) bool {
+	return true
+}
+
+func (check *MrApprovalsCheck) IsValidForPlatform(ciPlatform string) bool {
+	return strings.EqualFold(ciPlatform, "gitlab")
+}
+
+func (check *MrApprovalsCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -0,0 +1,320 @@
+package mrapprovals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/gitlabapi"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+// newTestGitLab serves team/app where merge request !7 (at commit head7) is approved by alice
+// (a member of team/release-managers) and bob, !9 (at commit head9) is not approved, commit
+// merged7 is part of the merged !7 and of the closed !5 and commit orphan is not part of a
+// merge request.
+func newTestGitLab(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/team%2Fapp/repository/commits/merged7/merge_requests":
+			fmt.Fprint(w, `[{"iid": 5, "state": "closed"}, {"iid": 7, "state": "merged"}]`)
+		case "/api/v4/projects/team%2Fapp/repository/commits/orphan/merge_requests":
+			fmt.Fprint(w, `[]`)
+		case "/api/v4/projects/team%2Fapp/merge_requests/7":
+			fmt.Fprint(w, `{"iid": 7, "state": "merged", "sha": "head7"}`)
+		case "/api/v4/projects/team%2Fapp/merge_requests/9":
+			fmt.Fprint(w, `{"iid": 9, "state": "opened", "sha": "head9"}`)
+		case "/api/v4/projects/team%2Fapp/merge_requests/7/approvals":
+			fmt.Fprint(w, `{"approved_by": [{"user": {"id": 1, "username": "alice"}}, {"user": {"id": 2, "username": "bob"}}]}`)
+		case "/api/v4/projects/team%2Fapp/merge_requests/9/approvals":
+			fmt.Fprint(w, `{"approved_by": []}`)
+		case "/api/v4/groups/team%2Frelease-managers/members/all/1":
+			fmt.Fprint(w, `{"id": 1, "username": "alice"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewMrApprovalsCheck(t *testing.T) {
+	gitLab := config.GitLabConfig{URL: "https://gitlab.example.com", Token: "test-token"}
+	newMrApprovalsCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck MrApprovalsCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "mrApprovals",
+			},
+			expectedCheck: MrApprovalsCheck{
+				jobName:         "testJob",
+				projectPath:     "team/app",
+				mergeRequestIID: 7,
+				commitSha:       "merged7",
+				gitLab:          gitLab,
+				minApprovals:    1,
+				abortOnFail:     true,
+			},
+		},
+		{
+			name: "approvers, groups and mfaOnFail",
+			config: config.CheckConfig{
+				Name: "mrApprovals",
+				Options: map[string]interface{}{
+					"minApprovals": float64(2),
+					"approvers":    []interface{}{"bob"},
+					"groups":       []interface{}{"team/release-managers"},
+					"mfaOnFail":    true,
+				},
+			},
+			expectedCheck: MrApprovalsCheck{
+				jobName:         "testJob",
+				projectPath:     "team/app",
+				mergeRequestIID: 7,
+				commitSha:       "merged7",
+				gitLab:          gitLab,
+				minApprovals:    2,
+				approvers:       []string{"bob"},
+				groups:          []string{"team/release-managers"},
+				mfaOnFail:       true,
+			},
+		},
+	}
+
+	for testNum, test := range newMrApprovalsCheckTests {
+		check := NewMrApprovalsCheck(test.config, "testJob", "team/app", 7, "merged7", gitLab)
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestMrApprovalsCheck(t *testing.T) {
+	server := newTestGitLab(t)
+
+	mrApprovalsCheckTests := []struct {
+		name           string
+		check          MrApprovalsCheck
+		expectedResult checks.CheckResult
+	}{
+		{
+			name: "merge request pipeline approved",
+			check: MrApprovalsCheck{
+				mergeRequestIID: 7,
+				commitSha:       "head7",
+				minApprovals:    2,
+				abortOnFail:     true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    MrApprovalsCheckName,
+				Version: MrApprovalsCheckVersion,
+				Details: "Merge request !7 has 2 of 2 required approvals (alice, bob)",
+			},
+		},
+		{
+			name: "merged merge request of the commit approved by a group member",
+			check: MrApprovalsCheck{
+				commitSha:    "merged7",
+				minApprovals: 1,
+				groups:       []string{"team/release-managers"},
+				abortOnFail:  true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    MrApprovalsCheckName,
+				Version: MrApprovalsCheckVersion,
+				Details: "Merge request !7 has 1 of 1 required approvals (alice)",
+			},
+		},
+		{
+			name: "approvals of a configured approver and of a group member",
+			check: MrApprovalsCheck{
+				mergeRequestIID: 7,
+				commitSha:       "head7",
+				minApprovals:    2,
+				approvers:       []string{"Bob"},
+				groups:          []string{"team/release-managers", "team/security"},
+				mfaOnFail:       true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    MrApprovalsCheckName,
+				Version: MrApprovalsCheckVersion,
+				Details: "Merge request !7 has 2 of 2 required approvals (alice, bob)",
+			},
+		},
+		{
+			name: "configured approver missing - mfa",
+			check: MrApprovalsCheck{
+				mergeRequestIID: 7,
+				commitSha:       "head7",
+				minApprovals:    1,
+				approvers:       []string{"carol"},
+				mfaOnFail:       true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     MrApprovalsCheckName,
+				Version:  MrApprovalsCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Merge request !7 has 0 of 1 required approvals",
+			},
+		},
+		{
+			name: "not approved - abort",
+			check: MrApprovalsCheck{
+				mergeRequestIID: 9,
+				commitSha:       "head9",
+				minApprovals:    1,
+				abortOnFail:     true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     MrApprovalsCheckName,
+				Version:  MrApprovalsCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Merge request !9 has 0 of 1 required approvals",
+			},
+		},
+		{
+			name: "merge request of another commit - abort",
+			check: MrApprovalsCheck{
+				mergeRequestIID: 7,
+				commitSha:       "other",
+				minApprovals:    1,
+				abortOnFail:     true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     MrApprovalsCheckName,
+				Version:  MrApprovalsCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Merge request !7 is not for commit other",
+			},
+		},
+		{
+			name: "commit without merge request - abort",
+			check: MrApprovalsCheck{
+				commitSha:    "orphan",
+				minApprovals: 1,
+				abortOnFail:  true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     MrApprovalsCheckName,
+				Version:  MrApprovalsCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "No merge request found for commit orphan",
+			},
+		},
+	}
+
+	for testNum, test := range mrApprovalsCheckTests {
+		test.check.projectPath = "team/app"
+		test.check.gitLab = config.GitLabConfig{URL: server.URL, Token: "test-token"}
+
+		result := runCheck(&test.check)
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestMrApprovalsCheckErrors(t *testing.T) {
+	server := newTestGitLab(t)
+
+	mrApprovalsCheckErrorTests := []struct {
+		name        string
+		gitLab      config.GitLabConfig
+		commitSha   string
+		expectedErr error
+	}{
+		{
+			name:        "no gitlab config",
+			gitLab:      config.GitLabConfig{},
+			commitSha:   "merged7",
+			expectedErr: ErrNoGitLabConfig,
+		},
+		{
+			name:        "no commit",
+			gitLab:      config.GitLabConfig{URL: server.URL, Token: "test-token"},
+			commitSha:   "",
+			expectedErr: ErrNoCommit,
+		},
+		{
+			name:        "wrong token",
+			gitLab:      config.GitLabConfig{URL: server.URL, Token: "wrong-token"},
+			commitSha:   "merged7",
+			expectedErr: gitlabapi.ErrUnexpectedStatus,
+		},
+	}
+
+	for testNum, test := range mrApprovalsCheckErrorTests {
+		check := MrApprovalsCheck{
+			projectPath:  "team/app",
+			commitSha:    test.commitSha,
+			gitLab:       test.gitLab,
+			minApprovals: 1,
+			abortOnFail:  true,
+		}
+
+		result := runCheck(&check)
+		if !errors.Is(result.Error, test.expectedErr) {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%v)\ngot: (%v)", testNum, test.name, test.expectedErr, result.Error)
+			continue
+		}
+
+		expectedResult := checks.CheckResult{
+			Name:     MrApprovalsCheckName,
+			Version:  MrApprovalsCheckVersion,
+			Error:    result.Error,
+			Outcome:  checks.OutcomeAbort,
+			Severity: checks.SeverityHigh,
+			Details:  MrApprovalsCheckError,
+		}
+
+		if !result.CompareCheckResult(expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, expectedResult, result)
+		}
+	}
+}
+
+func TestMrApprovalsCheckIsValid(t *testing.T) {
+	check := MrApprovalsCheck{}
+
+	if !check.IsValidForPlatform("gitlab") || check.IsValidForPlatform("github") {
+		t.Errorf("the check must only be valid for GitLab")
+	}
+
+	if !check.IsValidForCheckType(checks.ImageCheck) || !check.IsValidForCheckType(checks.ScriptCheck) {
+		t.Errorf("the check must be valid for every check type")
+	}
+}
+
+func runCheck(check *MrApprovalsCheck) checks.CheckResult {
+	var wg sync.WaitGroup
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+	wg.Wait()
+	close(channel)
+
+	return <-channel
+}
@@ -0,0 +1,163 @@
+package checks
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var ErrInvalidPatternRule = errors.New("invalid rule")
+
+// PatternRule is a named regular expression, i.e. a script lint rule or a secret detector.
+type PatternRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// GetBoolOption returns the boolean option with the given name or the fallback if it
+// is missing or not a boolean.
+func GetBoolOption(options map[string]interface{}, name string, fallback bool) bool {
+	value, exists := options[name].(bool)
+	if !exists {
+		return fallback
+	}
+
+	return value
+}
+
+// GetStringOption returns the string option with the given name or the fallback if it
+// is missing or not a string.
+func GetStringOption(options map[string]interface{}, name string, fallback string) string {
+	value, exists := options[name].(string)
+	if !exists {
+		return fallback
+	}
+
+	return value
+}
+
+// GetStringListOption returns the list of strings with the given name. Lists decoded
+// from JSON ([]interface{}) as well as []string are supported, non-string elements are
+// skipped.
+func GetStringListOption(options map[string]interface{}, name string) []string {
+	switch values := options[name].(type) {
+	case []string:
+		return values
+	case []interface{}:
+		var list []string
+		for _, value := range values {
+			if str, ok := value.(string); ok {
+				list = append(list, str)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// GetPatternRulesOption returns the list of rules with the given name. A rule is either a
+// {"name": ..., "pattern": ...} object or a pattern string, named after its pattern if it has
+// no name. The rules parsed before an invalid rule are returned along with the error.
+func GetPatternRulesOption(options map[string]interface{}, name string) ([]PatternRule, error) {
+	rawRules, ok := options[name].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var rules []PatternRule
+	for _, rawRule := range rawRules {
+		ruleName := ""
+		pattern := ""
+
+		switch rule := rawRule.(type) {
+		case string:
+			ruleName = rule
+			pattern = rule
+		case map[string]interface{}:
+			pattern, _ = rule["pattern"].(string)
+			ruleName, _ = rule["name"].(string)
+			if len(ruleName) == 0 {
+				ruleName = pattern
+			}
+		}
+
+		if len(pattern) == 0 {
+			return rules, fmt.Errorf("%w %s: missing pattern", ErrInvalidPatternRule, ruleName)
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return rules, fmt.Errorf("%w %s: %s", ErrInvalidPatternRule, ruleName, err.Error())
+		}
+
+		rules = append(rules, PatternRule{Name: ruleName, Pattern: re})
+	}
+
+	return rules, nil
+}
+
+// GetIntOption returns the number option with the given name as an int or the fallback
+// if it is missing or not a number. Numbers decoded from JSON (float64) are supported.
+func GetIntOption(options map[string]interface{}, name string, fallback int) int {
+	value, ok := toInt(options[name])
+	if !ok {
+		return fallback
+	}
+
+	return value
+}
+
+// GetFloatOption returns the number option with the given name as a float64 or the fallback
+// if it is missing or not a number.
+func GetFloatOption(options map[string]interface{}, name string, fallback float64) float64 {
+	switch number := options[name].(type) {
+	case float64:
+		return number
+	case int:
+		return float64(number)
+	case int64:
+		return float64(number)
+	default:
+		return fallback
+	}
+}
+
+// GetIntListOption returns the list of numbers with the given name. Lists decoded from
+// JSON ([]interface{} of float64) as well as []int are supported, non-number elements
+// are skipped.
+func GetIntListOption(options map[string]interface{}, name string) []int {
+	switch values := options[name].(type) {
+	case []int:
+		return values
+	case []float64:
+		var list []int
+		for _, value := range values {
+			list = append(list, int(value))
+		}
+		return list
+	case []interface{}:
+		var list []int
+		for _, value := range values {
+			if number, ok := toInt(value); ok {
+				list = append(list, number)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch number := value.(type) {
+	case int:
+		return number, true
+	case int64:
+		return int(number), true
+	case float64:
+		return int(number), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,221 @@
+package checks
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGetBoolOption(t *testing.T) {
+	options := map[string]interface{}{
+		"abortOnFail": true,
+		"mfaOnFail":   "true",
+	}
+
+	if !GetBoolOption(options, "abortOnFail", false) {
+		t.Errorf("expected abortOnFail to be true")
+	}
+
+	if GetBoolOption(options, "mfaOnFail", false) {
+		t.Errorf("expected non boolean mfaOnFail to use the fallback")
+	}
+
+	if !GetBoolOption(options, "missing", true) {
+		t.Errorf("expected missing option to use the fallback")
+	}
+}
+
+func TestGetStringOption(t *testing.T) {
+	options := map[string]interface{}{
+		"timezone": "Europe/Zurich",
+		"scale":    3,
+	}
+
+	if value := GetStringOption(options, "timezone", "UTC"); value != "Europe/Zurich" {
+		t.Errorf("unexpected timezone - expected: (Europe/Zurich) - got: (%s)", value)
+	}
+
+	if value := GetStringOption(options, "scale", "daily"); value != "daily" {
+		t.Errorf("unexpected scale - expected: (daily) - got: (%s)", value)
+	}
+}
+
+func TestGetStringListOption(t *testing.T) {
+	getStringListOptionTests := []struct {
+		name     string
+		options  map[string]interface{}
+		expected []string
+	}{
+		{
+			name: "json decoded list",
+			options: map[string]interface{}{
+				"list": []interface{}{"main", "develop"},
+			},
+			expected: []string{"main", "develop"},
+		},
+		{
+			name: "string slice",
+			options: map[string]interface{}{
+				"list": []string{"main"},
+			},
+			expected: []string{"main"},
+		},
+		{
+			name: "non string elements are skipped",
+			options: map[string]interface{}{
+				"list": []interface{}{"main", 1, true},
+			},
+			expected: []string{"main"},
+		},
+		{
+			name:     "missing list",
+			options:  map[string]interface{}{},
+			expected: nil,
+		},
+	}
+
+	for testNum, test := range getStringListOptionTests {
+		list := GetStringListOption(test.options, "list")
+		if !reflect.DeepEqual(list, test.expected) {
+			t.Errorf("\n%d) unexpected list -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expected, list)
+		}
+	}
+}
+
+func TestGetPatternRulesOption(t *testing.T) {
+	getPatternRulesOptionTests := []struct {
+		name          string
+		options       map[string]interface{}
+		expectedNames []string
+		expectedError error
+	}{
+		{
+			name: "named rules and patterns",
+			options: map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{"name": "no-sudo", "pattern": `\bsudo\b`},
+					map[string]interface{}{"pattern": "chmod 777"},
+					"rm -rf /",
+				},
+			},
+			expectedNames: []string{"no-sudo", "chmod 777", "rm -rf /"},
+		},
+		{
+			name: "missing pattern",
+			options: map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{"name": "no-sudo", "pattern": `\bsudo\b`},
+					map[string]interface{}{"name": "empty"},
+				},
+			},
+			expectedNames: []string{"no-sudo"},
+			expectedError: ErrInvalidPatternRule,
+		},
+		{
+			name: "invalid pattern",
+			options: map[string]interface{}{
+				"rules": []interface{}{"(unclosed"},
+			},
+			expectedError: ErrInvalidPatternRule,
+		},
+		{
+			name:    "missing rules",
+			options: map[string]interface{}{},
+		},
+	}
+
+	for testNum, test := range getPatternRulesOptionTests {
+		rules, err := GetPatternRulesOption(test.options, "rules")
+
+		var names []string
+		for _, rule := range rules {
+			names = append(names, rule.Name)
+		}
+
+		if !reflect.DeepEqual(names, test.expectedNames) || !errors.Is(err, test.expectedError) || (test.expectedError == nil && err != nil) {
+			t.Errorf("\n%d) unexpected rules for %s -\nexpected: (%+v, %v)\ngot: (%+v, %v)", testNum, test.name, test.expectedNames, test.expectedError, names, err)
+		}
+	}
+}
+
+func TestGetIntOption(t *testing.T) {
+	options := map[string]interface{}{
+		"tolerance": float64(600),
+		"seconds":   30,
+		"scale":     "daily",
+	}
+
+	if value := GetIntOption(options, "tolerance", 300); value != 600 {
+		t.Errorf("unexpected json decoded tolerance - expected: (600) - got: (%d)", value)
+	}
+
+	if value := GetIntOption(options, "seconds", 0); value != 30 {
+		t.Errorf("unexpected seconds - expected: (30) - got: (%d)", value)
+	}
+
+	if value := GetIntOption(options, "scale", 1); value != 1 {
+		t.Errorf("unexpected scale - expected: (1) - got: (%d)", value)
+	}
+}
+
+func TestGetFloatOption(t *testing.T) {
+	options := map[string]interface{}{
+		"minEntropy": 3.5,
+		"minLength":  20,
+		"scale":      "daily",
+	}
+
+	if value := GetFloatOption(options, "minEntropy", 4); value != 3.5 {
+		t.Errorf("unexpected json decoded minEntropy - expected: (3.5) - got: (%f)", value)
+	}
+
+	if value := GetFloatOption(options, "minLength", 0); value != 20 {
+		t.Errorf("unexpected minLength - expected: (20) - got: (%f)", value)
+	}
+
+	if value := GetFloatOption(options, "scale", 1.5); value != 1.5 {
+		t.Errorf("unexpected scale - expected: (1.5) - got: (%f)", value)
+	}
+}
+
+func TestGetIntListOption(t *testing.T) {
+	getIntListOptionTests := []struct {
+		name     string
+		options  map[string]interface{}
+		expected []int
+	}{
+		{
+			name: "json decoded list",
+			options: map[string]interface{}{
+				"list": []interface{}{float64(1), float64(5)},
+			},
+			expected: []int{1, 5},
+		},
+		{
+			name: "int slice",
+			options: map[string]interface{}{
+				"list": []int{3},
+			},
+			expected: []int{3},
+		},
+		{
+			name: "non number elements are skipped",
+			options: map[string]interface{}{
+				"list": []interface{}{float64(2), "monday"},
+			},
+			expected: []int{2},
+		},
+		{
+			name:     "missing list",
+			options:  map[string]interface{}{},
+			expected: nil,
+		},
+	}
+
+	for testNum, test := range getIntListOptionTests {
+		list := GetIntListOption(test.options, "list")
+		if !reflect.DeepEqual(list, test.expected) {
+			t.Errorf("\n%d) unexpected list -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expected, list)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package refrestriction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/glob"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	RefRestrictionCheckName          = "Ref Restriction Check"
+	RefRestrictionCheckVersion       = "1.0.0"
+	RefRestrictionCheckNoRef         = "No Commit Ref"
+	RefRestrictionCheckNotProtected  = "%s %s is not protected"
+	RefRestrictionCheckNotAllowed    = "%s %s is not allowed"
+	RefRestrictionCheckMfaRequired   = "%s %s requires MFA"
+	RefRestrictionCheckSuccess       = "%s %s is allowed"
+	ErrNoCommitRef                   = errors.New("no commit ref provided")
+	refRestrictionBranchKind         = "Branch"
+	refRestrictionTagKind            = "Tag"
+	refRestrictionGitHubBranchPrefix = "refs/heads/"
+	refRestrictionGitHubTagPrefix    = "refs/tags/"
+)
+
+func init() {
+	checks.Register("refRestriction", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewRefRestrictionCheck(config, job.JobName, job.CiPlatform, job.CommitRefName, job.CommitTag, job.CommitRefProtected)
+		return &check
+	},
+		checks.Option{Name: "allowedBranches", Type: checks.ListOption},
+		checks.Option{Name: "allowedTags", Type: checks.ListOption},
+		checks.Option{Name: "mfaBranches", Type: checks.ListOption},
+		checks.Option{Name: "mfaTags", Type: checks.ListOption},
+		checks.Option{Name: "requireProtected", Type: checks.BoolOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+type RefRestrictionCheck struct {
+	jobName          string
+	refName          string
+	isTag            bool
+	refProtected     bool
+	allowedBranches  []string
+	allowedTags      []string
+	mfaBranches      []string
+	mfaTags          []string
+	requireProtected bool
+	abortOnFail      bool
+	mfaOnFail        bool
+}
+
+// NewRefRestrictionCheck creates a ref restriction check for the commit ref the job is running for.
+// The refName is either a short branch or tag name (GitLab CI_COMMIT_REF_NAME) or a full git ref
+// (GitHub GITHUB_REF, i.e. refs/heads/main or refs/tags/v1.0.0). The ref prefixes are only
+// stripped on GitHub, on GitLab the ref is a tag if commitTag (CI_COMMIT_TAG) is set.
+func NewRefRestrictionCheck(config config.CheckConfig, jobName string, ciPlatform string, refName string, commitTag string, refProtected bool) RefRestrictionCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	isTag := false
+	isGitHub := strings.EqualFold(ciPlatform, "github")
+	if isGitHub && strings.HasPrefix(refName, refRestrictionGitHubTagPrefix) {
+		isTag = true
+		refName = strings.TrimPrefix(refName, refRestrictionGitHubTagPrefix)
+	} else if isGitHub {
+		refName = strings.TrimPrefix(refName, refRestrictionGitHubBranchPrefix)
+	} else if len(commitTag) != 0 {
+		isTag = true
+		refName = commitTag
+	}
+
+	return RefRestrictionCheck{
+		jobName:          jobName,
+		refName:          refName,
+		isTag:            isTag,
+		refProtected:     refProtected,
+		allowedBranches:  checks.GetStringListOption(config.Options, "allowedBranches"),
+		allowedTags:      checks.GetStringListOption(config.Options, "allowedTags"),
+		mfaBranches:      checks.GetStringListOption(config.Options, "mfaBranches"),
+		mfaTags:          checks.GetStringListOption(config.Options, "mfaTags"),
+		requireProtected: checks.GetBoolOption(config.Options, "requireProtected", false),
+		abortOnFail:      abortOnFail,
+		mfaOnFail:        mfaOnFail,
+	}
+}
+
+func (check *RefRestrictionCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: RefRestrictionCheckName, Version: RefRestrictionCheckVersion}
+
+	if len(check.refName) == 0 {
+		result.Error = ErrNoCommitRef
+		result.Details = RefRestrictionCheckNoRef
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	kind := refRestrictionBranchKind
+	allowed := check.allowedBranches
+	mfa := check.mfaBranches
+	if check.isTag {
+		kind = refRestrictionTagKind
+		allowed = check.allowedTags
+		mfa = check.mfaTags
+	}
+
+	if check.requireProtected && !check.refProtected {
+		result.Details = fmt.Sprintf(RefRestrictionCheckNotProtected, kind, check.refName)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else if glob.MatchAny(allowed, check.refName) {
+		result.Details = fmt.Sprintf(RefRestrictionCheckSuccess, kind, check.refName)
+	} else if glob.MatchAny(mfa, check.refName) {
+		result.SetOutcome(checks.OutcomeMfa)
+		result.Details = fmt.Sprintf(RefRestrictionCheckMfaRequired, kind, check.refName)
+	} else {
+		result.Details = fmt.Sprintf(RefRestrictionCheckNotAllowed, kind, check.refName)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+func (check *RefRestrictionCheck) IsValidForCheckType(checkType uint) bool {
+	return true
+}
+
+func (check *RefRestrictionCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *RefRestrictionCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
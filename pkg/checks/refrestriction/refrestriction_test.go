@@ -0,0 +1,264 @@
+package refrestriction
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+func TestNewRefRestrictionCheck(t *testing.T) {
+	newRefRestrictionCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		ciPlatform    string
+		refName       string
+		commitTag     string
+		refProtected  bool
+		expectedCheck RefRestrictionCheck
+	}{
+		{
+			name: "gitlab branch with defaults",
+			config: config.CheckConfig{
+				Name: "refRestriction",
+				Options: map[string]interface{}{
+					"allowedBranches": []interface{}{"main"},
+				},
+			},
+			ciPlatform:   "gitlab",
+			refName:      "main",
+			commitTag:    "",
+			refProtected: true,
+			expectedCheck: RefRestrictionCheck{
+				jobName:         "testJob",
+				refName:         "main",
+				isTag:           false,
+				refProtected:    true,
+				allowedBranches: []string{"main"},
+				abortOnFail:     true,
+				mfaOnFail:       false,
+			},
+		},
+		{
+			name: "gitlab tag",
+			config: config.CheckConfig{
+				Name: "refRestriction",
+				Options: map[string]interface{}{
+					"allowedTags": []interface{}{"v*"},
+					"mfaOnFail":   true,
+				},
+			},
+			ciPlatform:   "gitlab",
+			refName:      "v1.0.0",
+			commitTag:    "v1.0.0",
+			refProtected: false,
+			expectedCheck: RefRestrictionCheck{
+				jobName:     "testJob",
+				refName:     "v1.0.0",
+				isTag:       true,
+				allowedTags: []string{"v*"},
+				abortOnFail: false,
+				mfaOnFail:   true,
+			},
+		},
+		{
+			name: "github branch ref",
+			config: config.CheckConfig{
+				Name: "refRestriction",
+				Options: map[string]interface{}{
+					"mfaBranches":      []interface{}{"release/*"},
+					"requireProtected": true,
+					"abortOnFail":      true,
+					"mfaOnFail":        true,
+				},
+			},
+			ciPlatform:   "github",
+			refName:      "refs/heads/release/1.0",
+			refProtected: true,
+			expectedCheck: RefRestrictionCheck{
+				jobName:          "testJob",
+				refName:          "release/1.0",
+				isTag:            false,
+				refProtected:     true,
+				mfaBranches:      []string{"release/*"},
+				requireProtected: true,
+				abortOnFail:      true,
+				mfaOnFail:        true,
+			},
+		},
+		{
+			name: "github tag ref",
+			config: config.CheckConfig{
+				Name:    "refRestriction",
+				Options: map[string]interface{}{},
+			},
+			ciPlatform: "github",
+			refName:    "refs/tags/v2.0.0",
+			expectedCheck: RefRestrictionCheck{
+				jobName:     "testJob",
+				refName:     "v2.0.0",
+				isTag:       true,
+				abortOnFail: true,
+			},
+		},
+		{
+			name: "gitlab branch named like a tag ref",
+			config: config.CheckConfig{
+				Name: "refRestriction",
+				Options: map[string]interface{}{
+					"allowedTags": []interface{}{"v*"},
+				},
+			},
+			ciPlatform: "gitlab",
+			refName:    "refs/tags/v1.0.0",
+			expectedCheck: RefRestrictionCheck{
+				jobName:     "testJob",
+				refName:     "refs/tags/v1.0.0",
+				isTag:       false,
+				allowedTags: []string{"v*"},
+				abortOnFail: true,
+			},
+		},
+	}
+
+	for testNum, test := range newRefRestrictionCheckTests {
+		check := NewRefRestrictionCheck(test.config, "testJob", test.ciPlatform, test.refName, test.commitTag, test.refProtected)
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestRefRestrictionCheck(t *testing.T) {
+	refRestrictionCheckTests := []struct {
+		name           string
+		check          RefRestrictionCheck
+		expectedResult checks.CheckResult
+	}{
+		{
+			name: "allowed branch",
+			check: RefRestrictionCheck{
+				refName:         "main",
+				allowedBranches: []string{"main"},
+				abortOnFail:     true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    RefRestrictionCheckName,
+				Version: RefRestrictionCheckVersion,
+				Details: "Branch main is allowed",
+			},
+		},
+		{
+			name: "branch matching an allowed tag pattern is not allowed",
+			check: RefRestrictionCheck{
+				refName:     "v1.0.0",
+				allowedTags: []string{"v*"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     RefRestrictionCheckName,
+				Version:  RefRestrictionCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Branch v1.0.0 is not allowed",
+			},
+		},
+		{
+			name: "allowed tag",
+			check: RefRestrictionCheck{
+				refName:     "v1.0.0",
+				isTag:       true,
+				allowedTags: []string{"v*"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    RefRestrictionCheckName,
+				Version: RefRestrictionCheckVersion,
+				Details: "Tag v1.0.0 is allowed",
+			},
+		},
+		{
+			name: "branch requiring mfa",
+			check: RefRestrictionCheck{
+				refName:         "release/1.0",
+				allowedBranches: []string{"main"},
+				mfaBranches:     []string{"release/*"},
+				abortOnFail:     true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     RefRestrictionCheckName,
+				Version:  RefRestrictionCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Branch release/1.0 requires MFA",
+			},
+		},
+		{
+			name: "branch not allowed - mfaOnFail",
+			check: RefRestrictionCheck{
+				refName:         "feature/test",
+				allowedBranches: []string{"main"},
+				mfaOnFail:       true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     RefRestrictionCheckName,
+				Version:  RefRestrictionCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Branch feature/test is not allowed",
+			},
+		},
+		{
+			name: "allowed branch which is not protected",
+			check: RefRestrictionCheck{
+				refName:          "main",
+				allowedBranches:  []string{"main"},
+				requireProtected: true,
+				abortOnFail:      true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     RefRestrictionCheckName,
+				Version:  RefRestrictionCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Branch main is not protected",
+			},
+		},
+		{
+			name: "no ref",
+			check: RefRestrictionCheck{
+				allowedBranches: []string{"main"},
+				abortOnFail:     true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     RefRestrictionCheckName,
+				Version:  RefRestrictionCheckVersion,
+				Error:    ErrNoCommitRef,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  RefRestrictionCheckNoRef,
+			},
+		},
+	}
+
+	for testNum, test := range refRestrictionCheckTests {
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go test.check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+)
+
+var ErrInvalidCheckOption = errors.New("invalid check option")
+
+// OptionType is the JSON type expected for a check option.
+type OptionType uint
+
+const (
+	BoolOption OptionType = iota
+	StringOption
+	NumberOption
+	ListOption
+	ObjectOption
+)
+
+func (t OptionType) String() string {
+	switch t {
+	case BoolOption:
+		return "bool"
+	case StringOption:
+		return "string"
+	case NumberOption:
+		return "number"
+	case ListOption:
+		return "list"
+	case ObjectOption:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// Option describes a single option accepted by a check in its configuration.
+type Option struct {
+	Name string
+	Type OptionType
+}
+
+// SecretReader gives checks read access to the documents stored in the vault next to
+// the whitelists (i.e. {root}/{namespace}/users).
+type SecretReader interface {
+	// ReadSecret returns the JSON encoded data of the secret at the given mount or nil
+	// if no secret exists at that mount.
+	ReadSecret(ctx context.Context, mount string) ([]byte, error)
+}
+
+// JobInfo holds the information about the current CI job that is handed to
+// every check constructor (besides the check's own configuration).
+type JobInfo struct {
+	JobName            string
+	Image              string
+	ScriptLines        []string
+	CiPlatform         string
+	UserEmail          string
+	CommitRefName      string
+	CommitTag          string
+	CommitRefProtected bool
+	CommitSha          string
+	ProjectDir         string
+	ProjectPath        string
+
+	// Hostname is the host the runner executes the job on and RunnerTags the runner tags as
+	// given by the CI/CD platform (GitLab CI_RUNNER_TAGS is a JSON list).
+	Hostname          string
+	RunnerId          string
+	RunnerDescription string
+	RunnerTags        string
+
+	// DockerRunArgs are the arguments the custom executor passes to docker run (word split
+	// like prepare.sh does) and Services the images of the job's service containers.
+	DockerRunArgs string
+	Services      []string
+
+	// MergeRequestIID is the IID of the merge request of a merge request pipeline (zero
+	// otherwise) and GitLab gives access to the GitLab REST API.
+	MergeRequestIID int
+	GitLab          config.GitLabConfig
+
+	// CheckType is the type of the checks being run (ImageCheck, ScriptCheck or All).
+	CheckType uint
+
+	// PipelineSource is the pipeline source in the common source vocabulary (i.e. SourcePush)
+	// and PlatformPipelineSource the source as given by the CI/CD platform.
+	PipelineSource         string
+	PlatformPipelineSource string
+
+	// Vault gives access to the secrets below the namespace ({root}/{namespace}) and
+	// project ({root}/{namespace}/{project}) mounts.
+	Vault          SecretReader
+	NamespaceMount string
+	ProjectMount   string
+}
+
+// Constructor creates a new check from its configuration and the current job information.
+type Constructor func(config config.CheckConfig, job JobInfo) Check
+
+// Registration is a check registered under a name with its option schema.
+type Registration struct {
+	Name        string
+	Constructor Constructor
+	Options     []Option
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[string]Registration)
+)
+
+// Register makes a check available under the given name (case insensitive) so it
+// can be referenced from a project configuration. It is meant to be called from the
+// init function of the package implementing the check. If Register is called twice
+// with the same name or with a nil constructor, it panics.
+//
+// Example:
+//
+//	func init() {
+//		checks.Register("scriptHash", newCheck, checks.Option{Name: "abortOnFail", Type: checks.BoolOption})
+//	}
+func Register(name string, constructor Constructor, options ...Option) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if constructor == nil {
+		panic("checks: Register constructor is nil for check " + name)
+	}
+
+	key := strings.ToLower(name)
+	if _, exists := registry[key]; exists {
+		panic("checks: Register called twice for check " + name)
+	}
+
+	registry[key] = Registration{
+		Name:        name,
+		Constructor: constructor,
+		Options:     options,
+	}
+}
+
+// Lookup returns the registration of the check with the given name (case insensitive).
+func Lookup(name string) (Registration, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	registration, exists := registry[strings.ToLower(name)]
+	return registration, exists
+}
+
+// RegisteredNames returns the sorted names of every registered check.
+func RegisteredNames() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for _, registration := range registry {
+		names = append(names, registration.Name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ValidateOptions checks that every option present in the configuration which is
+// part of the registration's schema has the expected type.
+func (r Registration) ValidateOptions(options map[string]interface{}) error {
+	for _, option := range r.Options {
+		value, exists := options[option.Name]
+		if !exists || value == nil {
+			continue
+		}
+
+		if !option.Type.matches(value) {
+			return fmt.Errorf("%w: %s option %q must be a %s", ErrInvalidCheckOption, r.Name, option.Name, option.Type)
+		}
+	}
+
+	return nil
+}
+
+func (t OptionType) matches(value interface{}) bool {
+	kind := reflect.TypeOf(value).Kind()
+	switch t {
+	case BoolOption:
+		return kind == reflect.Bool
+	case StringOption:
+		return kind == reflect.String
+	case NumberOption:
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	case ListOption:
+		return kind == reflect.Slice || kind == reflect.Array
+	case ObjectOption:
+		return kind == reflect.Map
+	default:
+		return false
+	}
+}
@@ -0,0 +1,180 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+type registryTestCheck struct {
+	jobName string
+}
+
+func (check *registryTestCheck) Check(ctx context.Context, channel chan<- CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+	channel <- CheckResult{Name: "Registry Test Check"}
+}
+
+func (check *registryTestCheck) IsValidForCheckType(checkType uint) bool {
+	return true
+}
+
+func (check *registryTestCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *registryTestCheck) FailOptions() (bool, bool) {
+	return true, false
+}
+
+func newRegistryTestCheck(config config.CheckConfig, job JobInfo) Check {
+	return &registryTestCheck{jobName: job.JobName}
+}
+
+func TestRegisterAndLookup(t *testing.T) {
+	Register("registryTestCheck", newRegistryTestCheck, Option{Name: "abortOnFail", Type: BoolOption})
+
+	lookupTests := []struct {
+		name   string
+		lookup string
+		found  bool
+	}{
+		{
+			name:   "lookup registered check",
+			lookup: "registryTestCheck",
+			found:  true,
+		},
+		{
+			name:   "lookup registered check with different case",
+			lookup: "REGISTRYTESTCHECK",
+			found:  true,
+		},
+		{
+			name:   "lookup unknown check",
+			lookup: "unknownCheck",
+			found:  false,
+		},
+	}
+
+	for testNum, test := range lookupTests {
+		registration, found := Lookup(test.lookup)
+		if found != test.found {
+			t.Errorf("\n%d) unexpected found -\nexpected: (%t)\ngot: (%t)", testNum, test.found, found)
+			continue
+		}
+
+		if !found {
+			continue
+		}
+
+		if registration.Name != "registryTestCheck" {
+			t.Errorf("\n%d) unexpected name -\nexpected: (%s)\ngot: (%s)", testNum, "registryTestCheck", registration.Name)
+		}
+
+		check := registration.Constructor(config.CheckConfig{}, JobInfo{JobName: "testJob"})
+		expected := &registryTestCheck{jobName: "testJob"}
+		if !reflect.DeepEqual(check, expected) {
+			t.Errorf("\n%d) unexpected check -\nexpected: (%+v)\ngot: (%+v)", testNum, expected, check)
+		}
+	}
+
+	found := false
+	for _, name := range RegisteredNames() {
+		if name == "registryTestCheck" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("expected registryTestCheck in registered names - got: %+v", RegisteredNames())
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	Register("duplicateTestCheck", newRegistryTestCheck)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a duplicate check name to panic")
+		}
+	}()
+
+	Register("DuplicateTestCheck", newRegistryTestCheck)
+}
+
+func TestValidateOptions(t *testing.T) {
+	registration := Registration{
+		Name: "validateTestCheck",
+		Options: []Option{
+			{Name: "abortOnFail", Type: BoolOption},
+			{Name: "scale", Type: StringOption},
+			{Name: "tolerance", Type: NumberOption},
+			{Name: "intervals", Type: ListOption},
+			{Name: "groups", Type: ObjectOption},
+		},
+	}
+
+	validateOptionsTests := []struct {
+		name    string
+		options map[string]interface{}
+		err     error
+	}{
+		{
+			name:    "no options",
+			options: nil,
+			err:     nil,
+		},
+		{
+			name: "valid json decoded options",
+			options: map[string]interface{}{
+				"abortOnFail": true,
+				"scale":       "weekly",
+				"tolerance":   float64(300),
+				"intervals":   []interface{}{float64(1), float64(2)},
+				"groups":      map[string]interface{}{},
+			},
+			err: nil,
+		},
+		{
+			name: "valid go typed options",
+			options: map[string]interface{}{
+				"tolerance": 300,
+				"intervals": []int{1, 2},
+			},
+			err: nil,
+		},
+		{
+			name: "options outside of the schema are ignored",
+			options: map[string]interface{}{
+				"somethingElse": 1,
+			},
+			err: nil,
+		},
+		{
+			name: "invalid bool option",
+			options: map[string]interface{}{
+				"abortOnFail": "true",
+			},
+			err: ErrInvalidCheckOption,
+		},
+		{
+			name: "invalid list option",
+			options: map[string]interface{}{
+				"intervals": 1,
+			},
+			err: ErrInvalidCheckOption,
+		},
+	}
+
+	for testNum, test := range validateOptionsTests {
+		err := registration.ValidateOptions(test.options)
+		if !errors.Is(err, test.err) {
+			t.Errorf("\n%d) unexpected error -\nexpected: (%+v)\ngot: (%+v)", testNum, test.err, err)
+		}
+	}
+}
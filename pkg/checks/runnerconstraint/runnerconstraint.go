@@ -0,0 +1,191 @@
+package runnerconstraint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/glob"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	RunnerConstraintCheckName        = "Runner Constraint Check"
+	RunnerConstraintCheckVersion     = "1.0.0"
+	RunnerConstraintCheckSuccess     = "Runner %s (id: %s) on host %s satisfies the constraints"
+	RunnerConstraintCheckFound       = "Runner Constraints Violated:"
+	RunnerConstraintCheckHostname    = "\n - host %q is not an allowed host"
+	RunnerConstraintCheckRunnerId    = "\n - runner id %q is not an allowed runner"
+	RunnerConstraintCheckDescription = "\n - runner %q is not an allowed runner"
+	RunnerConstraintCheckMissingTag  = "\n - runner is not tagged %q"
+	RunnerConstraintCheckDeniedTag   = "\n - runner is tagged %q"
+	RunnerConstraintCheckUnknown     = "unknown"
+)
+
+func init() {
+	checks.Register("runnerConstraint", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewRunnerConstraintCheck(config, job.JobName, job.Hostname, job.RunnerId, job.RunnerDescription, job.RunnerTags)
+		return &check
+	},
+		checks.Option{Name: "hostnames", Type: checks.ListOption},
+		checks.Option{Name: "runnerIds", Type: checks.ListOption},
+		checks.Option{Name: "runnerDescriptions", Type: checks.ListOption},
+		checks.Option{Name: "requiredTags", Type: checks.ListOption},
+		checks.Option{Name: "deniedTags", Type: checks.ListOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+type RunnerConstraintCheck struct {
+	jobName            string
+	hostname           string
+	runnerId           string
+	runnerDescription  string
+	runnerTags         []string
+	hostnames          []string
+	runnerIds          []string
+	runnerDescriptions []string
+	requiredTags       []string
+	deniedTags         []string
+	abortOnFail        bool
+	mfaOnFail          bool
+}
+
+// NewRunnerConstraintCheck creates a runner constraint check. The runner tags are given as
+// provided by the CI/CD platform, either as a JSON list (i.e. ["docker", "prod"]) or comma
+// separated (i.e. "docker, prod").
+func NewRunnerConstraintCheck(config config.CheckConfig, jobName string, hostname string, runnerId string, runnerDescription string, runnerTags string) RunnerConstraintCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	// runner ids may be given as numbers or as strings
+	runnerIds := checks.GetStringListOption(config.Options, "runnerIds")
+	for _, id := range checks.GetIntListOption(config.Options, "runnerIds") {
+		runnerIds = append(runnerIds, strconv.Itoa(id))
+	}
+
+	return RunnerConstraintCheck{
+		jobName:            jobName,
+		hostname:           hostname,
+		runnerId:           runnerId,
+		runnerDescription:  runnerDescription,
+		runnerTags:         ParseRunnerTags(runnerTags),
+		hostnames:          checks.GetStringListOption(config.Options, "hostnames"),
+		runnerIds:          runnerIds,
+		runnerDescriptions: checks.GetStringListOption(config.Options, "runnerDescriptions"),
+		requiredTags:       checks.GetStringListOption(config.Options, "requiredTags"),
+		deniedTags:         checks.GetStringListOption(config.Options, "deniedTags"),
+		abortOnFail:        abortOnFail,
+		mfaOnFail:          mfaOnFail,
+	}
+}
+
+// ParseRunnerTags parses the runner tags given as a JSON list (GitLab CI_RUNNER_TAGS) or comma
+// separated.
+func ParseRunnerTags(runnerTags string) []string {
+	runnerTags = strings.TrimSpace(runnerTags)
+	if len(runnerTags) == 0 {
+		return nil
+	}
+
+	var tags []string
+	if strings.HasPrefix(runnerTags, "[") && json.Unmarshal([]byte(runnerTags), &tags) == nil {
+		return tags
+	}
+
+	for _, tag := range strings.Split(runnerTags, ",") {
+		if tag = strings.TrimSpace(tag); len(tag) != 0 {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func (check *RunnerConstraintCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: RunnerConstraintCheckName, Version: RunnerConstraintCheckVersion}
+
+	violations := check.violations()
+	if len(violations) == 0 {
+		result.Details = fmt.Sprintf(RunnerConstraintCheckSuccess, orUnknown(check.runnerDescription), orUnknown(check.runnerId), orUnknown(check.hostname))
+	} else {
+		result.Details = RunnerConstraintCheckFound + strings.Join(violations, "")
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+// violations returns the constraints the runner does not satisfy. Constraints which are not
+// configured are not checked.
+func (check *RunnerConstraintCheck) violations() []string {
+	var violations []string
+
+	if len(check.hostnames) != 0 && !glob.MatchAny(check.hostnames, check.hostname) {
+		violations = append(violations, fmt.Sprintf(RunnerConstraintCheckHostname, check.hostname))
+	}
+
+	if len(check.runnerIds) != 0 && !contains(check.runnerIds, check.runnerId) {
+		violations = append(violations, fmt.Sprintf(RunnerConstraintCheckRunnerId, check.runnerId))
+	}
+
+	if len(check.runnerDescriptions) != 0 && !glob.MatchAny(check.runnerDescriptions, check.runnerDescription) {
+		violations = append(violations, fmt.Sprintf(RunnerConstraintCheckDescription, check.runnerDescription))
+	}
+
+	for _, tag := range check.requiredTags {
+		if !contains(check.runnerTags, tag) {
+			violations = append(violations, fmt.Sprintf(RunnerConstraintCheckMissingTag, tag))
+		}
+	}
+
+	for _, tag := range check.deniedTags {
+		if contains(check.runnerTags, tag) {
+			violations = append(violations, fmt.Sprintf(RunnerConstraintCheckDeniedTag, tag))
+		}
+	}
+
+	return violations
+}
+
+func contains(values []string, value string) bool {
+	if len(value) == 0 {
+		return false
+	}
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func orUnknown(value string) string {
+	if len(value) == 0 {
+		return RunnerConstraintCheckUnknown
+	}
+
+	return value
+}
+
+func (check *RunnerConstraintCheck) IsValidForCheckType(checkType uint) bool {
+	return true
+}
+
+func (check *RunnerConstraintCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *RunnerConstraintCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
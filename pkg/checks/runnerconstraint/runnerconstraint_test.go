@@ -0,0 +1,202 @@
+package runnerconstraint
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+func TestNewRunnerConstraintCheck(t *testing.T) {
+	newRunnerConstraintCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck RunnerConstraintCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "runnerConstraint",
+			},
+			expectedCheck: RunnerConstraintCheck{
+				jobName:           "testJob",
+				hostname:          "runner-prod-1.internal",
+				runnerId:          "42",
+				runnerDescription: "prod-runner-1",
+				runnerTags:        []string{"docker", "prod"},
+				abortOnFail:       true,
+			},
+		},
+		{
+			name: "constraints and mfaOnFail",
+			config: config.CheckConfig{
+				Name: "runnerConstraint",
+				Options: map[string]interface{}{
+					"hostnames":          []interface{}{"runner-prod-*.internal"},
+					"runnerIds":          []interface{}{float64(42), "43"},
+					"runnerDescriptions": []interface{}{"prod-runner-*"},
+					"requiredTags":       []interface{}{"prod"},
+					"deniedTags":         []interface{}{"shared"},
+					"mfaOnFail":          true,
+				},
+			},
+			expectedCheck: RunnerConstraintCheck{
+				jobName:            "testJob",
+				hostname:           "runner-prod-1.internal",
+				runnerId:           "42",
+				runnerDescription:  "prod-runner-1",
+				runnerTags:         []string{"docker", "prod"},
+				hostnames:          []string{"runner-prod-*.internal"},
+				runnerIds:          []string{"43", "42"},
+				runnerDescriptions: []string{"prod-runner-*"},
+				requiredTags:       []string{"prod"},
+				deniedTags:         []string{"shared"},
+				mfaOnFail:          true,
+			},
+		},
+	}
+
+	for testNum, test := range newRunnerConstraintCheckTests {
+		check := NewRunnerConstraintCheck(test.config, "testJob", "runner-prod-1.internal", "42", "prod-runner-1", `["docker", "prod"]`)
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestParseRunnerTags(t *testing.T) {
+	parseRunnerTagsTests := []struct {
+		runnerTags   string
+		expectedTags []string
+	}{
+		{runnerTags: `["docker", "prod"]`, expectedTags: []string{"docker", "prod"}},
+		{runnerTags: "docker, prod", expectedTags: []string{"docker", "prod"}},
+		{runnerTags: "prod", expectedTags: []string{"prod"}},
+		{runnerTags: "[]", expectedTags: []string{}},
+		{runnerTags: "", expectedTags: nil},
+	}
+
+	for testNum, test := range parseRunnerTagsTests {
+		tags := ParseRunnerTags(test.runnerTags)
+		if !reflect.DeepEqual(tags, test.expectedTags) {
+			t.Errorf("\n%d) tags not equal -\nexpected: (%#v)\ngot: (%#v)", testNum, test.expectedTags, tags)
+		}
+	}
+}
+
+func TestRunnerConstraintCheck(t *testing.T) {
+	runnerConstraintCheckTests := []struct {
+		name           string
+		options        map[string]interface{}
+		runnerTags     string
+		expectedResult checks.CheckResult
+	}{
+		{
+			name:       "no constraints",
+			runnerTags: `["docker"]`,
+			expectedResult: checks.CheckResult{
+				Name:    RunnerConstraintCheckName,
+				Version: RunnerConstraintCheckVersion,
+				Details: "Runner prod-runner-1 (id: 42) on host runner-prod-1.internal satisfies the constraints",
+			},
+		},
+		{
+			name: "all constraints satisfied",
+			options: map[string]interface{}{
+				"hostnames":          []interface{}{"runner-prod-*.internal"},
+				"runnerIds":          []interface{}{float64(42)},
+				"runnerDescriptions": []interface{}{"prod-runner-*"},
+				"requiredTags":       []interface{}{"prod"},
+				"deniedTags":         []interface{}{"shared"},
+			},
+			runnerTags: `["docker", "prod"]`,
+			expectedResult: checks.CheckResult{
+				Name:    RunnerConstraintCheckName,
+				Version: RunnerConstraintCheckVersion,
+				Details: "Runner prod-runner-1 (id: 42) on host runner-prod-1.internal satisfies the constraints",
+			},
+		},
+		{
+			name: "misrouted job on a shared runner - abort",
+			options: map[string]interface{}{
+				"requiredTags": []interface{}{"prod"},
+				"deniedTags":   []interface{}{"shared"},
+			},
+			runnerTags: "docker, shared",
+			expectedResult: checks.CheckResult{
+				Name:     RunnerConstraintCheckName,
+				Version:  RunnerConstraintCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Runner Constraints Violated:\n - runner is not tagged \"prod\"\n - runner is tagged \"shared\"",
+			},
+		},
+		{
+			name: "wrong host and runner - mfa",
+			options: map[string]interface{}{
+				"hostnames":          []interface{}{"runner-staging-*.internal"},
+				"runnerIds":          []interface{}{"7"},
+				"runnerDescriptions": []interface{}{"staging-*"},
+				"mfaOnFail":          true,
+			},
+			runnerTags: `["prod"]`,
+			expectedResult: checks.CheckResult{
+				Name:     RunnerConstraintCheckName,
+				Version:  RunnerConstraintCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details: "Runner Constraints Violated:" +
+					"\n - host \"runner-prod-1.internal\" is not an allowed host" +
+					"\n - runner id \"42\" is not an allowed runner" +
+					"\n - runner \"prod-runner-1\" is not an allowed runner",
+			},
+		},
+	}
+
+	for testNum, test := range runnerConstraintCheckTests {
+		check := NewRunnerConstraintCheck(config.CheckConfig{Name: "runnerConstraint", Options: test.options}, "testJob", "runner-prod-1.internal", "42", "prod-runner-1", test.runnerTags)
+
+		result := runCheck(&check)
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestRunnerConstraintCheckUnknownRunner(t *testing.T) {
+	options := map[string]interface{}{
+		"runnerIds":    []interface{}{float64(42)},
+		"requiredTags": []interface{}{"prod"},
+	}
+	check := NewRunnerConstraintCheck(config.CheckConfig{Name: "runnerConstraint", Options: options}, "testJob", "", "", "", "")
+
+	result := runCheck(&check)
+	expectedResult := checks.CheckResult{
+		Name:     RunnerConstraintCheckName,
+		Version:  RunnerConstraintCheckVersion,
+		Outcome:  checks.OutcomeAbort,
+		Severity: checks.SeverityHigh,
+		Details:  "Runner Constraints Violated:\n - runner id \"\" is not an allowed runner\n - runner is not tagged \"prod\"",
+	}
+
+	if !result.CompareCheckResult(expectedResult) {
+		t.Errorf("Results not equal for an unknown runner -\nexpected: (%+v)\ngot: (%+v)", expectedResult, result)
+	}
+}
+
+func runCheck(check *RunnerConstraintCheck) checks.CheckResult {
+	var wg sync.WaitGroup
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+	wg.Wait()
+	close(channel)
+
+	return <-channel
+}
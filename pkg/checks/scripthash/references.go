@@ -0,0 +1,99 @@
+package scripthash
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNoProjectDir is returned if the referenced scripts are hashed but the project directory
+// is not available on the host (i.e. the sources could not be copied from the job container).
+var ErrNoProjectDir = errors.New("project directory not available to hash the referenced scripts")
+
+// referencePattern matches the paths of script files called from the job script
+// (i.e. "./deploy.sh", "scripts/build.py", "/builds/group/project/test.sh").
+var referencePattern = regexp.MustCompile(`(\./|/|[a-zA-Z0-9_-])([a-zA-Z0-9_-]*/)*\.?[a-zA-Z0-9_-]*\.(sh|py|ps1|rb|js)\b`)
+
+// scriptFile is a script file referenced by the job script.
+type scriptFile struct {
+	// name is the path of the file relative to the project directory (i.e. "scripts/deploy.sh").
+	name    string
+	content []byte
+}
+
+// findReferencedScripts returns the script files referenced by the script lines which exist
+// in the project directory, in the order they are first referenced. Relative paths are resolved
+// against the project directory and paths outside of it are ignored. A missing project directory
+// is an error if the script references any file: the files would be skipped without being checked.
+func findReferencedScripts(scriptLines []string, projectDir string) ([]scriptFile, error) {
+	var references []string
+	for _, line := range scriptLines {
+		references = append(references, referencePattern.FindAllString(line, -1)...)
+	}
+
+	if len(references) == 0 {
+		return nil, nil
+	}
+
+	if len(projectDir) == 0 {
+		return nil, ErrNoProjectDir
+	}
+
+	root, err := filepath.EvalSymlinks(projectDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNoProjectDir, projectDir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var files []scriptFile
+	seen := make(map[string]bool)
+
+	for _, reference := range references {
+		path := reference
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+
+		name, exists := projectPath(root, path)
+		if !exists || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		content, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, scriptFile{name: filepath.ToSlash(name), content: content})
+	}
+
+	return files, nil
+}
+
+// projectPath returns the path of the regular file relative to the project root and whether
+// the file exists within the project (following symbolic links).
+func projectPath(root string, path string) (string, bool) {
+	// references are guessed from the script, anything which does not resolve is not a file
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+
+	name, err := filepath.Rel(root, resolved)
+	if err != nil || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return name, true
+}
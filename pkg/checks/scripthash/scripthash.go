@@ -1,6 +1,7 @@
 package scripthash
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
@@ -9,27 +10,57 @@ import (
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/diff"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
 )
 
 var (
 	ScriptHashCheckName                 = "Script Hash Check"
-	ScriptHashCheckVersion              = "1.0.0"
+	ScriptHashCheckVersion              = "1.1.0"
 	ScriptHashCheckEmptyScriptDetails   = "No Script"
 	ScriptHashCheckAbortScriptDetails   = "Unknown Script %s@%s Aborting"
 	ScriptHashCheckMfaScriptDetails     = "Unknown Script %s@%s MFA Required"
+	ScriptHashCheckWarnScriptDetails    = "Unknown Script %s@%s"
 	ScriptHashCheckSuccessDetails       = "Found Script Sha"
+	ScriptHashCheckApprovalDetails      = "\n - %s (%s)"
 	ScriptHashCheckUpdatedScriptDetails = " - CI Job %s has been updated"
+	ScriptHashCheckUpdatedFileDetails   = " - Script File %s has been updated"
+	ScriptHashCheckError                = "---ERROR---"
 )
 
+func init() {
+	checks.Register("scriptHash", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewScriptHashCheck(config, job.JobName, job.ScriptLines, job.ProjectDir)
+		return &check
+	},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "referencedScripts", Type: checks.BoolOption},
+	)
+}
+
 type ScriptHashCheck struct {
-	jobName     string
-	abortOnFail bool
-	mfaOnFail   bool
-	scriptLines []string
+	jobName           string
+	abortOnFail       bool
+	mfaOnFail         bool
+	scriptLines       []string
+	projectDir        string
+	referencedScripts bool
 }
 
-func NewScriptHashCheck(config config.CheckConfig, jobName string, scriptLines []string) ScriptHashCheck {
+// scriptEntry is a script hashed and whitelisted on its own: the job script (named after
+// the job) or a script file referenced by it (named after its path in the project).
+type scriptEntry struct {
+	name   string
+	isFile bool
+	sha    string
+	lines  []string
+}
+
+// NewScriptHashCheck creates a check looking up the hash of the job script in the whitelist.
+// Unless referencedScripts is disabled, the script files referenced by the job script (i.e.
+// "./deploy.sh") which exist in projectDir are hashed and looked up as separate entries.
+func NewScriptHashCheck(config config.CheckConfig, jobName string, scriptLines []string, projectDir string) ScriptHashCheck {
 	abortOnFail, exists := config.Options["abortOnFail"].(bool)
 	if !exists {
 		abortOnFail = false
@@ -41,52 +72,128 @@ func NewScriptHashCheck(config config.CheckConfig, jobName string, scriptLines [
 	}
 
 	return ScriptHashCheck{
-		abortOnFail: abortOnFail,
-		mfaOnFail:   mfaOnFail,
-		jobName:     jobName,
-		scriptLines: scriptLines,
+		abortOnFail:       abortOnFail,
+		mfaOnFail:         mfaOnFail,
+		jobName:           jobName,
+		scriptLines:       scriptLines,
+		projectDir:        projectDir,
+		referencedScripts: checks.GetBoolOption(config.Options, "referencedScripts", true),
 	}
 }
 
-func (check *ScriptHashCheck) Check(channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+func (check *ScriptHashCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
 	defer wg.Done()
 
 	result := checks.CheckResult{Name: ScriptHashCheckName, Version: ScriptHashCheckVersion}
-	details := ""
-
-	scriptUpdate := false
 
 	scriptSha := check.hashScript()
 	if scriptSha == "" {
-		details = ScriptHashCheckEmptyScriptDetails
-		result.Details = details
+		result.Details = ScriptHashCheckEmptyScriptDetails
 		channel <- result
 		return
 	}
 
-	foundScript := w.ContainsScript(scriptSha)
+	entries, err := check.scriptEntries(scriptSha)
+	if err != nil {
+		result.Error = err
+		result.Details = ScriptHashCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
 
-	if !foundScript {
-		scriptUpdate, _ = w.ContainsJobName(check.jobName)
+	foundAll := true
+	var details, diffs, approvals []string
+	for _, entry := range entries {
+		found, entryDetails, entryDiff := check.checkEntry(w, entry)
+		if found {
+			if len(entryDetails) != 0 {
+				approvals = append(approvals, entryDetails)
+			}
+			continue
+		}
+
+		foundAll = false
+		if len(entryDetails) != 0 {
+			details = append(details, entryDetails)
+		}
+
+		if len(entryDiff) != 0 {
+			diffs = append(diffs, entryDiff)
+		}
 	}
 
-	if !foundScript && check.abortOnFail {
-		result.Abort = true
-		details = fmt.Sprintf(ScriptHashCheckAbortScriptDetails, check.jobName, scriptSha)
-	} else if !foundScript && check.mfaOnFail {
-		result.Mfa = true
-		details = fmt.Sprintf(ScriptHashCheckMfaScriptDetails, check.jobName, scriptSha)
-	} else if foundScript {
-		details = ScriptHashCheckSuccessDetails
+	if foundAll {
+		result.Details = ScriptHashCheckSuccessDetails + strings.Join(approvals, "")
+	} else {
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		result.Details = strings.Join(details, "\n")
+		result.Diff = strings.Join(diffs, "")
 	}
 
-	if scriptUpdate {
-		details += fmt.Sprintf(ScriptHashCheckUpdatedScriptDetails, check.jobName)
+	channel <- result
+}
+
+// checkEntry looks up the script entry in the whitelist and returns whether it was found,
+// the details reported (the approval of the whitelist entry if it was found) and the diff
+// against the approved version of the entry.
+func (check *ScriptHashCheck) checkEntry(w whitelist.Whitelist, entry scriptEntry) (bool, string, string) {
+	if whitelistEntry, found := w.Script(entry.sha, check.jobName); found {
+		if approval := whitelistEntry.Approval(); len(approval) != 0 {
+			return true, fmt.Sprintf(ScriptHashCheckApprovalDetails, entry.name, approval), ""
+		}
+
+		return true, "", ""
 	}
 
-	result.Details = strings.Clone(details)
+	details := fmt.Sprintf(ScriptHashCheckWarnScriptDetails, entry.name, entry.sha)
+	if check.abortOnFail {
+		details = fmt.Sprintf(ScriptHashCheckAbortScriptDetails, entry.name, entry.sha)
+	} else if check.mfaOnFail {
+		details = fmt.Sprintf(ScriptHashCheckMfaScriptDetails, entry.name, entry.sha)
+	}
 
-	channel <- result
+	scriptUpdate, approvedSha := w.ContainsJobName(entry.name)
+	if !scriptUpdate {
+		return false, details, ""
+	}
+
+	if entry.isFile {
+		details += fmt.Sprintf(ScriptHashCheckUpdatedFileDetails, entry.name)
+	} else {
+		details += fmt.Sprintf(ScriptHashCheckUpdatedScriptDetails, entry.name)
+	}
+
+	return false, details, diffScript(w, entry, approvedSha)
+}
+
+// scriptEntries returns the job script followed by the script files it references.
+func (check *ScriptHashCheck) scriptEntries(scriptSha string) ([]scriptEntry, error) {
+	var lines []string
+	for _, line := range check.scriptLines {
+		lines = append(lines, strings.Split(line, "\n")...)
+	}
+
+	entries := []scriptEntry{{name: check.jobName, sha: scriptSha, lines: lines}}
+	if !check.referencedScripts {
+		return entries, nil
+	}
+
+	files, err := findReferencedScripts(check.scriptLines, check.projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		entries = append(entries, scriptEntry{
+			name:   file.name,
+			isFile: true,
+			sha:    Hash(file.content),
+			lines:  strings.Split(strings.TrimSuffix(string(file.content), "\n"), "\n"),
+		})
+	}
+
+	return entries, nil
 }
 
 func (check *ScriptHashCheck) hashScript() string {
@@ -99,12 +206,28 @@ func (check *ScriptHashCheck) hashScript() string {
 		return ""
 	}
 
+	return Hash([]byte(script))
+}
+
+// Hash returns the hash a script is whitelisted by (i.e. "sha256:<base64 url encoded sha256>").
+func Hash(content []byte) string {
 	h := sha256.New()
-	h.Write([]byte(script))
+	h.Write(content)
 
 	return "sha256:" + base64.URLEncoding.EncodeToString(h.Sum(nil))
 }
 
+// diffScript returns a unified diff between the approved source of the script entry stored in
+// the whitelist and its current source (empty if no approved source is stored for the entry).
+func diffScript(w whitelist.Whitelist, entry scriptEntry, approvedSha string) string {
+	approved, err := w.ScriptSource(entry.name + "@" + approvedSha)
+	if err != nil {
+		return ""
+	}
+
+	return diff.Unified(entry.name+"@"+approvedSha, entry.name+"@"+entry.sha, approved, entry.lines, diff.DefaultContext)
+}
+
 func (check *ScriptHashCheck) IsValidForCheckType(checkType uint) bool {
 	switch checkType {
 	case checks.All:
@@ -117,3 +240,7 @@ func (check *ScriptHashCheck) IsValidForCheckType(checkType uint) bool {
 func (check *ScriptHashCheck) IsValidForPlatform(ciPlatform string) bool {
 	return true
 }
+
+func (check *ScriptHashCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
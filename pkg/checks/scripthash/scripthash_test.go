@@ -1,8 +1,13 @@
 package scripthash
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
@@ -15,6 +20,7 @@ func TestNewScriptHashCheck(t *testing.T) {
 	newScriptHashCheckTests := []struct {
 		name          string
 		config        config.CheckConfig
+		projectDir    string
 		expectedCheck ScriptHashCheck
 	}{
 		{
@@ -27,10 +33,11 @@ func TestNewScriptHashCheck(t *testing.T) {
 				},
 			},
 			expectedCheck: ScriptHashCheck{
-				jobName:     "testJob",
-				abortOnFail: true,
-				mfaOnFail:   true,
-				scriptLines: []string{""},
+				jobName:           "testJob",
+				abortOnFail:       true,
+				mfaOnFail:         true,
+				scriptLines:       []string{""},
+				referencedScripts: true,
 			},
 		},
 		{
@@ -43,10 +50,11 @@ func TestNewScriptHashCheck(t *testing.T) {
 				},
 			},
 			expectedCheck: ScriptHashCheck{
-				jobName:     "testJob",
-				abortOnFail: false,
-				mfaOnFail:   false,
-				scriptLines: []string{""},
+				jobName:           "testJob",
+				abortOnFail:       false,
+				mfaOnFail:         false,
+				scriptLines:       []string{""},
+				referencedScripts: true,
 			},
 		},
 		{
@@ -55,11 +63,46 @@ func TestNewScriptHashCheck(t *testing.T) {
 				Name:    "scriptHash",
 				Options: map[string]interface{}{},
 			},
+			expectedCheck: ScriptHashCheck{
+				jobName:           "testJob",
+				abortOnFail:       false,
+				mfaOnFail:         false,
+				scriptLines:       []string{""},
+				referencedScripts: true,
+			},
+		},
+		{
+			name: "test referencedScripts true",
+			config: config.CheckConfig{
+				Name: "scriptHash",
+				Options: map[string]interface{}{
+					"mfaOnFail":         true,
+					"referencedScripts": true,
+				},
+			},
+			projectDir: "/builds/group/project",
+			expectedCheck: ScriptHashCheck{
+				jobName:           "testJob",
+				abortOnFail:       false,
+				mfaOnFail:         true,
+				scriptLines:       []string{""},
+				projectDir:        "/builds/group/project",
+				referencedScripts: true,
+			},
+		},
+		{
+			name: "test referencedScripts false",
+			config: config.CheckConfig{
+				Name: "scriptHash",
+				Options: map[string]interface{}{
+					"referencedScripts": false,
+				},
+			},
+			projectDir: "/builds/group/project",
 			expectedCheck: ScriptHashCheck{
 				jobName:     "testJob",
-				abortOnFail: false,
-				mfaOnFail:   false,
 				scriptLines: []string{""},
+				projectDir:  "/builds/group/project",
 			},
 		},
 	}
@@ -67,7 +110,7 @@ func TestNewScriptHashCheck(t *testing.T) {
 	jobName := "testJob"
 	scriptLines := []string{""}
 	for testNum, test := range newScriptHashCheckTests {
-		scriptHashCheck := NewScriptHashCheck(test.config, jobName, scriptLines)
+		scriptHashCheck := NewScriptHashCheck(test.config, jobName, scriptLines, test.projectDir)
 		if !reflect.DeepEqual(scriptHashCheck, test.expectedCheck) {
 			t.Errorf("%d) Checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, scriptHashCheck)
 		}
@@ -92,19 +135,61 @@ func TestScriptHashCheck(t *testing.T) {
 				},
 			},
 			whitelist: whitelist.Whitelist{
-				AllowedScripts: []string{
-					"testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw=",
+				AllowedScripts: []whitelist.Entry{
+					{Value: "testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw="},
 				},
 			},
 			expectedResult: checks.CheckResult{
 				Name:    ScriptHashCheckName,
 				Version: ScriptHashCheckVersion,
 				Error:   nil,
-				Abort:   false,
-				Mfa:     false,
 				Details: ScriptHashCheckSuccessDetails,
 			},
 		},
+		{
+			name: "test script in whitelist with approval - success",
+			scriptHashCheck: ScriptHashCheck{
+				jobName:     "testJob",
+				abortOnFail: true,
+				scriptLines: []string{
+					`$ echo "this script is for testing"`,
+					`$ curl -X POST -H yourdomain.com"`,
+				},
+			},
+			whitelist: whitelist.Whitelist{
+				AllowedScripts: []whitelist.Entry{
+					{Value: "testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw=", ApprovedBy: "alice", Reason: "release upload", Jobs: []string{"testJob"}},
+				},
+			},
+			expectedResult: checks.CheckResult{
+				Name:    ScriptHashCheckName,
+				Version: ScriptHashCheckVersion,
+				Details: ScriptHashCheckSuccessDetails + "\n - testJob (approved by alice: release upload)",
+			},
+		},
+		{
+			name: "test script whitelisted for another job - abort",
+			scriptHashCheck: ScriptHashCheck{
+				jobName:     "testJob",
+				abortOnFail: true,
+				scriptLines: []string{
+					`$ echo "this script is for testing"`,
+					`$ curl -X POST -H yourdomain.com"`,
+				},
+			},
+			whitelist: whitelist.Whitelist{
+				AllowedScripts: []whitelist.Entry{
+					{Value: "otherJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw=", Jobs: []string{"otherJob"}},
+				},
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ScriptHashCheckName,
+				Version:  ScriptHashCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  fmt.Sprintf(ScriptHashCheckAbortScriptDetails, "testJob", "sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw="),
+			},
+		},
 		{
 			name: "test script not in (empty) whitelist - abort",
 			scriptHashCheck: ScriptHashCheck{
@@ -118,12 +203,12 @@ func TestScriptHashCheck(t *testing.T) {
 			},
 			whitelist: whitelist.Whitelist{},
 			expectedResult: checks.CheckResult{
-				Name:    ScriptHashCheckName,
-				Version: ScriptHashCheckVersion,
-				Error:   nil,
-				Abort:   true,
-				Mfa:     false,
-				Details: fmt.Sprintf(ScriptHashCheckAbortScriptDetails, "testJob", "sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw="),
+				Name:     ScriptHashCheckName,
+				Version:  ScriptHashCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  fmt.Sprintf(ScriptHashCheckAbortScriptDetails, "testJob", "sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw="),
 			},
 		},
 		{
@@ -138,16 +223,16 @@ func TestScriptHashCheck(t *testing.T) {
 				},
 			},
 			whitelist: whitelist.Whitelist{
-				AllowedScripts: []string{
-					"testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRpxyz=",
+				AllowedScripts: []whitelist.Entry{
+					{Value: "testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRpxyz="},
 				},
 			},
 			expectedResult: checks.CheckResult{
-				Name:    ScriptHashCheckName,
-				Version: ScriptHashCheckVersion,
-				Error:   nil,
-				Abort:   true,
-				Mfa:     false,
+				Name:     ScriptHashCheckName,
+				Version:  ScriptHashCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
 				Details: fmt.Sprintf(ScriptHashCheckAbortScriptDetails+ScriptHashCheckUpdatedScriptDetails,
 					"testJob", "sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw=", "testJob"),
 			},
@@ -165,12 +250,12 @@ func TestScriptHashCheck(t *testing.T) {
 			},
 			whitelist: whitelist.Whitelist{},
 			expectedResult: checks.CheckResult{
-				Name:    ScriptHashCheckName,
-				Version: ScriptHashCheckVersion,
-				Error:   nil,
-				Abort:   false,
-				Mfa:     true,
-				Details: fmt.Sprintf(ScriptHashCheckMfaScriptDetails, "testJob", "sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw="),
+				Name:     ScriptHashCheckName,
+				Version:  ScriptHashCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  fmt.Sprintf(ScriptHashCheckMfaScriptDetails, "testJob", "sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw="),
 			},
 		},
 		{
@@ -185,20 +270,54 @@ func TestScriptHashCheck(t *testing.T) {
 				},
 			},
 			whitelist: whitelist.Whitelist{
-				AllowedScripts: []string{
-					"testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRpxyz=",
+				AllowedScripts: []whitelist.Entry{
+					{Value: "testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRpxyz="},
 				},
 			},
 			expectedResult: checks.CheckResult{
-				Name:    ScriptHashCheckName,
-				Version: ScriptHashCheckVersion,
-				Error:   nil,
-				Abort:   false,
-				Mfa:     true,
+				Name:     ScriptHashCheckName,
+				Version:  ScriptHashCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
 				Details: fmt.Sprintf(ScriptHashCheckMfaScriptDetails+ScriptHashCheckUpdatedScriptDetails,
 					"testJob", "sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw=", "testJob"),
 			},
 		},
+		{
+			name: "test script not in whitelist (updated with approved source) - mfa with diff",
+			scriptHashCheck: ScriptHashCheck{
+				jobName:     "testJob",
+				abortOnFail: false,
+				mfaOnFail:   true,
+				scriptLines: []string{
+					`$ echo "this script is for testing"`,
+					`$ curl -X POST -H yourdomain.com"`,
+				},
+			},
+			whitelist: whitelist.Whitelist{
+				AllowedScripts: []whitelist.Entry{
+					{Value: "testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRpxyz="},
+				},
+				ScriptSources: map[string]string{
+					"testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRpxyz=": "H4sIAAAAAAAAA1NRSE3OyFdQKsnILFYoTi7KLChRALLS8osUSlKLSzLz0pW4ABIpfjYkAAAA",
+				},
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ScriptHashCheckName,
+				Version:  ScriptHashCheckVersion,
+				Error:    nil,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details: fmt.Sprintf(ScriptHashCheckMfaScriptDetails+ScriptHashCheckUpdatedScriptDetails,
+					"testJob", "sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw=", "testJob"),
+				Diff: "--- testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRpxyz=\n" +
+					"+++ testJob@sha256:v7gXKQ__R6c76dShfVJoe8gvPOc-TJxHhv3QDdRp5kw=\n" +
+					"@@ -1 +1,2 @@\n" +
+					" $ echo \"this script is for testing\"\n" +
+					"+$ curl -X POST -H yourdomain.com\"\n",
+			},
+		},
 	}
 
 	for testNum, test := range scriptHashCheckTests {
@@ -206,7 +325,7 @@ func TestScriptHashCheck(t *testing.T) {
 		channel := make(chan checks.CheckResult, 1)
 
 		wg.Add(1)
-		go test.scriptHashCheck.Check(channel, &wg, test.whitelist)
+		go test.scriptHashCheck.Check(context.Background(), channel, &wg, test.whitelist)
 
 		wg.Wait()
 		close(channel)
@@ -219,6 +338,200 @@ func TestScriptHashCheck(t *testing.T) {
 	}
 }
 
+// newTestProject creates a project directory with referenced scripts and a symbolic link
+// pointing to a script outside of the project.
+func newTestProject(t *testing.T) string {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "project")
+
+	files := map[string]string{
+		"project/scripts/deploy.sh": "#!/bin/sh\necho \"deploying\"\nkubectl apply -f deploy.yml\n",
+		"project/build.py":          "print('building')\n",
+		"outside.sh":                "echo \"outside of the project\"\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("unable to create test project: %s", err.Error())
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("unable to create test project: %s", err.Error())
+		}
+	}
+
+	if err := os.Symlink(filepath.Join(root, "outside.sh"), filepath.Join(projectDir, "link.sh")); err != nil {
+		t.Fatalf("unable to create test project: %s", err.Error())
+	}
+
+	return projectDir
+}
+
+func TestFindReferencedScripts(t *testing.T) {
+	projectDir := newTestProject(t)
+
+	scriptLines := []string{
+		`$ ./scripts/deploy.sh`,
+		`$ python3 build.py && ./scripts/deploy.sh`,
+		`$ ./missing.sh`,
+		`$ ./link.sh`,
+		`$ ../outside.sh`,
+		`$ curl -fsSL https://example.com/install.sh`,
+		`$ ` + filepath.Join(projectDir, "scripts", "deploy.sh"),
+		`$ cat package.json`,
+	}
+
+	files, err := findReferencedScripts(scriptLines, projectDir)
+	if err != nil {
+		t.Fatalf("unable to find referenced scripts: %s", err.Error())
+	}
+
+	var names []string
+	for _, file := range files {
+		names = append(names, file.name)
+	}
+
+	expectedNames := []string{"scripts/deploy.sh", "build.py"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("unexpected referenced scripts -\nexpected: (%+v)\ngot: (%+v)", expectedNames, names)
+	}
+
+	files, err = findReferencedScripts(scriptLines, "")
+	if !errors.Is(err, ErrNoProjectDir) || len(files) != 0 {
+		t.Errorf("expected an error without a project directory - got: (%+v, %+v)", files, err)
+	}
+
+	files, err = findReferencedScripts([]string{`$ echo "no files"`}, "")
+	if err != nil || len(files) != 0 {
+		t.Errorf("expected no error without a project directory and references - got: (%+v, %+v)", files, err)
+	}
+}
+
+func TestScriptHashCheckReferencedScripts(t *testing.T) {
+	projectDir := newTestProject(t)
+
+	scriptLines := []string{`$ python3 build.py`, `$ ./scripts/deploy.sh`}
+	scriptSha := Hash([]byte(strings.Join(scriptLines, "")))
+	buildSha := Hash([]byte("print('building')\n"))
+	deploySha := Hash([]byte("#!/bin/sh\necho \"deploying\"\nkubectl apply -f deploy.yml\n"))
+
+	approvedDeploy := []string{"#!/bin/sh", "echo \"deploying\""}
+	approvedDeploySha := Hash([]byte(strings.Join(approvedDeploy, "\n") + "\n"))
+	approvedDeploySource, err := whitelist.EncodeScriptSource(approvedDeploy)
+	if err != nil {
+		t.Fatalf("unable to encode script source: %s", err.Error())
+	}
+
+	scriptHashCheckTests := []struct {
+		name              string
+		referencedScripts bool
+		whitelist         whitelist.Whitelist
+		expectedResult    checks.CheckResult
+	}{
+		{
+			name:              "job script and referenced scripts are whitelisted",
+			referencedScripts: true,
+			whitelist: whitelist.Whitelist{
+				AllowedScripts: []whitelist.Entry{{Value: "testJob@" + scriptSha}, {Value: "build.py@" + buildSha}, {Value: "scripts/deploy.sh@" + deploySha}},
+			},
+			expectedResult: checks.CheckResult{
+				Name:    ScriptHashCheckName,
+				Version: ScriptHashCheckVersion,
+				Details: ScriptHashCheckSuccessDetails,
+			},
+		},
+		{
+			name:              "referenced script has been updated",
+			referencedScripts: true,
+			whitelist: whitelist.Whitelist{
+				AllowedScripts: []whitelist.Entry{{Value: "testJob@" + scriptSha}, {Value: "scripts/deploy.sh@" + approvedDeploySha}},
+				ScriptSources: map[string]string{
+					"scripts/deploy.sh@" + approvedDeploySha: approvedDeploySource,
+				},
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ScriptHashCheckName,
+				Version:  ScriptHashCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details: fmt.Sprintf(ScriptHashCheckMfaScriptDetails, "build.py", buildSha) + "\n" +
+					fmt.Sprintf(ScriptHashCheckMfaScriptDetails+ScriptHashCheckUpdatedFileDetails, "scripts/deploy.sh", deploySha, "scripts/deploy.sh"),
+				Diff: "--- scripts/deploy.sh@" + approvedDeploySha + "\n" +
+					"+++ scripts/deploy.sh@" + deploySha + "\n" +
+					"@@ -1,2 +1,3 @@\n" +
+					" #!/bin/sh\n" +
+					" echo \"deploying\"\n" +
+					"+kubectl apply -f deploy.yml\n",
+			},
+		},
+		{
+			name:              "referenced scripts disabled",
+			referencedScripts: false,
+			whitelist: whitelist.Whitelist{
+				AllowedScripts: []whitelist.Entry{{Value: "testJob@" + scriptSha}},
+			},
+			expectedResult: checks.CheckResult{
+				Name:    ScriptHashCheckName,
+				Version: ScriptHashCheckVersion,
+				Details: ScriptHashCheckSuccessDetails,
+			},
+		},
+	}
+
+	for testNum, test := range scriptHashCheckTests {
+		scriptHashCheck := ScriptHashCheck{
+			jobName:           "testJob",
+			mfaOnFail:         true,
+			scriptLines:       scriptLines,
+			projectDir:        projectDir,
+			referencedScripts: test.referencedScripts,
+		}
+
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go scriptHashCheck.Check(context.Background(), channel, &wg, test.whitelist)
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestScriptHashCheckNoProjectDir(t *testing.T) {
+	for _, projectDir := range []string{"", filepath.Join(t.TempDir(), "missing")} {
+		scriptHashCheck := ScriptHashCheck{
+			jobName:           "testJob",
+			abortOnFail:       true,
+			scriptLines:       []string{`$ ./scripts/deploy.sh`},
+			projectDir:        projectDir,
+			referencedScripts: true,
+		}
+
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go scriptHashCheck.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !errors.Is(result.Error, ErrNoProjectDir) || result.Outcome != checks.OutcomeAbort || result.Details != ScriptHashCheckError {
+			t.Errorf("expected the check to abort without a project directory %q, got: (%+v)", projectDir, result)
+		}
+	}
+}
+
 func TestHashScript(t *testing.T) {
 	hashScriptTests := []struct {
 		name            string
@@ -0,0 +1,156 @@
+package scriptlint
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	ScriptLintCheckName     = "Script Lint Check"
+	ScriptLintCheckVersion  = "1.0.0"
+	ScriptLintCheckSuccess  = "No Forbidden Commands Found"
+	ScriptLintCheckFound    = "Forbidden Commands Found:"
+	ScriptLintCheckFinding  = "\n - [%s] line %d: %s"
+	ScriptLintCheckError    = "---ERROR---"
+	ScriptLintCheckBadRules = "invalid script lint rules: %w"
+)
+
+// Rule is a named regular expression which must not match any line of the script.
+type Rule = checks.PatternRule
+
+// BuiltinRules are the rules used by the check unless builtinRules is set to false.
+var BuiltinRules = []Rule{
+	{
+		Name:    "curl-pipe-shell",
+		Pattern: regexp.MustCompile(`\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(ba|da|k|z)?sh\b`),
+	},
+	{
+		Name:    "set-plus-x",
+		Pattern: regexp.MustCompile(`\bset\s+\+x\b`),
+	},
+	{
+		Name:    "chmod-777",
+		Pattern: regexp.MustCompile(`\bchmod\s+(-[a-zA-Z]+\s+)*0?777\b`),
+	},
+	{
+		Name:    "docker-privileged",
+		Pattern: regexp.MustCompile(`\bdocker\s+(container\s+)?(run|create)\b.*--privileged\b`),
+	},
+}
+
+func init() {
+	checks.Register("scriptLint", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewScriptLintCheck(config, job.JobName, job.ScriptLines)
+		return &check
+	},
+		checks.Option{Name: "rules", Type: checks.ListOption},
+		checks.Option{Name: "builtinRules", Type: checks.BoolOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+type ScriptLintCheck struct {
+	jobName     string
+	scriptLines []string
+	rules       []Rule
+	rulesErr    error
+	abortOnFail bool
+	mfaOnFail   bool
+}
+
+// NewScriptLintCheck creates a script lint check. Custom rules are given in the rules option either
+// as a regular expression or as an object with a name and a pattern.
+//
+// Example:
+//
+//	"rules": ["rm -rf /", {"name": "no-sudo", "pattern": "\\bsudo\\b"}]
+func NewScriptLintCheck(config config.CheckConfig, jobName string, scriptLines []string) ScriptLintCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	var rules []Rule
+	if checks.GetBoolOption(config.Options, "builtinRules", true) {
+		rules = append(rules, BuiltinRules...)
+	}
+
+	customRules, err := checks.GetPatternRulesOption(config.Options, "rules")
+	rules = append(rules, customRules...)
+	if err != nil {
+		err = fmt.Errorf(ScriptLintCheckBadRules, err)
+	}
+
+	return ScriptLintCheck{
+		jobName:     jobName,
+		scriptLines: scriptLines,
+		rules:       rules,
+		rulesErr:    err,
+		abortOnFail: abortOnFail,
+		mfaOnFail:   mfaOnFail,
+	}
+}
+
+func (check *ScriptLintCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: ScriptLintCheckName, Version: ScriptLintCheckVersion}
+
+	if check.rulesErr != nil {
+		result.Error = check.rulesErr
+		result.Details = ScriptLintCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	findings := check.lint()
+	if len(findings) == 0 {
+		result.Details = ScriptLintCheckSuccess
+	} else {
+		result.Details = ScriptLintCheckFound + strings.Join(findings, "")
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+// lint returns a finding for every script line matching one of the rules.
+func (check *ScriptLintCheck) lint() []string {
+	var findings []string
+	for lineNum, scriptLine := range check.scriptLines {
+		// GitHub steps span over multiple lines
+		for _, line := range strings.Split(scriptLine, "\n") {
+			for _, rule := range check.rules {
+				if rule.Pattern.MatchString(line) {
+					findings = append(findings, fmt.Sprintf(ScriptLintCheckFinding, rule.Name, lineNum+1, strings.TrimSpace(line)))
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+func (check *ScriptLintCheck) IsValidForCheckType(checkType uint) bool {
+	switch checkType {
+	case checks.All:
+		return true
+	default:
+		return checkType == checks.ScriptCheck
+	}
+}
+
+func (check *ScriptLintCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *ScriptLintCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -0,0 +1,192 @@
+package scriptlint
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+func TestNewScriptLintCheck(t *testing.T) {
+	newScriptLintCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedRules []string
+		expectedError bool
+		abortOnFail   bool
+		mfaOnFail     bool
+	}{
+		{
+			name: "builtin rules by default",
+			config: config.CheckConfig{
+				Name: "scriptLint",
+			},
+			expectedRules: []string{"curl-pipe-shell", "set-plus-x", "chmod-777", "docker-privileged"},
+			abortOnFail:   true,
+		},
+		{
+			name: "custom rules only",
+			config: config.CheckConfig{
+				Name: "scriptLint",
+				Options: map[string]interface{}{
+					"builtinRules": false,
+					"rules": []interface{}{
+						`rm -rf /`,
+						map[string]interface{}{"name": "no-sudo", "pattern": `\bsudo\b`},
+					},
+					"mfaOnFail": true,
+				},
+			},
+			expectedRules: []string{"rm -rf /", "no-sudo"},
+			mfaOnFail:     true,
+		},
+		{
+			name: "invalid custom rule",
+			config: config.CheckConfig{
+				Name: "scriptLint",
+				Options: map[string]interface{}{
+					"builtinRules": false,
+					"rules":        []interface{}{`(unclosed`},
+				},
+			},
+			expectedRules: nil,
+			expectedError: true,
+			abortOnFail:   true,
+		},
+		{
+			name: "custom rule without pattern",
+			config: config.CheckConfig{
+				Name: "scriptLint",
+				Options: map[string]interface{}{
+					"builtinRules": false,
+					"rules":        []interface{}{map[string]interface{}{"name": "empty"}},
+				},
+			},
+			expectedRules: nil,
+			expectedError: true,
+			abortOnFail:   true,
+		},
+	}
+
+	for testNum, test := range newScriptLintCheckTests {
+		check := NewScriptLintCheck(test.config, "testJob", []string{})
+
+		var ruleNames []string
+		for _, rule := range check.rules {
+			ruleNames = append(ruleNames, rule.Name)
+		}
+
+		if strings.Join(ruleNames, ",") != strings.Join(test.expectedRules, ",") {
+			t.Errorf("\n%d) unexpected rules -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedRules, ruleNames)
+		}
+
+		if (check.rulesErr != nil) != test.expectedError {
+			t.Errorf("\n%d) unexpected rules error: %+v", testNum, check.rulesErr)
+		}
+
+		if check.abortOnFail != test.abortOnFail || check.mfaOnFail != test.mfaOnFail {
+			t.Errorf("\n%d) unexpected abortOnFail/mfaOnFail - got: (%t/%t)", testNum, check.abortOnFail, check.mfaOnFail)
+		}
+	}
+}
+
+func TestScriptLintCheck(t *testing.T) {
+	scriptLintCheckTests := []struct {
+		name           string
+		config         config.CheckConfig
+		scriptLines    []string
+		expectedResult checks.CheckResult
+	}{
+		{
+			name:        "harmless script",
+			config:      config.CheckConfig{Name: "scriptLint"},
+			scriptLines: []string{`echo "hello"`, `curl -o out.txt https://example.com`},
+			expectedResult: checks.CheckResult{
+				Name:    ScriptLintCheckName,
+				Version: ScriptLintCheckVersion,
+				Details: ScriptLintCheckSuccess,
+			},
+		},
+		{
+			name:   "builtin rules - abort",
+			config: config.CheckConfig{Name: "scriptLint"},
+			scriptLines: []string{
+				`curl -sSL https://example.com/install.sh | sudo bash`,
+				`echo "ok"`,
+				`set +x`,
+				`chmod -R 777 /srv`,
+				`docker run --rm --privileged alpine`,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     ScriptLintCheckName,
+				Version:  ScriptLintCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details: ScriptLintCheckFound +
+					"\n - [curl-pipe-shell] line 1: curl -sSL https://example.com/install.sh | sudo bash" +
+					"\n - [set-plus-x] line 3: set +x" +
+					"\n - [chmod-777] line 4: chmod -R 777 /srv" +
+					"\n - [docker-privileged] line 5: docker run --rm --privileged alpine",
+			},
+		},
+		{
+			name: "custom rule on a multi line github step - mfa",
+			config: config.CheckConfig{
+				Name: "scriptLint",
+				Options: map[string]interface{}{
+					"mfaOnFail": true,
+					"rules": []interface{}{
+						map[string]interface{}{"name": "no-sudo", "pattern": `\bsudo\b`},
+					},
+				},
+			},
+			scriptLines: []string{"name: install\n  run: sudo apt-get install -y jq"},
+			expectedResult: checks.CheckResult{
+				Name:     ScriptLintCheckName,
+				Version:  ScriptLintCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  ScriptLintCheckFound + "\n - [no-sudo] line 1: run: sudo apt-get install -y jq",
+			},
+		},
+	}
+
+	for testNum, test := range scriptLintCheckTests {
+		check := NewScriptLintCheck(test.config, "testJob", test.scriptLines)
+		result := runCheck(&check)
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestScriptLintCheckInvalidRule(t *testing.T) {
+	check := NewScriptLintCheck(config.CheckConfig{
+		Name: "scriptLint",
+		Options: map[string]interface{}{
+			"rules": []interface{}{`(unclosed`},
+		},
+	}, "testJob", []string{`echo "hello"`})
+
+	result := runCheck(&check)
+	if result.Error == nil || result.Outcome != checks.OutcomeAbort || result.Details != ScriptLintCheckError {
+		t.Errorf("expected an aborting error result - got: (%+v)", result)
+	}
+}
+
+func runCheck(check *ScriptLintCheck) checks.CheckResult {
+	var wg sync.WaitGroup
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+	wg.Wait()
+	close(channel)
+
+	return <-channel
+}
@@ -0,0 +1,265 @@
+package secretscan
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	SecretScanCheckName         = "Secret Scan Check"
+	SecretScanCheckVersion      = "1.0.0"
+	SecretScanCheckSuccess      = "No Secrets Found"
+	SecretScanCheckFound        = "Secrets Found:"
+	SecretScanCheckFinding      = "\n - [%s] line %d: %s"
+	SecretScanCheckError        = "---ERROR---"
+	SecretScanCheckBadRules     = "invalid secret scan rules: %w"
+	HighEntropyDetectorName     = "high-entropy"
+	defaultMinEntropy           = 4.5
+	minEntropyLength            = 20
+	entropyCandidatePattern     = regexp.MustCompile(`[A-Za-z0-9+/_\-]{20,}={0,2}`)
+	redactedSecret              = "****"
+	redactedSecretVisiblePrefix = 4
+)
+
+// Detector is a named regular expression matching hard-coded credentials.
+type Detector = checks.PatternRule
+
+// BuiltinDetectors are the detectors used by the check unless builtinDetectors is set to false.
+// The high-entropy detector is not a regular expression and is configured by its own options.
+var BuiltinDetectors = []Detector{
+	{
+		Name:    "aws-access-key",
+		Pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		Name:    "private-key",
+		Pattern: regexp.MustCompile(`-----BEGIN ((RSA|EC|DSA|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`),
+	},
+	{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+	},
+}
+
+func init() {
+	checks.Register("secretScan", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewSecretScanCheck(config, job.JobName, job.ScriptLines)
+		return &check
+	},
+		checks.Option{Name: "rules", Type: checks.ListOption},
+		checks.Option{Name: "builtinDetectors", Type: checks.BoolOption},
+		checks.Option{Name: "entropy", Type: checks.BoolOption},
+		checks.Option{Name: "minEntropy", Type: checks.NumberOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+type SecretScanCheck struct {
+	jobName     string
+	scriptLines []string
+	detectors   []Detector
+	detectorErr error
+	entropy     bool
+	minEntropy  float64
+	abortOnFail bool
+	mfaOnFail   bool
+}
+
+// NewSecretScanCheck creates a secret scan check. Custom rules are given in the rules option
+// either as a regular expression or as an object with a name and a pattern.
+//
+// Example:
+//
+//	"rules": ["glpat-[0-9A-Za-z_-]{20}", {"name": "slack-token", "pattern": "xox[baprs]-[0-9A-Za-z-]+"}]
+func NewSecretScanCheck(config config.CheckConfig, jobName string, scriptLines []string) SecretScanCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+	builtinDetectors := checks.GetBoolOption(config.Options, "builtinDetectors", true)
+
+	var detectors []Detector
+	if builtinDetectors {
+		detectors = append(detectors, BuiltinDetectors...)
+	}
+
+	customDetectors, err := checks.GetPatternRulesOption(config.Options, "rules")
+	detectors = append(detectors, customDetectors...)
+	if err != nil {
+		err = fmt.Errorf(SecretScanCheckBadRules, err)
+	}
+
+	return SecretScanCheck{
+		jobName:     jobName,
+		scriptLines: scriptLines,
+		detectors:   detectors,
+		detectorErr: err,
+		entropy:     checks.GetBoolOption(config.Options, "entropy", builtinDetectors),
+		minEntropy:  checks.GetFloatOption(config.Options, "minEntropy", defaultMinEntropy),
+		abortOnFail: abortOnFail,
+		mfaOnFail:   mfaOnFail,
+	}
+}
+
+func (check *SecretScanCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: SecretScanCheckName, Version: SecretScanCheckVersion}
+
+	if check.detectorErr != nil {
+		result.Error = check.detectorErr
+		result.Details = SecretScanCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	findings := check.scan()
+	if len(findings) == 0 {
+		result.Details = SecretScanCheckSuccess
+	} else {
+		result.Details = SecretScanCheckFound + strings.Join(findings, "")
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+// scan returns a finding for every detector matching a script line. The secrets are redacted
+// from the line reported in the finding.
+func (check *SecretScanCheck) scan() []string {
+	var findings []string
+	for lineNum, scriptLine := range check.scriptLines {
+		// GitHub steps span over multiple lines
+		for _, line := range strings.Split(scriptLine, "\n") {
+			var names []string
+			var secrets [][]int
+
+			for _, detector := range check.detectors {
+				matches := detector.Pattern.FindAllStringIndex(line, -1)
+				if len(matches) != 0 {
+					names = append(names, detector.Name)
+					secrets = append(secrets, matches...)
+				}
+			}
+
+			if check.entropy {
+				matches := check.highEntropyStrings(line)
+				if len(matches) != 0 {
+					names = append(names, HighEntropyDetectorName)
+					secrets = append(secrets, matches...)
+				}
+			}
+
+			if len(names) == 0 {
+				continue
+			}
+
+			redactedLine := strings.TrimSpace(redact(line, secrets))
+			for _, name := range names {
+				findings = append(findings, fmt.Sprintf(SecretScanCheckFinding, name, lineNum+1, redactedLine))
+			}
+		}
+	}
+
+	return findings
+}
+
+// highEntropyStrings returns the positions of the strings of the line which look like random
+// tokens: long enough, mixing upper case letters, lower case letters and digits and with a
+// Shannon entropy of at least minEntropy bits per character.
+func (check *SecretScanCheck) highEntropyStrings(line string) [][]int {
+	var matches [][]int
+	for _, match := range entropyCandidatePattern.FindAllStringIndex(line, -1) {
+		candidate := line[match[0]:match[1]]
+		if len(candidate) >= minEntropyLength && isMixedCase(candidate) && shannonEntropy(candidate) >= check.minEntropy {
+			matches = append(matches, match)
+		}
+	}
+
+	return matches
+}
+
+func isMixedCase(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+
+	return upper && lower && digit
+}
+
+// shannonEntropy returns the Shannon entropy of the string in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	entropy := 0.0
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// redact replaces the secrets of the line (given as start and end positions which may overlap)
+// by their first characters followed by a mask.
+func redact(line string, secrets [][]int) string {
+	masked := make([]bool, len(line))
+	for _, secret := range secrets {
+		start := secret[0]
+		if secret[1]-secret[0] > 2*redactedSecretVisiblePrefix {
+			start += redactedSecretVisiblePrefix
+		}
+
+		for i := start; i < secret[1]; i++ {
+			masked[i] = true
+		}
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(line); i++ {
+		if !masked[i] {
+			builder.WriteByte(line[i])
+			continue
+		}
+
+		builder.WriteString(redactedSecret)
+		for i+1 < len(line) && masked[i+1] {
+			i++
+		}
+	}
+
+	return builder.String()
+}
+
+func (check *SecretScanCheck) IsValidForCheckType(checkType uint) bool {
+	switch checkType {
+	case checks.All:
+		return true
+	default:
+		return checkType == checks.ScriptCheck
+	}
+}
+
+func (check *SecretScanCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *SecretScanCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
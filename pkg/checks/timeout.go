@@ -0,0 +1,30 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrCheckTimeout = errors.New("check timed out")
+
+// WithTimeout returns a context which is done after the timeout of a check (or with its
+// parent if the timeout is zero).
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// ContextError returns err wrapped in ErrCheckTimeout if it happened because the context of
+// the check is done (i.e. a killed git process or an aborted HTTP request) and err otherwise.
+func ContextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	return fmt.Errorf("%w (%w): %w", ErrCheckTimeout, ctx.Err(), err)
+}
@@ -0,0 +1,46 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		t.Errorf("unexpected deadline for a check without timeout")
+	}
+
+	ctx, cancel = WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("unexpected context error -\nexpected: (%+v)\ngot: (%+v)", context.DeadlineExceeded, ctx.Err())
+	}
+}
+
+func TestContextError(t *testing.T) {
+	errRead := errors.New("read error")
+
+	err := ContextError(context.Background(), errRead)
+	if err != errRead {
+		t.Errorf("unexpected error while the context is running -\nexpected: (%+v)\ngot: (%+v)", errRead, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = ContextError(ctx, errRead)
+	if !errors.Is(err, ErrCheckTimeout) || !errors.Is(err, context.Canceled) || !errors.Is(err, errRead) {
+		t.Errorf("unexpected error once the context is done -\nexpected: (%+v)\ngot: (%+v)", ErrCheckTimeout, err)
+	}
+
+	if ContextError(ctx, nil) != nil {
+		t.Errorf("unexpected error without error")
+	}
+}
@@ -0,0 +1,122 @@
+package triggersource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	TriggerSourceCheckName        = "Trigger Source Check"
+	TriggerSourceCheckVersion     = "1.0.0"
+	TriggerSourceCheckNoSource    = "No Pipeline Source"
+	TriggerSourceCheckAllowed     = "Pipeline source %s (%s) is allowed"
+	TriggerSourceCheckMfaRequired = "Pipeline source %s (%s) requires MFA"
+	TriggerSourceCheckDenied      = "Pipeline source %s (%s) is denied"
+	TriggerSourceCheckNotAllowed  = "Pipeline source %s (%s) is not allowed"
+	ErrNoPipelineSource           = errors.New("no pipeline source provided")
+)
+
+func init() {
+	checks.Register("triggerSource", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewTriggerSourceCheck(config, job.JobName, job.PipelineSource, job.PlatformPipelineSource)
+		return &check
+	},
+		checks.Option{Name: "allowedSources", Type: checks.ListOption},
+		checks.Option{Name: "mfaSources", Type: checks.ListOption},
+		checks.Option{Name: "deniedSources", Type: checks.ListOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+type TriggerSourceCheck struct {
+	jobName        string
+	source         string
+	platformSource string
+	allowedSources []string
+	mfaSources     []string
+	deniedSources  []string
+	abortOnFail    bool
+	mfaOnFail      bool
+}
+
+// NewTriggerSourceCheck creates a check applying a policy to the source which triggered the
+// pipeline. The source lists may contain sources from the common vocabulary (i.e. merge_request)
+// or platform specific sources (i.e. merge_request_event, pull_request_target).
+func NewTriggerSourceCheck(config config.CheckConfig, jobName string, source string, platformSource string) TriggerSourceCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return TriggerSourceCheck{
+		jobName:        jobName,
+		source:         source,
+		platformSource: platformSource,
+		allowedSources: checks.GetStringListOption(config.Options, "allowedSources"),
+		mfaSources:     checks.GetStringListOption(config.Options, "mfaSources"),
+		deniedSources:  checks.GetStringListOption(config.Options, "deniedSources"),
+		abortOnFail:    abortOnFail,
+		mfaOnFail:      mfaOnFail,
+	}
+}
+
+// Check applies the first matching policy: denied sources fail, MFA sources require MFA and
+// allowed sources pass. Sources matching no list pass unless allowedSources is set.
+func (check *TriggerSourceCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: TriggerSourceCheckName, Version: TriggerSourceCheckVersion}
+
+	if len(check.platformSource) == 0 {
+		result.Error = ErrNoPipelineSource
+		result.Details = TriggerSourceCheckNoSource
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	if check.matches(check.deniedSources) {
+		result.Details = fmt.Sprintf(TriggerSourceCheckDenied, check.source, check.platformSource)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else if check.matches(check.mfaSources) {
+		result.SetOutcome(checks.OutcomeMfa)
+		result.Details = fmt.Sprintf(TriggerSourceCheckMfaRequired, check.source, check.platformSource)
+	} else if len(check.allowedSources) == 0 || check.matches(check.allowedSources) {
+		result.Details = fmt.Sprintf(TriggerSourceCheckAllowed, check.source, check.platformSource)
+	} else {
+		result.Details = fmt.Sprintf(TriggerSourceCheckNotAllowed, check.source, check.platformSource)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	}
+
+	channel <- result
+}
+
+// matches returns whether the common or the platform specific source is in the sources.
+func (check *TriggerSourceCheck) matches(sources []string) bool {
+	for _, source := range sources {
+		source = strings.TrimSpace(source)
+		if strings.EqualFold(source, check.source) || strings.EqualFold(source, check.platformSource) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (check *TriggerSourceCheck) IsValidForCheckType(checkType uint) bool {
+	return true
+}
+
+func (check *TriggerSourceCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *TriggerSourceCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
@@ -0,0 +1,217 @@
+package triggersource
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+func TestNewTriggerSourceCheck(t *testing.T) {
+	newTriggerSourceCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck TriggerSourceCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "triggerSource",
+			},
+			expectedCheck: TriggerSourceCheck{
+				jobName:        "testJob",
+				source:         checks.SourceManual,
+				platformSource: "web",
+				abortOnFail:    true,
+			},
+		},
+		{
+			name: "source policy with mfaOnFail",
+			config: config.CheckConfig{
+				Name: "triggerSource",
+				Options: map[string]interface{}{
+					"allowedSources": []interface{}{"schedule"},
+					"mfaSources":     []interface{}{"web"},
+					"deniedSources":  []interface{}{"merge_request_event", "pull_request_target"},
+					"mfaOnFail":      true,
+				},
+			},
+			expectedCheck: TriggerSourceCheck{
+				jobName:        "testJob",
+				source:         checks.SourceManual,
+				platformSource: "web",
+				allowedSources: []string{"schedule"},
+				mfaSources:     []string{"web"},
+				deniedSources:  []string{"merge_request_event", "pull_request_target"},
+				mfaOnFail:      true,
+			},
+		},
+	}
+
+	for testNum, test := range newTriggerSourceCheckTests {
+		check := NewTriggerSourceCheck(test.config, "testJob", checks.SourceManual, "web")
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestTriggerSourceCheck(t *testing.T) {
+	policy := TriggerSourceCheck{
+		allowedSources: []string{checks.SourceSchedule, checks.SourcePush},
+		mfaSources:     []string{"web", "workflow_dispatch"},
+		deniedSources:  []string{"merge_request_event", "pull_request_target"},
+		abortOnFail:    true,
+	}
+
+	triggerSourceCheckTests := []struct {
+		name           string
+		source         string
+		platformSource string
+		check          TriggerSourceCheck
+		expectedResult checks.CheckResult
+	}{
+		{
+			name:           "scheduled pipeline is allowed",
+			source:         checks.SourceSchedule,
+			platformSource: "schedule",
+			check:          policy,
+			expectedResult: checks.CheckResult{
+				Name:    TriggerSourceCheckName,
+				Version: TriggerSourceCheckVersion,
+				Details: "Pipeline source schedule (schedule) is allowed",
+			},
+		},
+		{
+			name:           "gitlab web pipeline requires mfa",
+			source:         checks.SourceManual,
+			platformSource: "web",
+			check:          policy,
+			expectedResult: checks.CheckResult{
+				Name:     TriggerSourceCheckName,
+				Version:  TriggerSourceCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Pipeline source manual (web) requires MFA",
+			},
+		},
+		{
+			name:           "gitlab merge request pipeline is denied",
+			source:         checks.SourceMergeRequest,
+			platformSource: "merge_request_event",
+			check:          policy,
+			expectedResult: checks.CheckResult{
+				Name:     TriggerSourceCheckName,
+				Version:  TriggerSourceCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Pipeline source merge_request (merge_request_event) is denied",
+			},
+		},
+		{
+			name:           "github pull request target is denied",
+			source:         checks.SourceMergeRequestTarget,
+			platformSource: "pull_request_target",
+			check:          policy,
+			expectedResult: checks.CheckResult{
+				Name:     TriggerSourceCheckName,
+				Version:  TriggerSourceCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Pipeline source merge_request_target (pull_request_target) is denied",
+			},
+		},
+		{
+			name:           "github push is allowed through the common vocabulary",
+			source:         checks.SourcePush,
+			platformSource: "push",
+			check:          policy,
+			expectedResult: checks.CheckResult{
+				Name:    TriggerSourceCheckName,
+				Version: TriggerSourceCheckVersion,
+				Details: "Pipeline source push (push) is allowed",
+			},
+		},
+		{
+			name:           "source not in the allowed sources",
+			source:         checks.SourceApi,
+			platformSource: "trigger",
+			check:          policy,
+			expectedResult: checks.CheckResult{
+				Name:     TriggerSourceCheckName,
+				Version:  TriggerSourceCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "Pipeline source api (trigger) is not allowed",
+			},
+		},
+		{
+			name:           "denied source with mfaOnFail",
+			source:         checks.SourceMergeRequest,
+			platformSource: "pull_request",
+			check: TriggerSourceCheck{
+				deniedSources: []string{checks.SourceMergeRequest},
+				mfaOnFail:     true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     TriggerSourceCheckName,
+				Version:  TriggerSourceCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "Pipeline source merge_request (pull_request) is denied",
+			},
+		},
+		{
+			name:           "no allowed sources configured",
+			source:         checks.SourceOther,
+			platformSource: "chat",
+			check: TriggerSourceCheck{
+				deniedSources: []string{checks.SourceMergeRequest},
+				abortOnFail:   true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    TriggerSourceCheckName,
+				Version: TriggerSourceCheckVersion,
+				Details: "Pipeline source other (chat) is allowed",
+			},
+		},
+		{
+			name:   "no pipeline source",
+			check:  policy,
+			source: checks.SourceOther,
+			expectedResult: checks.CheckResult{
+				Name:     TriggerSourceCheckName,
+				Version:  TriggerSourceCheckVersion,
+				Error:    ErrNoPipelineSource,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  TriggerSourceCheckNoSource,
+			},
+		},
+	}
+
+	for testNum, test := range triggerSourceCheckTests {
+		check := test.check
+		check.source = test.source
+		check.platformSource = test.platformSource
+
+		var wg sync.WaitGroup
+		channel := make(chan checks.CheckResult, 1)
+
+		wg.Add(1)
+		go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+		wg.Wait()
+		close(channel)
+
+		result := <-channel
+
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package userallowlist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var (
+	UserAllowlistCheckName          = "User Allowlist Check"
+	UserAllowlistCheckVersion       = "1.0.0"
+	UserAllowlistCheckAllowed       = "User %s is allowed"
+	UserAllowlistCheckAllowedGroups = "User %s is allowed (groups: %s)"
+	UserAllowlistCheckNotAllowed    = "User %s is not an allowed operator"
+	UserAllowlistCheckError         = "---ERROR---"
+	ErrNoUserVault                  = errors.New("no vault available to read the users")
+	userAllowlistMount              = "users"
+)
+
+func init() {
+	checks.Register("userAllowlist", func(config config.CheckConfig, job checks.JobInfo) checks.Check {
+		check := NewUserAllowlistCheck(config, job.JobName, job.UserEmail, job.Vault, job.NamespaceMount, job.ProjectMount)
+		return &check
+	},
+		checks.Option{Name: "users", Type: checks.ListOption},
+		checks.Option{Name: "groups", Type: checks.ListOption},
+		checks.Option{Name: "abortOnFail", Type: checks.BoolOption},
+		checks.Option{Name: "mfaOnFail", Type: checks.BoolOption},
+	)
+}
+
+// Users is the document stored in the vault at {root}/{namespace}/users and
+// {root}/{namespace}/{project}/users.
+//
+// Example:
+//
+//	{
+//		"users": ["alice@example.com"],
+//		"groups": {
+//			"release-managers": ["bob@example.com", "carol@example.com"]
+//		}
+//	}
+type Users struct {
+	Users  []string            `json:"users"`
+	Groups map[string][]string `json:"groups"`
+}
+
+// AddUsers merges the users and groups of another document into this one.
+func (u *Users) AddUsers(other Users) {
+	u.Users = append(u.Users, other.Users...)
+
+	if len(other.Groups) != 0 && u.Groups == nil {
+		u.Groups = make(map[string][]string)
+	}
+
+	for group, members := range other.Groups {
+		u.Groups[group] = append(u.Groups[group], members...)
+	}
+}
+
+type UserAllowlistCheck struct {
+	jobName        string
+	userEmail      string
+	users          []string
+	groups         []string
+	abortOnFail    bool
+	mfaOnFail      bool
+	vault          checks.SecretReader
+	namespaceMount string
+	projectMount   string
+}
+
+func NewUserAllowlistCheck(config config.CheckConfig, jobName string, userEmail string, vault checks.SecretReader, namespaceMount string, projectMount string) UserAllowlistCheck {
+	mfaOnFail := checks.GetBoolOption(config.Options, "mfaOnFail", false)
+	abortOnFail := checks.GetBoolOption(config.Options, "abortOnFail", !mfaOnFail)
+
+	return UserAllowlistCheck{
+		jobName:        jobName,
+		userEmail:      userEmail,
+		users:          checks.GetStringListOption(config.Options, "users"),
+		groups:         checks.GetStringListOption(config.Options, "groups"),
+		abortOnFail:    abortOnFail,
+		mfaOnFail:      mfaOnFail,
+		vault:          vault,
+		namespaceMount: namespaceMount,
+		projectMount:   projectMount,
+	}
+}
+
+func (check *UserAllowlistCheck) Check(ctx context.Context, channel chan<- checks.CheckResult, wg *sync.WaitGroup, w whitelist.Whitelist) {
+	defer wg.Done()
+
+	result := checks.CheckResult{Name: UserAllowlistCheckName, Version: UserAllowlistCheckVersion}
+
+	users, err := check.readUsers(ctx)
+	if err != nil {
+		result.Error = checks.ContextError(ctx, err)
+		result.Details = UserAllowlistCheckError
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+		channel <- result
+		return
+	}
+
+	allowed, groups := check.isAllowed(users)
+	if !allowed {
+		result.Details = fmt.Sprintf(UserAllowlistCheckNotAllowed, check.userEmail)
+		result.Fail(check.abortOnFail, check.mfaOnFail)
+	} else if len(groups) != 0 {
+		result.Details = fmt.Sprintf(UserAllowlistCheckAllowedGroups, check.userEmail, strings.Join(groups, ", "))
+	} else {
+		result.Details = fmt.Sprintf(UserAllowlistCheckAllowed, check.userEmail)
+	}
+
+	channel <- result
+}
+
+// isAllowed returns whether the user is allowed to run the job and the allowed groups
+// the user is a member of. If groups are configured only the members of those groups
+// (and the configured users) are allowed, otherwise the users listed in the vault are.
+func (check *UserAllowlistCheck) isAllowed(users Users) (bool, []string) {
+	if containsUser(check.users, check.userEmail) {
+		return true, nil
+	}
+
+	if len(check.groups) == 0 {
+		return containsUser(users.Users, check.userEmail), nil
+	}
+
+	var memberOf []string
+	for _, group := range check.groups {
+		if containsUser(users.Groups[group], check.userEmail) {
+			memberOf = append(memberOf, group)
+		}
+	}
+	sort.Strings(memberOf)
+
+	return len(memberOf) != 0, memberOf
+}
+
+func (check *UserAllowlistCheck) readUsers(ctx context.Context) (Users, error) {
+	users := Users{}
+
+	if check.vault == nil {
+		return users, ErrNoUserVault
+	}
+
+	for _, mount := range []string{check.namespaceMount, check.projectMount} {
+		vaultRes, err := check.vault.ReadSecret(ctx, mount+"/"+userAllowlistMount)
+		if err != nil {
+			return users, err
+		}
+
+		if len(vaultRes) == 0 {
+			continue
+		}
+
+		var mountUsers Users
+		err = json.Unmarshal(vaultRes, &mountUsers)
+		if err != nil {
+			return users, fmt.Errorf("unable to parse users at %s: %s", mount+"/"+userAllowlistMount, err.Error())
+		}
+
+		users.AddUsers(mountUsers)
+	}
+
+	return users, nil
+}
+
+func containsUser(users []string, user string) bool {
+	user = strings.TrimSpace(user)
+	if len(user) == 0 {
+		return false
+	}
+
+	for _, allowedUser := range users {
+		if strings.EqualFold(strings.TrimSpace(allowedUser), user) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (check *UserAllowlistCheck) IsValidForCheckType(checkType uint) bool {
+	return true
+}
+
+func (check *UserAllowlistCheck) IsValidForPlatform(ciPlatform string) bool {
+	return true
+}
+
+func (check *UserAllowlistCheck) FailOptions() (bool, bool) {
+	return check.abortOnFail, check.mfaOnFail
+}
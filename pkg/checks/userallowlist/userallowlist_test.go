@@ -0,0 +1,251 @@
+package userallowlist
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+)
+
+var errTestVault = errors.New("vault unavailable")
+
+type mockVault struct {
+	secrets map[string]string
+	err     error
+}
+
+func (v *mockVault) ReadSecret(ctx context.Context, mount string) ([]byte, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	secret, exists := v.secrets[mount]
+	if !exists {
+		return nil, nil
+	}
+
+	return []byte(secret), nil
+}
+
+func TestNewUserAllowlistCheck(t *testing.T) {
+	vault := &mockVault{}
+	newUserAllowlistCheckTests := []struct {
+		name          string
+		config        config.CheckConfig
+		expectedCheck UserAllowlistCheck
+	}{
+		{
+			name: "defaults",
+			config: config.CheckConfig{
+				Name: "userAllowlist",
+			},
+			expectedCheck: UserAllowlistCheck{
+				jobName:        "testJob",
+				userEmail:      "test.user@example.com",
+				abortOnFail:    true,
+				mfaOnFail:      false,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+				projectMount:   "cicd/namespace/project",
+			},
+		},
+		{
+			name: "users, groups and mfaOnFail",
+			config: config.CheckConfig{
+				Name: "userAllowlist",
+				Options: map[string]interface{}{
+					"users":     []interface{}{"alice@example.com"},
+					"groups":    []interface{}{"release-managers"},
+					"mfaOnFail": true,
+				},
+			},
+			expectedCheck: UserAllowlistCheck{
+				jobName:        "testJob",
+				userEmail:      "test.user@example.com",
+				users:          []string{"alice@example.com"},
+				groups:         []string{"release-managers"},
+				abortOnFail:    false,
+				mfaOnFail:      true,
+				vault:          vault,
+				namespaceMount: "cicd/namespace",
+				projectMount:   "cicd/namespace/project",
+			},
+		},
+	}
+
+	for testNum, test := range newUserAllowlistCheckTests {
+		check := NewUserAllowlistCheck(test.config, "testJob", "test.user@example.com", vault, "cicd/namespace", "cicd/namespace/project")
+		if !reflect.DeepEqual(check, test.expectedCheck) {
+			t.Errorf("\n%d) checks not equal -\nexpected: (%+v)\ngot: (%+v)", testNum, test.expectedCheck, check)
+		}
+	}
+}
+
+func TestUserAllowlistCheck(t *testing.T) {
+	vault := &mockVault{
+		secrets: map[string]string{
+			"cicd/namespace/users":         `{"users": ["Alice@example.com"], "groups": {"release-managers": ["bob@example.com"]}}`,
+			"cicd/namespace/project/users": `{"groups": {"release-managers": ["carol@example.com"], "operators": ["dave@example.com"]}}`,
+		},
+	}
+
+	userAllowlistCheckTests := []struct {
+		name           string
+		check          UserAllowlistCheck
+		expectedResult checks.CheckResult
+	}{
+		{
+			name: "user listed in the vault users",
+			check: UserAllowlistCheck{
+				userEmail:   "alice@example.com",
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    UserAllowlistCheckName,
+				Version: UserAllowlistCheckVersion,
+				Details: "User alice@example.com is allowed",
+			},
+		},
+		{
+			name: "user not listed in the vault users - abort",
+			check: UserAllowlistCheck{
+				userEmail:   "mallory@example.com",
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     UserAllowlistCheckName,
+				Version:  UserAllowlistCheckVersion,
+				Outcome:  checks.OutcomeAbort,
+				Severity: checks.SeverityHigh,
+				Details:  "User mallory@example.com is not an allowed operator",
+			},
+		},
+		{
+			name: "user in a namespace group",
+			check: UserAllowlistCheck{
+				userEmail:   "bob@example.com",
+				groups:      []string{"release-managers"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    UserAllowlistCheckName,
+				Version: UserAllowlistCheckVersion,
+				Details: "User bob@example.com is allowed (groups: release-managers)",
+			},
+		},
+		{
+			name: "user in a project group merged with the namespace group",
+			check: UserAllowlistCheck{
+				userEmail:   "carol@example.com",
+				groups:      []string{"release-managers"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    UserAllowlistCheckName,
+				Version: UserAllowlistCheckVersion,
+				Details: "User carol@example.com is allowed (groups: release-managers)",
+			},
+		},
+		{
+			name: "vault user not in the configured groups - mfa",
+			check: UserAllowlistCheck{
+				userEmail: "alice@example.com",
+				groups:    []string{"release-managers"},
+				mfaOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:     UserAllowlistCheckName,
+				Version:  UserAllowlistCheckVersion,
+				Outcome:  checks.OutcomeMfa,
+				Severity: checks.SeverityMedium,
+				Details:  "User alice@example.com is not an allowed operator",
+			},
+		},
+		{
+			name: "user configured in the check options",
+			check: UserAllowlistCheck{
+				userEmail:   "erin@example.com",
+				users:       []string{"erin@example.com"},
+				groups:      []string{"release-managers"},
+				abortOnFail: true,
+			},
+			expectedResult: checks.CheckResult{
+				Name:    UserAllowlistCheckName,
+				Version: UserAllowlistCheckVersion,
+				Details: "User erin@example.com is allowed",
+			},
+		},
+	}
+
+	for testNum, test := range userAllowlistCheckTests {
+		test.check.vault = vault
+		test.check.namespaceMount = "cicd/namespace"
+		test.check.projectMount = "cicd/namespace/project"
+
+		result := runCheck(&test.check)
+		if !result.CompareCheckResult(test.expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedResult, result)
+		}
+	}
+}
+
+func TestUserAllowlistCheckVaultErrors(t *testing.T) {
+	userAllowlistCheckVaultErrorTests := []struct {
+		name        string
+		vault       checks.SecretReader
+		expectedErr error
+	}{
+		{
+			name:        "vault read error",
+			vault:       &mockVault{err: errTestVault},
+			expectedErr: errTestVault,
+		},
+		{
+			name:        "no vault",
+			vault:       nil,
+			expectedErr: ErrNoUserVault,
+		},
+	}
+
+	for testNum, test := range userAllowlistCheckVaultErrorTests {
+		check := UserAllowlistCheck{
+			userEmail:      "alice@example.com",
+			abortOnFail:    true,
+			vault:          test.vault,
+			namespaceMount: "cicd/namespace",
+			projectMount:   "cicd/namespace/project",
+		}
+
+		result := runCheck(&check)
+		expectedResult := checks.CheckResult{
+			Name:     UserAllowlistCheckName,
+			Version:  UserAllowlistCheckVersion,
+			Error:    test.expectedErr,
+			Outcome:  checks.OutcomeAbort,
+			Severity: checks.SeverityHigh,
+			Details:  UserAllowlistCheckError,
+		}
+
+		if !result.CompareCheckResult(expectedResult) {
+			t.Errorf("\n%d) Results not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, expectedResult, result)
+		}
+	}
+}
+
+func runCheck(check *UserAllowlistCheck) checks.CheckResult {
+	var wg sync.WaitGroup
+	channel := make(chan checks.CheckResult, 1)
+
+	wg.Add(1)
+	go check.Check(context.Background(), channel, &wg, whitelist.Whitelist{})
+
+	wg.Wait()
+	close(channel)
+
+	return <-channel
+}
@@ -0,0 +1,96 @@
+package scriptsourcecmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks/scripthash"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
+	"github.com/urfave/cli/v2"
+)
+
+var ErrNoScriptName = errors.New("a script name is required to read the script from stdin")
+
+// scriptSource is the part of the whitelist allowing a script along with its approved source.
+type scriptSource struct {
+	AllowedScripts []string          `json:"allowed_scripts"`
+	ScriptSources  map[string]string `json:"script_sources"`
+}
+
+func Commands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:        "script-source",
+			Usage:       "script-source <flags> [file]",
+			Description: `Print the Whitelist Entry and the script_sources Entry of an Approved Script (a Referenced Script File or the Job Script Read from Stdin)`,
+			Action:      scriptsourcecommand,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:        "name",
+					Value:       "",
+					DefaultText: "Name of the Script in the Whitelist (the Job Name or the Path of the File in the Project, Default: the File Path)",
+				},
+				&cli.BoolFlag{
+					Name:        "job",
+					Value:       false,
+					DefaultText: "Whether the Script is a Job Script (its Commands as Printed in the Job Log, One per Line)",
+				},
+			},
+		},
+	}
+}
+
+func scriptsourcecommand(c *cli.Context) error {
+	name := c.String("name")
+	isJob := c.Bool("job")
+	file := c.Args().First()
+
+	var content []byte
+	var err error
+	if len(file) != 0 {
+		content, err = os.ReadFile(file)
+		if len(name) == 0 {
+			name = filepath.ToSlash(filepath.Clean(file))
+		}
+	} else {
+		content, err = io.ReadAll(c.App.Reader)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(name) == 0 {
+		return ErrNoScriptName
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+
+	// A job script is hashed as its lines put together, a script file as its content
+	sha := scripthash.Hash(content)
+	if isJob {
+		sha = scripthash.Hash([]byte(strings.Join(lines, "")))
+	}
+
+	source, err := whitelist.EncodeScriptSource(lines)
+	if err != nil {
+		return err
+	}
+
+	script := name + "@" + sha
+	output, err := json.MarshalIndent(scriptSource{
+		AllowedScripts: []string{script},
+		ScriptSources:  map[string]string{script: source},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(c.App.Writer, string(output))
+	return err
+}
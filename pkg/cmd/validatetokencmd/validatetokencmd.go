@@ -1,15 +1,21 @@
 package validatetokencmd
 
 import (
-	"sync"
+	"context"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checkparser"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checkrunner"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/jobtoken"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/loggerclient"
 	scriptcleanerparser "github.com/kudelskisecurity/youshallnotpass/pkg/scriptcleanerclient"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/vaultclient"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/vaultclient/hashicorpclient"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/vaultclient/vaultclientparser"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
 	"github.com/urfave/cli/v2"
 )
 
@@ -64,6 +70,78 @@ func Commands() []*cli.Command {
 					Value:       "shared",
 					DefaultText: "Email of the User Executing the CI job (Username on GitHub)",
 				},
+				&cli.StringFlag{
+					Name:        "ci-commit-ref-name",
+					EnvVars:     []string{"CI_COMMIT_REF_NAME", "CUSTOM_ENV_CI_COMMIT_REF_NAME", "GITHUB_REF"},
+					Value:       "",
+					DefaultText: "Branch or Tag Name the CI Job is Run for (Full Ref on GitHub, i.e. refs/heads/main)",
+				},
+				&cli.StringFlag{
+					Name:        "ci-commit-tag",
+					EnvVars:     []string{"CI_COMMIT_TAG", "CUSTOM_ENV_CI_COMMIT_TAG"},
+					Value:       "",
+					DefaultText: "Tag Name the CI Job is Run for (Only Set for Tags on GitLab)",
+				},
+				&cli.BoolFlag{
+					Name:        "ci-commit-ref-protected",
+					EnvVars:     []string{"CI_COMMIT_REF_PROTECTED", "CUSTOM_ENV_CI_COMMIT_REF_PROTECTED", "GITHUB_REF_PROTECTED"},
+					Value:       false,
+					DefaultText: "Whether the Branch or Tag the CI Job is Run for is Protected",
+				},
+				&cli.StringFlag{
+					Name:        "ci-commit-sha",
+					EnvVars:     []string{"CI_COMMIT_SHA", "CUSTOM_ENV_CI_COMMIT_SHA", "GITHUB_SHA"},
+					Value:       "",
+					DefaultText: "Commit SHA the CI Job is Run for",
+				},
+				&cli.StringFlag{
+					Name:        "ci-project-dir",
+					EnvVars:     []string{"CI_PROJECT_DIR", "CUSTOM_ENV_CI_PROJECT_DIR", "GITHUB_WORKSPACE"},
+					Value:       "",
+					DefaultText: "Directory the Repository is Checked Out in",
+				},
+				&cli.IntFlag{
+					Name:        "ci-merge-request-iid",
+					EnvVars:     []string{"CI_MERGE_REQUEST_IID", "CUSTOM_ENV_CI_MERGE_REQUEST_IID"},
+					Value:       0,
+					DefaultText: "IID of the Merge Request of a Merge Request Pipeline",
+				},
+				&cli.StringFlag{
+					Name:        "ci-pipeline-source",
+					EnvVars:     []string{"CI_PIPELINE_SOURCE", "CUSTOM_ENV_CI_PIPELINE_SOURCE", "GITHUB_EVENT_NAME"},
+					Value:       "",
+					DefaultText: "How the CI Pipeline was Triggered (i.e. push, schedule, web, pull_request)",
+				},
+				&cli.StringFlag{
+					Name:        "ci-runner-id",
+					EnvVars:     []string{"CI_RUNNER_ID", "CUSTOM_ENV_CI_RUNNER_ID"},
+					Value:       "",
+					DefaultText: "ID of the Runner Executing the CI Job",
+				},
+				&cli.StringFlag{
+					Name:        "ci-runner-description",
+					EnvVars:     []string{"CI_RUNNER_DESCRIPTION", "CUSTOM_ENV_CI_RUNNER_DESCRIPTION", "RUNNER_NAME"},
+					Value:       "",
+					DefaultText: "Description of the Runner Executing the CI Job (Runner Name on GitHub)",
+				},
+				&cli.StringFlag{
+					Name:        "ci-runner-tags",
+					EnvVars:     []string{"CI_RUNNER_TAGS", "CUSTOM_ENV_CI_RUNNER_TAGS"},
+					Value:       "",
+					DefaultText: "Tags of the Runner Executing the CI Job (JSON List or Comma Separated)",
+				},
+				&cli.StringFlag{
+					Name:        "ci-services",
+					EnvVars:     []string{"CI_SERVICES"},
+					Value:       "",
+					DefaultText: "Service Containers of the CI Job (the GitHub Services Block as JSON, Ignored on GitLab)",
+				},
+				&cli.StringFlag{
+					Name:        "docker-run-args",
+					EnvVars:     []string{"DOCKER_RUN_ARGS"},
+					Value:       "",
+					DefaultText: "Arguments the Custom Executor Passes to docker run",
+				},
 				&cli.StringFlag{
 					Name:        "ci-platform",
 					Value:       "gitlab",
@@ -122,10 +200,33 @@ func Commands() []*cli.Command {
 				&cli.IntFlag{
 					Name:        "timeout",
 					EnvVars:     []string{"YOUSHALLNOTPASS_TIMEOUT"},
-					DefaultText: "How Long to Wait for Vault Authentication until Timeout (s)",
+					DefaultText: "How Long to Wait for the Checks and for Vault Authentication until Timeout (s)",
 					Value:       60 * 2,
 					Hidden:      true,
 				},
+				&cli.IntFlag{
+					Name:        "check-timeout",
+					EnvVars:     []string{"YOUSHALLNOTPASS_CHECK_TIMEOUT"},
+					DefaultText: "How Long Each Check May Run Before it Fails With an Error (s, 0 for the Timeout of the Run)",
+					Value:       0,
+				},
+				&cli.StringSliceFlag{
+					Name:        "whitelist-trusted-keys",
+					EnvVars:     []string{"YOUSHALLNOTPASS_WHITELIST_TRUSTED_KEYS"},
+					DefaultText: "Keys the Whitelists Must be Signed With (Replacing the Namespace Trusted Keys)",
+				},
+				&cli.BoolFlag{
+					Name:        "whitelist-signing-required",
+					EnvVars:     []string{"YOUSHALLNOTPASS_WHITELIST_SIGNING_REQUIRED"},
+					Value:       false,
+					DefaultText: "Fail Jobs Whose Whitelists Cannot be Verified (i.e. the Namespace Configuration is Unreadable)",
+				},
+				&cli.StringFlag{
+					Name:        "whitelist-version-dir",
+					EnvVars:     []string{"YOUSHALLNOTPASS_WHITELIST_VERSION_DIR"},
+					Value:       "",
+					DefaultText: "Directory Keeping the Latest Verified Version of Each Signed Whitelist (Rejects Older Versions)",
+				},
 				&cli.StringFlag{
 					Name:        "check-type",
 					Value:       "all",
@@ -150,8 +251,24 @@ func validatetokencommand(c *cli.Context) error {
 	ciProjectNamespace := c.String("ci-project-namespace")
 	ciPipelineId := c.Int("ci-pipeline-id")
 	timeout := c.Int("timeout")
+	checkTimeout := c.Int("check-timeout")
 	checkType := c.String("check-type")
 	ciPlatform := c.String("ci-platform")
+	ciCommitRefName := c.String("ci-commit-ref-name")
+	ciCommitTag := c.String("ci-commit-tag")
+	ciCommitRefProtected := c.Bool("ci-commit-ref-protected")
+	ciCommitSha := c.String("ci-commit-sha")
+	ciProjectDir := c.String("ci-project-dir")
+	ciPipelineSource := c.String("ci-pipeline-source")
+	ciMergeRequestIID := c.Int("ci-merge-request-iid")
+	ciRunnerId := c.String("ci-runner-id")
+	ciRunnerDescription := c.String("ci-runner-description")
+	ciRunnerTags := c.String("ci-runner-tags")
+	ciServices := c.String("ci-services")
+	dockerRunArgs := c.String("docker-run-args")
+	whitelistTrustedKeys := c.StringSlice("whitelist-trusted-keys")
+	whitelistSigningRequired := c.Bool("whitelist-signing-required")
+	whitelistVersionDir := c.String("whitelist-version-dir")
 
 	var v vaultclient.VaultClient
 	var err error
@@ -168,8 +285,11 @@ func validatetokencommand(c *cli.Context) error {
 		}
 	}
 
-	namespaceConfigMount := vaultRoot + "/" + ciProjectNamespace + "/" + "youshallnotpass_config"
-	projectConfigMount := vaultRoot + "/" + ciProjectPath + "/" + "youshallnotpass_config"
+	namespaceMount := vaultRoot + "/" + ciProjectNamespace
+	projectMount := vaultRoot + "/" + ciProjectPath
+
+	namespaceConfigMount := namespaceMount + "/" + "youshallnotpass_config"
+	projectConfigMount := projectMount + "/" + "youshallnotpass_config"
 
 	// Parse namespace-level configuration for youshallnotpass
 	namespaceConfig, namespaceConfigErr := v.GetNamespaceConfig(namespaceConfigMount)
@@ -179,7 +299,13 @@ func validatetokencommand(c *cli.Context) error {
 		return err
 	}
 
+	// The namespace configuration may hold the trusted whitelist keys, when signed whitelists are
+	// expected an unreadable configuration must not silently disable the verification
 	if namespaceConfigErr != nil {
+		if whitelistSigningRequired || len(whitelistTrustedKeys) != 0 {
+			return loggerClient.LogFailedExecution(namespaceConfigErr.Error())
+		}
+
 		loggerClient.LogRecoverableError(namespaceConfigErr)
 	}
 
@@ -189,6 +315,27 @@ func validatetokencommand(c *cli.Context) error {
 		loggerClient.LogRecoverableError(err)
 	}
 
+	// On GitLab the ref, commit, pipeline source, user and runner are taken from the claims of the
+	// job JWT the vault verified to log in as the job variables can override the CUSTOM_ENV_* ones
+	jwtToken := c.String("jwt-token")
+	if strings.EqualFold(ciPlatform, "gitlab") && len(jwtToken) != 0 {
+		claims, err := jobtoken.ParseClaims(jwtToken)
+		if err != nil {
+			return loggerClient.LogFailedExecution(err.Error())
+		}
+
+		ciCommitRefName = claims.Ref
+		ciCommitTag = ""
+		if claims.IsTag() {
+			ciCommitTag = claims.Ref
+		}
+		ciCommitRefProtected = claims.IsProtected()
+		ciCommitSha = claims.Sha
+		ciPipelineSource = claims.PipelineSource
+		ciUserEmail = claims.UserEmail
+		ciRunnerId = claims.RunnerId.String()
+	}
+
 	cleaner, err := scriptcleanerparser.ParseCleaner(ciPlatform)
 	if err != nil {
 		return loggerClient.LogFailedExecution(err.Error())
@@ -196,66 +343,110 @@ func validatetokencommand(c *cli.Context) error {
 
 	scriptLines := cleaner.CleanupScript(ciJobScript)
 
+	hostname, err := os.Hostname()
+	if err != nil {
+		loggerClient.LogRecoverableError(err)
+	}
+
 	// Parse Checks for the Current Job
 	checkConfigs := projectConfig.GetJobConfig(ciJobName)
 
-	jobChecks, err := checkparser.ParseChecks(checkConfigs, ciJobName, ciJobImage, scriptLines, checkType, ciPlatform)
+	job := checks.JobInfo{
+		JobName:                ciJobName,
+		Image:                  ciJobImage,
+		ScriptLines:            scriptLines,
+		CiPlatform:             ciPlatform,
+		UserEmail:              ciUserEmail,
+		CommitRefName:          ciCommitRefName,
+		CommitTag:              ciCommitTag,
+		CommitRefProtected:     ciCommitRefProtected,
+		CommitSha:              ciCommitSha,
+		ProjectDir:             ciProjectDir,
+		ProjectPath:            ciProjectPath,
+		MergeRequestIID:        ciMergeRequestIID,
+		GitLab:                 namespaceConfig.GitLab,
+		Hostname:               hostname,
+		RunnerId:               ciRunnerId,
+		RunnerDescription:      ciRunnerDescription,
+		RunnerTags:             ciRunnerTags,
+		DockerRunArgs:          dockerRunArgs,
+		Services:               cleaner.Services(ciServices),
+		PipelineSource:         cleaner.PipelineSource(ciPipelineSource),
+		PlatformPipelineSource: ciPipelineSource,
+		Vault:                  v,
+		NamespaceMount:         namespaceMount,
+		ProjectMount:           projectMount,
+	}
+
+	jobChecks, err := checkparser.ParseChecks(checkConfigs, job, checkType)
 	if err != nil {
 		return loggerClient.LogFailedExecution(err.Error())
 	}
 
 	// Get whitelist
-	namespaceWhitelistMount := vaultRoot + "/" + ciProjectNamespace + "/" + "whitelist"
-	projectWhitelistMount := vaultRoot + "/" + ciProjectPath + "/" + "whitelist"
+	namespaceWhitelistMount := namespaceMount + "/" + "whitelist"
+	projectWhitelistMount := projectMount + "/" + "whitelist"
+
+	// The namespace keys are stored in the same vault as the whitelists, anyone who can write the
+	// vault can add their own key. The keys configured on the runner are then the only ones trusted.
+	trustedKeys := whitelistTrustedKeys
+	if len(trustedKeys) == 0 {
+		trustedKeys = namespaceConfig.WhitelistSigning.TrustedKeys
+	}
+	if whitelistSigningRequired && len(trustedKeys) == 0 {
+		return loggerClient.LogFailedExecution("Whitelist Signing Required but no Trusted Keys Configured")
+	}
+
+	// Without the version directory older signed whitelists are not rejected
+	if whitelistSigningRequired && len(whitelistVersionDir) == 0 {
+		return loggerClient.LogFailedExecution("Whitelist Signing Required but no Whitelist Version Directory Configured")
+	}
+
+	whitelistVersions := whitelist.NewVersionStore(whitelistVersionDir)
 
-	whitelist, err := v.ReadWhitelists(namespaceWhitelistMount, projectWhitelistMount)
+	whitelist, err := v.ReadWhitelists(namespaceWhitelistMount, projectWhitelistMount, trustedKeys, whitelistVersions)
 	if err != nil {
 		return loggerClient.LogFailedExecution(err.Error())
 	}
 
-	// Run Checks
-	var checkWaitGroup sync.WaitGroup
-	checkChannel := make(chan checks.CheckResult, len(jobChecks))
-
-	checkWaitGroup.Add(len(jobChecks))
-	for _, check := range jobChecks {
-		check := check
-		go check.Check(checkChannel, &checkWaitGroup, whitelist)
+	// Invalid entries (i.e. with invalid dates) are expired, they are reported so that they can be fixed
+	for _, entryErr := range whitelist.EntryErrors() {
+		loggerClient.LogRecoverableError(entryErr)
 	}
 
+	// Run Checks (with a deadline derived from the timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	results := checkrunner.Run(ctx, jobChecks, whitelist, time.Duration(checkTimeout)*time.Second)
+
 	// Check Results
-	checkWaitGroup.Wait()
-	close(checkChannel)
-
-	abort := false
-	mfaRequired := false
-
-	var results []checks.CheckResult
-	for result := range checkChannel {
-		if result.Abort {
-			abort = true
-		} else if result.Mfa {
-			mfaRequired = true
+	var diffs []string
+	for _, result := range results {
+		if len(result.Diff) != 0 {
+			diffs = append(diffs, result.Diff)
 		}
-
-		results = append(results, result)
 	}
 
 	_ = loggerClient.LogCheckResults(results)
 
-	if abort {
+	outcome := checks.WorstOutcome(results)
+	switch outcome {
+	case checks.OutcomeError:
+		return loggerClient.LogFailedExecution("Check Could Not Be Evaluated")
+	case checks.OutcomeAbort:
 		return loggerClient.LogFailedExecution("Crucial Check Failed")
 	}
 
 	// If Necessary Run MFA
-	if mfaRequired {
+	if outcome == checks.OutcomeMfa {
 		// Check for prevalidation
 		if vaultclient.TokenCreated(mfaValidationToken) {
 			_ = loggerClient.LogPrevalidated()
 			return nil
 		}
 
-		return performMFA(ciPipelineId, ciUserEmail, timeout, checkType, v, loggerClient)
+		return performMFA(ciPipelineId, ciUserEmail, timeout, checkType, diffs, v, loggerClient)
 	}
 
 	_ = loggerClient.LogSuccessfulExecution()
@@ -263,7 +454,7 @@ func validatetokencommand(c *cli.Context) error {
 	return nil
 }
 
-func performMFA(pipelineId int, user string, timeout int, checkType string, c vaultclient.VaultClient, loggerClient loggerclient.LoggerClient) error {
+func performMFA(pipelineId int, user string, timeout int, checkType string, diffs []string, c vaultclient.VaultClient, loggerClient loggerclient.LoggerClient) error {
 	// Write Secret + Check if Exists
 	secretPath, err := c.WriteScratch(pipelineId, user)
 	if err != nil {
@@ -271,7 +462,7 @@ func performMFA(pipelineId int, user string, timeout int, checkType string, c va
 	}
 
 	// Tell User To Delete Scratch code at Location
-	c.LogMFAInstructions(user, loggerClient)
+	c.LogMFAInstructions(user, diffs, loggerClient)
 
 	// Check For Secret Deletion
 	success := c.WaitForMFA(timeout, secretPath)
@@ -7,8 +7,24 @@ type LoggerConfig struct {
 	Options map[string]interface{} `json:"options,omitempty"`
 }
 
+// GitLabConfig gives access to the GitLab REST API (i.e. for the mrApprovals check). The token
+// needs the read_api scope.
+type GitLabConfig struct {
+	URL   string `json:"url,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+// WhitelistSigningConfig requires the whitelists to be signed by one of the trusted keys (given
+// in the authorized_keys format or as base64 encoded Ed25519 public keys). The keys are stored
+// in the vault with the whitelists, the keys set on the runner replace them.
+type WhitelistSigningConfig struct {
+	TrustedKeys []string `json:"trustedKeys,omitempty"`
+}
+
 type NamespaceConfig struct {
-	LoggerConfig LoggerConfig `json:"logger,omitempty"`
+	LoggerConfig     LoggerConfig           `json:"logger,omitempty"`
+	GitLab           GitLabConfig           `json:"gitlab,omitempty"`
+	WhitelistSigning WhitelistSigningConfig `json:"whitelistSigning,omitempty"`
 }
 
 type CheckConfig struct {
@@ -26,7 +26,7 @@ func TestParseNamespaceConfig(t *testing.T) {
 	}
 }`,
 			expectedConfig: NamespaceConfig{
-				LoggerConfig{
+				LoggerConfig: LoggerConfig{
 					Name: "mattermost",
 					Options: map[string]interface{}{
 						"url":       "http://127.0.0.1:8000/mattermost",
@@ -46,12 +46,35 @@ func TestParseNamespaceConfig(t *testing.T) {
 	}
 }`,
 			expectedConfig: NamespaceConfig{
-				LoggerConfig{
+				LoggerConfig: LoggerConfig{
 					Name: "console",
 				},
 			},
 			errorExpected: false,
 		},
+		{
+			name: "parse gitlab api configuration",
+			jsonText: `
+{
+	"logger": {
+		"name": "console"
+	},
+	"gitlab": {
+		"url": "https://gitlab.example.com",
+		"token": "glpat-1234567890"
+	}
+}`,
+			expectedConfig: NamespaceConfig{
+				LoggerConfig: LoggerConfig{
+					Name: "console",
+				},
+				GitLab: GitLabConfig{
+					URL:   "https://gitlab.example.com",
+					Token: "glpat-1234567890",
+				},
+			},
+			errorExpected: false,
+		},
 		{
 			name:           "parse default namespace configuration",
 			jsonText:       ``,
@@ -0,0 +1,181 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// Schedule is a parsed standard five field cron expression:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Every field accepts '*', numbers, ranges (1-5), lists (1,3,5) and steps (*/15, 0-30/10).
+// Months and week days also accept their three letter English names (jan, mon) and
+// Sunday is either 0 or 7. As in Vixie cron, if both the day of month and the day of
+// week are restricted, a time matches if either of them matches.
+type Schedule struct {
+	expression  string
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	domStar     bool
+	dowStar     bool
+}
+
+type field struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField     = field{min: 0, max: 59}
+	hourField       = field{min: 0, max: 23}
+	dayOfMonthField = field{min: 1, max: 31}
+	monthField      = field{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dayOfWeekField = field{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Parse parses a five field cron expression.
+func Parse(expression string) (Schedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("%w %q: expected 5 fields, got %d", ErrInvalidExpression, expression, len(fields))
+	}
+
+	schedule := Schedule{expression: expression}
+
+	var err error
+	parsers := []struct {
+		bits  *uint64
+		field field
+	}{
+		{&schedule.minutes, minuteField},
+		{&schedule.hours, hourField},
+		{&schedule.daysOfMonth, dayOfMonthField},
+		{&schedule.months, monthField},
+		{&schedule.daysOfWeek, dayOfWeekField},
+	}
+
+	for i, parser := range parsers {
+		*parser.bits, err = parser.field.parse(fields[i])
+		if err != nil {
+			return Schedule{}, fmt.Errorf("%w %q: %s", ErrInvalidExpression, expression, err.Error())
+		}
+	}
+
+	// sunday can be 0 or 7
+	if schedule.daysOfWeek&(1<<7) != 0 {
+		schedule.daysOfWeek |= 1
+	}
+
+	schedule.domStar = strings.HasPrefix(fields[2], "*")
+	schedule.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return schedule, nil
+}
+
+func (f field) parse(expression string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expression, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		start, end := f.min, f.max
+		if rangePart != "*" {
+			startPart, endPart, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			start, err = f.value(startPart)
+			if err != nil {
+				return 0, err
+			}
+
+			end = start
+			if isRange {
+				end, err = f.value(endPart)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = f.max
+			}
+
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+func (f field) value(expression string) (int, error) {
+	if value, exists := f.names[strings.ToLower(expression)]; exists {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(expression)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid value %q (expected %d-%d)", expression, f.min, f.max)
+	}
+
+	return value, nil
+}
+
+// Matches returns whether the schedule fires at the minute of t (in t's location).
+func (s Schedule) Matches(t time.Time) bool {
+	if s.minutes&(1<<uint(t.Minute())) == 0 || s.hours&(1<<uint(t.Hour())) == 0 || s.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	domMatch := s.daysOfMonth&(1<<uint(t.Day())) != 0
+	dowMatch := s.daysOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+// Previous returns the latest time at or before t (truncated to the minute) at which the
+// schedule fires, searching no further back than the given limit.
+func (s Schedule) Previous(t time.Time, limit time.Duration) (time.Time, bool) {
+	start := t.Truncate(time.Minute)
+	for current := start; !current.Before(t.Add(-limit)); current = current.Add(-time.Minute) {
+		if s.Matches(current) {
+			return current, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (s Schedule) String() string {
+	return s.expression
+}
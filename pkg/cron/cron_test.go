@@ -0,0 +1,187 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	parseTests := []struct {
+		name       string
+		expression string
+		err        error
+	}{
+		{
+			name:       "every minute",
+			expression: "* * * * *",
+			err:        nil,
+		},
+		{
+			name:       "lists, ranges, steps and names",
+			expression: "*/15 2-4,22 1,15 jan-mar MON-fri",
+			err:        nil,
+		},
+		{
+			name:       "sunday as 7",
+			expression: "0 0 * * 7",
+			err:        nil,
+		},
+		{
+			name:       "too few fields",
+			expression: "0 0 * *",
+			err:        ErrInvalidExpression,
+		},
+		{
+			name:       "minute out of range",
+			expression: "60 0 * * *",
+			err:        ErrInvalidExpression,
+		},
+		{
+			name:       "reversed range",
+			expression: "0 5-2 * * *",
+			err:        ErrInvalidExpression,
+		},
+		{
+			name:       "invalid step",
+			expression: "*/0 * * * *",
+			err:        ErrInvalidExpression,
+		},
+		{
+			name:       "unknown name",
+			expression: "0 0 * foo *",
+			err:        ErrInvalidExpression,
+		},
+	}
+
+	for testNum, test := range parseTests {
+		_, err := Parse(test.expression)
+		if !errors.Is(err, test.err) {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.err, err)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	// Monday 2024-01-15 02:30 UTC
+	monday := time.Date(2024, time.January, 15, 2, 30, 0, 0, time.UTC)
+
+	matchesTests := []struct {
+		name       string
+		expression string
+		time       time.Time
+		matches    bool
+	}{
+		{
+			name:       "every minute",
+			expression: "* * * * *",
+			time:       monday,
+			matches:    true,
+		},
+		{
+			name:       "step matches",
+			expression: "*/15 2 * * *",
+			time:       monday,
+			matches:    true,
+		},
+		{
+			name:       "step does not match",
+			expression: "*/20 2 * * *",
+			time:       monday,
+			matches:    false,
+		},
+		{
+			name:       "week days",
+			expression: "30 2 * * mon-fri",
+			time:       monday,
+			matches:    true,
+		},
+		{
+			name:       "weekend",
+			expression: "30 2 * * sat,sun",
+			time:       monday,
+			matches:    false,
+		},
+		{
+			name:       "sunday as 7",
+			expression: "30 2 * * 7",
+			time:       monday.AddDate(0, 0, -1),
+			matches:    true,
+		},
+		{
+			name:       "day of month or day of week",
+			expression: "30 2 1 * mon",
+			time:       monday,
+			matches:    true,
+		},
+		{
+			name:       "day of month and any day of week",
+			expression: "30 2 1 * *",
+			time:       monday,
+			matches:    false,
+		},
+		{
+			name:       "month",
+			expression: "30 2 * feb *",
+			time:       monday,
+			matches:    false,
+		},
+	}
+
+	for testNum, test := range matchesTests {
+		schedule, err := Parse(test.expression)
+		if err != nil {
+			t.Fatalf("\n%d) unable to parse %s: %s", testNum, test.expression, err.Error())
+		}
+
+		matches := schedule.Matches(test.time)
+		if matches != test.matches {
+			t.Errorf("\n%d) unexpected match for %s -\nexpected: (%t)\ngot: (%t)", testNum, test.name, test.matches, matches)
+		}
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 2, 30, 45, 0, time.UTC)
+
+	previousTests := []struct {
+		name       string
+		expression string
+		limit      time.Duration
+		expected   time.Time
+		found      bool
+	}{
+		{
+			name:       "current minute",
+			expression: "30 2 * * *",
+			limit:      time.Hour,
+			expected:   time.Date(2024, time.January, 15, 2, 30, 0, 0, time.UTC),
+			found:      true,
+		},
+		{
+			name:       "earlier in the hour",
+			expression: "0 2 * * *",
+			limit:      time.Hour,
+			expected:   time.Date(2024, time.January, 15, 2, 0, 0, 0, time.UTC),
+			found:      true,
+		},
+		{
+			name:       "before the limit",
+			expression: "0 1 * * *",
+			limit:      time.Hour,
+			found:      false,
+		},
+	}
+
+	for testNum, test := range previousTests {
+		schedule, err := Parse(test.expression)
+		if err != nil {
+			t.Fatalf("\n%d) unable to parse %s: %s", testNum, test.expression, err.Error())
+		}
+
+		previous, found := schedule.Previous(now, test.limit)
+		if found != test.found || !previous.Equal(test.expected) {
+			t.Errorf("\n%d) unexpected previous time for %s -\nexpected: (%s, %t)\ngot: (%s, %t)", testNum, test.name, test.expected, test.found, previous, found)
+		}
+	}
+}
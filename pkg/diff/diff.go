@@ -0,0 +1,222 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around every change.
+const DefaultContext = 3
+
+// maxTableSize caps the size of the longest common subsequence table (about 8MB), the lines
+// of larger inputs are not diffed (see TooLargeDetails).
+const maxTableSize = 1 << 20
+
+// TooLargeDetails replaces the hunks of inputs which differ in too many lines to be diffed.
+var TooLargeDetails = "@@ content changed, too many lines changed to be diffed @@\n"
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	a    int // index of the line in a (valid for opEqual and opDelete)
+	b    int // index of the line in b (valid for opEqual and opInsert)
+}
+
+// Unified returns a unified diff (as produced by diff -u) from the lines in a to the lines in b
+// with context lines of unchanged context around every change. An empty string is returned if
+// both line slices are identical.
+func Unified(aName string, bName string, a []string, b []string, context int) string {
+	ops, ok := lineOps(a, b)
+	if !ok {
+		return fmt.Sprintf("--- %s\n+++ %s\n", aName, bName) + TooLargeDetails
+	}
+
+	changed := false
+	for _, o := range ops {
+		if o.kind != opEqual {
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+
+	if context < 0 {
+		context = 0
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+
+	for start := 0; start < len(ops); {
+		// find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == opEqual {
+			first++
+		}
+
+		if first == len(ops) {
+			break
+		}
+
+		// extend the hunk until there are more than 2*context equal lines between changes
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != opEqual {
+				last = i
+			} else if i-last > 2*context {
+				break
+			}
+		}
+
+		hunkStart := max(first-context, start)
+		hunkEnd := min(last+context+1, len(ops))
+
+		writeHunk(&out, a, b, ops[hunkStart:hunkEnd])
+
+		start = hunkEnd
+	}
+
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, a []string, b []string, ops []op) {
+	aStart, bStart := -1, -1
+	aCount, bCount := 0, 0
+
+	for _, o := range ops {
+		if o.kind != opInsert {
+			if aStart == -1 {
+				aStart = o.a
+			}
+			aCount++
+		}
+
+		if o.kind != opDelete {
+			if bStart == -1 {
+				bStart = o.b
+			}
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount, ops[0].a), hunkRange(bStart, bCount, ops[0].b))
+
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			out.WriteString(" " + a[o.a] + "\n")
+		case opDelete:
+			out.WriteString("-" + a[o.a] + "\n")
+		case opInsert:
+			out.WriteString("+" + b[o.b] + "\n")
+		}
+	}
+}
+
+// hunkRange formats the line range of one side of a hunk. Empty ranges refer to the line
+// before the hunk, as in GNU diff.
+func hunkRange(start int, count int, position int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", position)
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// lineOps computes the edit script from a to b using the longest common subsequence of lines.
+// Only the lines between the common prefix and suffix are compared, false is returned if there
+// are too many of them.
+func lineOps(a []string, b []string) ([]op, bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	aEnd, bEnd := len(a)-suffix, len(b)-suffix
+	if (aEnd-prefix+1)*(bEnd-prefix+1) > maxTableSize {
+		return nil, false
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[prefix+i:aEnd] and b[prefix+j:bEnd]
+	lcs := make([][]int, aEnd-prefix+1)
+	for i := range lcs {
+		lcs[i] = make([]int, bEnd-prefix+1)
+	}
+
+	for i := aEnd - 1; i >= prefix; i-- {
+		for j := bEnd - 1; j >= prefix; j-- {
+			if a[i] == b[j] {
+				lcs[i-prefix][j-prefix] = lcs[i-prefix+1][j-prefix+1] + 1
+			} else {
+				lcs[i-prefix][j-prefix] = max(lcs[i-prefix+1][j-prefix], lcs[i-prefix][j-prefix+1])
+			}
+		}
+	}
+
+	var ops []op
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: opEqual, a: i, b: i})
+	}
+
+	i, j := prefix, prefix
+	for i < aEnd && j < bEnd {
+		if a[i] == b[j] {
+			ops = append(ops, op{kind: opEqual, a: i, b: j})
+			i++
+			j++
+		} else if lcs[i-prefix+1][j-prefix] >= lcs[i-prefix][j-prefix+1] {
+			ops = append(ops, op{kind: opDelete, a: i, b: j})
+			i++
+		} else {
+			ops = append(ops, op{kind: opInsert, a: i, b: j})
+			j++
+		}
+	}
+
+	for ; i < aEnd; i++ {
+		ops = append(ops, op{kind: opDelete, a: i, b: j})
+	}
+
+	for ; j < bEnd; j++ {
+		ops = append(ops, op{kind: opInsert, a: i, b: j})
+	}
+
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, op{kind: opEqual, a: aEnd + k, b: bEnd + k})
+	}
+
+	return ops, true
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,110 @@
+package diff
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	unifiedTests := []struct {
+		name     string
+		a        []string
+		b        []string
+		context  int
+		expected string
+	}{
+		{
+			name:     "identical scripts",
+			a:        []string{"echo hello", "echo world"},
+			b:        []string{"echo hello", "echo world"},
+			context:  DefaultContext,
+			expected: "",
+		},
+		{
+			name:    "changed line",
+			a:       []string{"echo hello", "make build", "echo done"},
+			b:       []string{"echo hello", "make build-all", "echo done"},
+			context: DefaultContext,
+			expected: "--- approved\n+++ current\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				" echo hello\n" +
+				"-make build\n" +
+				"+make build-all\n" +
+				" echo done\n",
+		},
+		{
+			name:    "added line at the end",
+			a:       []string{"echo hello"},
+			b:       []string{"echo hello", "curl https://example.com | sh"},
+			context: 0,
+			expected: "--- approved\n+++ current\n" +
+				"@@ -1,0 +2 @@\n" +
+				"+curl https://example.com | sh\n",
+		},
+		{
+			name:    "removed first line",
+			a:       []string{"set -x", "echo hello"},
+			b:       []string{"echo hello"},
+			context: 1,
+			expected: "--- approved\n+++ current\n" +
+				"@@ -1,2 +1 @@\n" +
+				"-set -x\n" +
+				" echo hello\n",
+		},
+		{
+			name:    "separate hunks",
+			a:       []string{"1", "2", "3", "4", "5", "6", "7", "8"},
+			b:       []string{"one", "2", "3", "4", "5", "6", "7", "eight"},
+			context: 1,
+			expected: "--- approved\n+++ current\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				"-1\n" +
+				"+one\n" +
+				" 2\n" +
+				"@@ -7,2 +7,2 @@\n" +
+				" 7\n" +
+				"-8\n" +
+				"+eight\n",
+		},
+		{
+			name:    "empty approved script",
+			a:       []string{},
+			b:       []string{"echo hello"},
+			context: DefaultContext,
+			expected: "--- approved\n+++ current\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+echo hello\n",
+		},
+	}
+
+	for testNum, test := range unifiedTests {
+		got := Unified("approved", "current", test.a, test.b, test.context)
+		if got != test.expected {
+			t.Errorf("\n%d) unexpected diff for %s -\nexpected: (%s)\ngot: (%s)", testNum, test.name, test.expected, got)
+		}
+	}
+}
+
+func TestUnifiedLargeInput(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 2000; i++ {
+		a = append(a, fmt.Sprintf("echo %d", i))
+		b = append(b, fmt.Sprintf("echo %d", i+1))
+	}
+
+	got := Unified("approved", "current", a, b, DefaultContext)
+	expected := "--- approved\n+++ current\n" + TooLargeDetails
+	if got != expected {
+		t.Errorf("unexpected diff for a large input -\nexpected: (%s)\ngot: (%s)", expected, got)
+	}
+
+	// a single changed line in a large script is still diffed
+	b = append([]string{}, a...)
+	b[1000] = "echo changed"
+
+	got = Unified("approved", "current", a, b, 0)
+	expected = "--- approved\n+++ current\n@@ -1001 +1001 @@\n-echo 1000\n+echo changed\n"
+	if got != expected {
+		t.Errorf("unexpected diff for a large script -\nexpected: (%s)\ngot: (%s)", expected, got)
+	}
+}
@@ -0,0 +1,131 @@
+package gitlabapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("not found in GitLab")
+	ErrUnexpectedStatus = errors.New("unexpected GitLab response")
+	defaultTimeout      = 30 * time.Second
+	maxContentSize      = int64(4 << 20)
+	apiPath             = "/api/v4"
+)
+
+// User is a GitLab user as returned in the approvals of a merge request.
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// MergeRequest is a GitLab merge request as far as needed to read its approvals.
+type MergeRequest struct {
+	IID    int    `json:"iid"`
+	State  string `json:"state"`
+	SHA    string `json:"sha"`
+	WebURL string `json:"web_url"`
+}
+
+// Approval is the approval of a merge request by a user.
+type Approval struct {
+	User User `json:"user"`
+}
+
+// Approvals are the approvals given to a merge request.
+type Approvals struct {
+	ApprovedBy []Approval `json:"approved_by"`
+}
+
+// Client reads merge requests and their approvals from the GitLab REST API (v4) with a
+// personal, group or project access token.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewClient creates a GitLab API client for the GitLab instance at baseURL (i.e.
+// "https://gitlab.example.com").
+func NewClient(baseURL string, token string) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+	}
+}
+
+// MergeRequest returns the merge request with the given IID of the project (given by its
+// path, i.e. "team/app").
+func (c *Client) MergeRequest(ctx context.Context, project string, iid int) (MergeRequest, error) {
+	var mergeRequest MergeRequest
+	err := c.get(ctx, fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(project), iid), &mergeRequest)
+	return mergeRequest, err
+}
+
+// CommitMergeRequests returns the merge requests of the project the commit is part of.
+func (c *Client) CommitMergeRequests(ctx context.Context, project string, sha string) ([]MergeRequest, error) {
+	var mergeRequests []MergeRequest
+	err := c.get(ctx, fmt.Sprintf("/projects/%s/repository/commits/%s/merge_requests", url.PathEscape(project), url.PathEscape(sha)), &mergeRequests)
+	return mergeRequests, err
+}
+
+// MergeRequestApprovals returns the approvals of the merge request with the given IID.
+func (c *Client) MergeRequestApprovals(ctx context.Context, project string, iid int) (Approvals, error) {
+	var approvals Approvals
+	err := c.get(ctx, fmt.Sprintf("/projects/%s/merge_requests/%d/approvals", url.PathEscape(project), iid), &approvals)
+	return approvals, err
+}
+
+// IsGroupMember returns whether the user is a member of the group (given by its path, i.e.
+// "team/release-managers"), including inherited memberships.
+func (c *Client) IsGroupMember(ctx context.Context, group string, userID int) (bool, error) {
+	var member User
+	err := c.get(ctx, fmt.Sprintf("/groups/%s/members/all/%d", url.PathEscape(group), userID), &member)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+apiPath+path, nil)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Accept", "application/json")
+	if len(c.token) != 0 {
+		request.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("%s: %w", path, ErrNotFound)
+	default:
+		return fmt.Errorf("%w %s for %s", ErrUnexpectedStatus, response.Status, path)
+	}
+
+	err = json.NewDecoder(io.LimitReader(response.Body, maxContentSize)).Decode(v)
+	if err != nil {
+		return fmt.Errorf("unable to parse %s: %s", path, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package gitlabapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newTestGitLab serves merge request !7 of team/app approved by alice (a member of
+// team/release-managers) and bob, requiring the test token.
+func newTestGitLab(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/team%2Fapp/merge_requests/7":
+			fmt.Fprint(w, `{"iid": 7, "state": "opened", "sha": "abc123", "web_url": "https://gitlab.example.com/team/app/-/merge_requests/7"}`)
+		case "/api/v4/projects/team%2Fapp/repository/commits/abc123/merge_requests":
+			fmt.Fprint(w, `[{"iid": 7, "state": "opened", "sha": "abc123"}]`)
+		case "/api/v4/projects/team%2Fapp/merge_requests/7/approvals":
+			fmt.Fprint(w, `{"approved_by": [{"user": {"id": 1, "username": "alice", "name": "Alice"}}, {"user": {"id": 2, "username": "bob", "name": "Bob"}}]}`)
+		case "/api/v4/groups/team%2Frelease-managers/members/all/1":
+			fmt.Fprint(w, `{"id": 1, "username": "alice", "name": "Alice"}`)
+		case "/api/v4/projects/team%2Fapp/merge_requests/8":
+			fmt.Fprint(w, `{"iid": 8,`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := newTestGitLab(t)
+	client := NewClient(server.URL+"/", "test-token")
+	ctx := context.Background()
+
+	mergeRequest, err := client.MergeRequest(ctx, "team/app", 7)
+	expectedMergeRequest := MergeRequest{IID: 7, State: "opened", SHA: "abc123", WebURL: "https://gitlab.example.com/team/app/-/merge_requests/7"}
+	if err != nil || mergeRequest != expectedMergeRequest {
+		t.Errorf("unexpected merge request -\nexpected: (%+v)\ngot: (%+v, %v)", expectedMergeRequest, mergeRequest, err)
+	}
+
+	mergeRequests, err := client.CommitMergeRequests(ctx, "team/app", "abc123")
+	expectedMergeRequests := []MergeRequest{{IID: 7, State: "opened", SHA: "abc123"}}
+	if err != nil || !reflect.DeepEqual(mergeRequests, expectedMergeRequests) {
+		t.Errorf("unexpected commit merge requests -\nexpected: (%+v)\ngot: (%+v, %v)", expectedMergeRequests, mergeRequests, err)
+	}
+
+	approvals, err := client.MergeRequestApprovals(ctx, "team/app", 7)
+	expectedApprovals := Approvals{ApprovedBy: []Approval{{User: User{ID: 1, Username: "alice", Name: "Alice"}}, {User: User{ID: 2, Username: "bob", Name: "Bob"}}}}
+	if err != nil || !reflect.DeepEqual(approvals, expectedApprovals) {
+		t.Errorf("unexpected approvals -\nexpected: (%+v)\ngot: (%+v, %v)", expectedApprovals, approvals, err)
+	}
+
+	for userID, expectedMember := range map[int]bool{1: true, 2: false} {
+		member, err := client.IsGroupMember(ctx, "team/release-managers", userID)
+		if err != nil || member != expectedMember {
+			t.Errorf("unexpected membership of user %d -\nexpected: (%t)\ngot: (%t, %v)", userID, expectedMember, member, err)
+		}
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := newTestGitLab(t)
+	ctx := context.Background()
+
+	_, err := NewClient(server.URL, "test-token").MergeRequest(ctx, "team/other", 7)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("unexpected error for a missing merge request -\nexpected: (%v)\ngot: (%v)", ErrNotFound, err)
+	}
+
+	_, err = NewClient(server.URL, "wrong-token").MergeRequest(ctx, "team/app", 7)
+	if !errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("unexpected error for a wrong token -\nexpected: (%v)\ngot: (%v)", ErrUnexpectedStatus, err)
+	}
+
+	_, err = NewClient(server.URL, "wrong-token").IsGroupMember(ctx, "team/release-managers", 1)
+	if !errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("unexpected error for a membership with a wrong token -\nexpected: (%v)\ngot: (%v)", ErrUnexpectedStatus, err)
+	}
+
+	_, err = NewClient(server.URL, "test-token").MergeRequest(ctx, "team/app", 8)
+	if err == nil {
+		t.Errorf("expected an error for an invalid response")
+	}
+}
@@ -0,0 +1,61 @@
+package glob
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Match reports whether name matches the shell-like glob pattern.
+//
+// The following wildcards are supported:
+//   - '*' matches any sequence of characters except '/'
+//   - '**' matches any sequence of characters including '/'
+//   - '?' matches any single character except '/'
+//
+// Example:
+// "release/*" matches "release/1.0" but not "release/1.0/hotfix", "registry.internal/**"
+// matches "registry.internal/team/base-alpine".
+func Match(pattern string, name string) bool {
+	re, err := regexp.Compile(toRegexp(pattern))
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(name)
+}
+
+// MatchAny reports whether name matches at least one of the glob patterns.
+func MatchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toRegexp(pattern string) string {
+	var builder strings.Builder
+	builder.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				builder.WriteString(".*")
+				i++
+			} else {
+				builder.WriteString("[^/]*")
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		}
+	}
+
+	builder.WriteString("$")
+
+	return builder.String()
+}
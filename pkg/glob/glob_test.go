@@ -0,0 +1,103 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	matchTests := []struct {
+		name    string
+		pattern string
+		input   string
+		match   bool
+	}{
+		{
+			name:    "exact match",
+			pattern: "main",
+			input:   "main",
+			match:   true,
+		},
+		{
+			name:    "exact mismatch",
+			pattern: "main",
+			input:   "maintenance",
+			match:   false,
+		},
+		{
+			name:    "single star within segment",
+			pattern: "release/*",
+			input:   "release/1.0",
+			match:   true,
+		},
+		{
+			name:    "single star does not cross segments",
+			pattern: "release/*",
+			input:   "release/1.0/hotfix",
+			match:   false,
+		},
+		{
+			name:    "double star crosses segments",
+			pattern: "registry.internal/**",
+			input:   "registry.internal/team/base-alpine",
+			match:   true,
+		},
+		{
+			name:    "star in the middle of a pattern",
+			pattern: "registry.internal/*/base-*",
+			input:   "registry.internal/team/base-alpine",
+			match:   true,
+		},
+		{
+			name:    "question mark matches single character",
+			pattern: "v?.0",
+			input:   "v1.0",
+			match:   true,
+		},
+		{
+			name:    "regexp characters are escaped",
+			pattern: "v1.0",
+			input:   "v1x0",
+			match:   false,
+		},
+	}
+
+	for testNum, test := range matchTests {
+		match := Match(test.pattern, test.input)
+		if match != test.match {
+			t.Errorf("\n%d) unexpected match for %s -\nexpected: (%t)\ngot: (%t)", testNum, test.name, test.match, match)
+		}
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	matchAnyTests := []struct {
+		name     string
+		patterns []string
+		input    string
+		match    bool
+	}{
+		{
+			name:     "no patterns",
+			patterns: []string{},
+			input:    "main",
+			match:    false,
+		},
+		{
+			name:     "one of the patterns matches",
+			patterns: []string{"develop", "main"},
+			input:    "main",
+			match:    true,
+		},
+		{
+			name:     "none of the patterns match",
+			patterns: []string{"develop", "release/*"},
+			input:    "main",
+			match:    false,
+		},
+	}
+
+	for testNum, test := range matchAnyTests {
+		match := MatchAny(test.patterns, test.input)
+		if match != test.match {
+			t.Errorf("\n%d) unexpected match for %s -\nexpected: (%t)\ngot: (%t)", testNum, test.name, test.match, match)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package imageref
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrEmptyReference      = errors.New("empty image reference")
+	ErrInvalidReference    = errors.New("invalid image reference")
+	DefaultRegistry        = "docker.io"
+	DefaultTag             = "latest"
+	officialRepositoryPath = "library/"
+	legacyDefaultRegistry  = "index.docker.io"
+
+	pathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	tagRegexp           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp        = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+)
+
+// Reference is a parsed container image reference of the form
+// [registry/]repository[:tag][@digest].
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// Parse parses and normalizes an image reference the same way docker does.
+//
+// Example:
+// "alpine:3.18" -> {Registry: "docker.io", Repository: "library/alpine", Tag: "3.18"}
+// "registry.internal:5000/team/base@sha256:..." -> {Registry: "registry.internal:5000", Repository: "team/base", Digest: "sha256:..."}
+func Parse(image string) (Reference, error) {
+	image = strings.TrimSpace(image)
+	if len(image) == 0 {
+		return Reference{}, ErrEmptyReference
+	}
+
+	reference := Reference{}
+
+	if at := strings.Index(image, "@"); at != -1 {
+		reference.Digest = image[at+1:]
+		image = image[:at]
+
+		if !digestRegexp.MatchString(reference.Digest) {
+			return Reference{}, ErrInvalidReference
+		}
+	}
+
+	name := image
+	lastSlash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > lastSlash {
+		reference.Tag = image[colon+1:]
+		name = image[:colon]
+
+		if !tagRegexp.MatchString(reference.Tag) {
+			return Reference{}, ErrInvalidReference
+		}
+	}
+
+	reference.Registry = DefaultRegistry
+	reference.Repository = name
+	if slash := strings.Index(name, "/"); slash != -1 {
+		host := name[:slash]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			reference.Registry = strings.ToLower(host)
+			reference.Repository = name[slash+1:]
+		}
+	}
+
+	if reference.Registry == legacyDefaultRegistry {
+		reference.Registry = DefaultRegistry
+	}
+
+	if reference.Registry == DefaultRegistry && !strings.Contains(reference.Repository, "/") {
+		reference.Repository = officialRepositoryPath + reference.Repository
+	}
+
+	for _, component := range strings.Split(reference.Repository, "/") {
+		if !pathComponentRegexp.MatchString(component) {
+			return Reference{}, ErrInvalidReference
+		}
+	}
+
+	if len(reference.Tag) == 0 && len(reference.Digest) == 0 {
+		reference.Tag = DefaultTag
+	}
+
+	return reference, nil
+}
+
+// Name returns the fully qualified repository name (i.e. docker.io/library/alpine).
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the fully qualified image reference.
+func (r Reference) String() string {
+	reference := r.Name()
+	if len(r.Tag) != 0 {
+		reference += ":" + r.Tag
+	}
+
+	if len(r.Digest) != 0 {
+		reference += "@" + r.Digest
+	}
+
+	return reference
+}
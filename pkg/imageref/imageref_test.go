@@ -0,0 +1,125 @@
+package imageref
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	parseTests := []struct {
+		name              string
+		image             string
+		expectedReference Reference
+		expectedName      string
+		err               error
+	}{
+		{
+			name:  "official image",
+			image: "alpine",
+			expectedReference: Reference{
+				Registry:   "docker.io",
+				Repository: "library/alpine",
+				Tag:        "latest",
+			},
+			expectedName: "docker.io/library/alpine",
+		},
+		{
+			name:  "official image with tag and digest",
+			image: "alpine:3.18.4@sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978",
+			expectedReference: Reference{
+				Registry:   "docker.io",
+				Repository: "library/alpine",
+				Tag:        "3.18.4",
+				Digest:     "sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978",
+			},
+			expectedName: "docker.io/library/alpine",
+		},
+		{
+			name:  "docker hub user image",
+			image: "bitnami/redis:7.2",
+			expectedReference: Reference{
+				Registry:   "docker.io",
+				Repository: "bitnami/redis",
+				Tag:        "7.2",
+			},
+			expectedName: "docker.io/bitnami/redis",
+		},
+		{
+			name:  "legacy docker hub registry",
+			image: "index.docker.io/alpine",
+			expectedReference: Reference{
+				Registry:   "docker.io",
+				Repository: "library/alpine",
+				Tag:        "latest",
+			},
+			expectedName: "docker.io/library/alpine",
+		},
+		{
+			name:  "private registry with port and digest only",
+			image: "registry.internal:5000/team/base-alpine@sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978",
+			expectedReference: Reference{
+				Registry:   "registry.internal:5000",
+				Repository: "team/base-alpine",
+				Digest:     "sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978",
+			},
+			expectedName: "registry.internal:5000/team/base-alpine",
+		},
+		{
+			name:  "localhost registry",
+			image: "localhost/base:1",
+			expectedReference: Reference{
+				Registry:   "localhost",
+				Repository: "base",
+				Tag:        "1",
+			},
+			expectedName: "localhost/base",
+		},
+		{
+			name:  "empty image",
+			image: "",
+			err:   ErrEmptyReference,
+		},
+		{
+			name:  "uppercase repository",
+			image: "Alpine:3.18",
+			err:   ErrInvalidReference,
+		},
+		{
+			name:  "invalid digest",
+			image: "alpine@sha256",
+			err:   ErrInvalidReference,
+		},
+		{
+			name:  "invalid tag",
+			image: "alpine:-3",
+			err:   ErrInvalidReference,
+		},
+	}
+
+	for testNum, test := range parseTests {
+		reference, err := Parse(test.image)
+		if err != test.err {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.err, err)
+			continue
+		}
+
+		if reference != test.expectedReference {
+			t.Errorf("\n%d) unexpected reference for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedReference, reference)
+		}
+
+		if err == nil && reference.Name() != test.expectedName {
+			t.Errorf("\n%d) unexpected name for %s -\nexpected: (%s)\ngot: (%s)", testNum, test.name, test.expectedName, reference.Name())
+		}
+	}
+}
+
+func TestReferenceString(t *testing.T) {
+	reference := Reference{
+		Registry:   "registry.internal",
+		Repository: "team/base",
+		Tag:        "1.0",
+		Digest:     "sha256:abc",
+	}
+
+	expected := "registry.internal/team/base:1.0@sha256:abc"
+	if reference.String() != expected {
+		t.Errorf("unexpected string - expected: (%s) - got: (%s)", expected, reference.String())
+	}
+}
@@ -0,0 +1,62 @@
+package jobtoken
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid job token")
+	refTypeTag      = "tag"
+)
+
+// Claims are the claims of the GitLab CI job JWT (CI_JOB_JWT or an id_token) describing the
+// ref, the commit, the user and the runner of the job. Unlike the CUSTOM_ENV_* variables, they cannot be
+// overridden by the variables of the job.
+type Claims struct {
+	Ref            string      `json:"ref"`
+	RefType        string      `json:"ref_type"`
+	RefProtected   string      `json:"ref_protected"`
+	Sha            string      `json:"sha"`
+	PipelineSource string      `json:"pipeline_source"`
+	UserEmail      string      `json:"user_email"`
+	RunnerId       json.Number `json:"runner_id"`
+}
+
+// ParseClaims decodes the claims of a JWT. The signature of the token is not verified, the
+// claims must only be trusted once the vault accepted the token to log in (the vault verifies
+// it against the keys of the GitLab instance).
+func ParseClaims(token string) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return claims, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+
+	return claims, nil
+}
+
+// IsTag returns whether the job is running for a tag.
+func (c Claims) IsTag() bool {
+	return c.RefType == refTypeTag
+}
+
+// IsProtected returns whether the ref the job is running for is protected (GitLab encodes
+// the flag as a string).
+func (c Claims) IsProtected() bool {
+	return c.RefProtected == "true"
+}
@@ -0,0 +1,57 @@
+package jobtoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func token(payload string) string {
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func TestParseClaims(t *testing.T) {
+	parseClaimsTests := []struct {
+		name              string
+		token             string
+		expectedClaims    Claims
+		expectedTag       bool
+		expectedProtected bool
+		expectedError     error
+	}{
+		{
+			name:              "protected tag",
+			token:             token(`{"ref": "v1.0.0", "ref_type": "tag", "ref_protected": "true", "sha": "0123456789abcdef", "pipeline_source": "push", "user_email": "alice@example.com", "runner_id": 42}`),
+			expectedClaims:    Claims{Ref: "v1.0.0", RefType: "tag", RefProtected: "true", Sha: "0123456789abcdef", PipelineSource: "push", UserEmail: "alice@example.com", RunnerId: "42"},
+			expectedTag:       true,
+			expectedProtected: true,
+		},
+		{
+			name:           "unprotected branch",
+			token:          token(`{"ref": "refs/tags/v1.0.0", "ref_type": "branch", "ref_protected": "false"}`),
+			expectedClaims: Claims{Ref: "refs/tags/v1.0.0", RefType: "branch", RefProtected: "false"},
+		},
+		{
+			name:          "not a jwt",
+			token:         "glpat-1234567890",
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name:          "invalid payload",
+			token:         "eyJhbGciOiJSUzI1NiJ9.bm90IGpzb24.c2lnbmF0dXJl",
+			expectedError: ErrInvalidToken,
+		},
+	}
+
+	for testNum, test := range parseClaimsTests {
+		claims, err := ParseClaims(test.token)
+		if !errors.Is(err, test.expectedError) {
+			t.Errorf("\n%d) Errors not equal for %s -\nexpected: (%v)\ngot: (%v)", testNum, test.name, test.expectedError, err)
+			continue
+		}
+
+		if claims != test.expectedClaims || claims.IsTag() != test.expectedTag || claims.IsProtected() != test.expectedProtected {
+			t.Errorf("\n%d) Claims not equal for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.expectedClaims, claims)
+		}
+	}
+}
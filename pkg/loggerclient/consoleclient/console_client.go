@@ -7,9 +7,10 @@ import (
 )
 
 var (
-	colorRed   = "\033[31m"
-	colorGreen = "\033[32m"
-	colorReset = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
 )
 
 type ConsoleClient struct {
@@ -41,15 +42,36 @@ func (c *ConsoleClient) LogCheckResults(results []checks.CheckResult) error {
 	message := "---------------------------------------------------------------------\n"
 	message += fmt.Sprintf("%s/ui/vault/secrets/%s - %s\n", c.vaultExternalAddr, c.vaultRoot+"/show/"+c.ciProjectPath+"/whitelist", c.ciJobName)
 	message += "---------------------------------------------------------------------\n"
-	message += "   Name   |   Version   |   Error   |   Abort   |   Mfa   |   Details\n"
+	message += "   Name   |   Version   |   Outcome   |   Severity   |   Error   |   Details\n"
 	message += "---------------------------------------------------------------------\n"
+	var warnings []checks.CheckResult
 	for _, result := range results {
-		errStr := ""
-		if result.Error != nil {
-			errStr = result.Error.Error()
+		// the results of composite checks are shown as a tree below them, their nested warnings
+		// are listed with the others
+		checks.WalkResults([]checks.CheckResult{result}, func(prefix string, result checks.CheckResult) {
+			if result.Outcome == checks.OutcomeWarn {
+				warnings = append(warnings, result)
+			}
+
+			errStr := ""
+			if result.Error != nil {
+				errStr = result.Error.Error()
+			}
+			message += fmt.Sprintf("%s%s | %s | %s | %s | %s | %s\n", prefix, result.Name, result.Version,
+				result.Outcome, result.Severity, errStr, result.Details)
+		})
+		if len(result.Diff) != 0 {
+			message += result.Diff
+		}
+		message += "---------------------------------------------------------------------\n"
+	}
+
+	// warnings do not block the job, they are listed again so they are not missed
+	if len(warnings) != 0 {
+		message += fmt.Sprintf("  ⚠️  %sWarnings (the job is not blocked)%s\n", string(colorYellow), string(colorReset))
+		for _, result := range warnings {
+			message += fmt.Sprintf("  - %s [%s]: %s\n", result.Name, result.Severity, result.Details)
 		}
-		message += fmt.Sprintf("%s | %s | %s | %t | %t | %s\n", result.Name, result.Version,
-			errStr, result.Abort, result.Mfa, result.Details)
 		message += "---------------------------------------------------------------------\n"
 	}
 	fmt.Printf("\n\n%s\n\n", message)
@@ -84,28 +84,42 @@ func TestLogCheckResults(t *testing.T) {
 
 	checkResults := []checks.CheckResult{
 		{
-			Name:    "imageHash",
-			Version: "1.0.0",
-			Error:   nil,
-			Abort:   true,
-			Mfa:     false,
-			Details: "New Image Detected",
+			Name:     "imageHash",
+			Version:  "1.0.0",
+			Error:    nil,
+			Outcome:  checks.OutcomeAbort,
+			Severity: checks.SeverityHigh,
+			Details:  "New Image Detected",
 		},
 		{
 			Name:    "scriptHash",
 			Version: "1.0.0",
 			Error:   nil,
-			Abort:   false,
-			Mfa:     false,
 			Details: "Script Hash Check Succeeded",
 		},
 		{
-			Name:    "mfaRequired",
+			Name:     "mfaRequired",
+			Version:  "1.0.0",
+			Error:    nil,
+			Outcome:  checks.OutcomeMfa,
+			Severity: checks.SeverityMedium,
+			Details:  "Mfa required for job",
+		},
+		{
+			Name:    "Composite Check",
 			Version: "1.0.0",
 			Error:   nil,
-			Abort:   false,
-			Mfa:     true,
-			Details: "Mfa required for job",
+			Details: "Policy passed",
+			Children: []checks.CheckResult{
+				{
+					Name:     "Image Signature Check",
+					Version:  "1.0.0",
+					Error:    nil,
+					Outcome:  checks.OutcomeWarn,
+					Severity: checks.SeverityLow,
+					Details:  "Image is not signed",
+				},
+			},
 		},
 	}
 
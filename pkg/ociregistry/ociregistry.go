@@ -0,0 +1,311 @@
+package ociregistry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/imageref"
+)
+
+var (
+	ErrNotFound          = errors.New("not found in the registry")
+	ErrDigestMismatch    = errors.New("content does not match its digest")
+	ErrUnexpectedStatus  = errors.New("unexpected registry response")
+	ErrUnsupportedDigest = errors.New("unsupported digest algorithm")
+	dockerHubRegistry    = "registry-1.docker.io"
+	defaultTimeout       = 30 * time.Second
+	maxContentSize       = int64(4 << 20)
+	manifestMediaTypes   = []string{
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+	}
+)
+
+// Descriptor describes content stored in a registry.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an OCI image manifest (or docker v2 manifest) as far as needed to read
+// the artifacts attached to an image.
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// Client reads manifests and blobs from OCI distribution registries. Only anonymous
+// access (including anonymous bearer tokens, as used by Docker Hub) is supported.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	tokens     map[string]string
+}
+
+// NewClient creates a registry client. If baseURL is set (i.e. "http://localhost:5000"),
+// every request is sent to it instead of the registry of the image (i.e. to use a local
+// registry or a mirror), otherwise registries on localhost are reached over http and every
+// other registry over https.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		tokens:     make(map[string]string),
+	}
+}
+
+// Resolve returns the digest of the manifest the reference points to (the digest of the
+// reference itself if it is pinned).
+func (c *Client) Resolve(ctx context.Context, reference imageref.Reference) (string, error) {
+	if len(reference.Digest) != 0 {
+		return reference.Digest, nil
+	}
+
+	_, digest, err := c.fetchManifest(ctx, reference, reference.Tag)
+	return digest, err
+}
+
+// Manifest returns the manifest with the given tag or digest in the repository of the reference.
+func (c *Client) Manifest(ctx context.Context, reference imageref.Reference, tagOrDigest string) (Manifest, error) {
+	content, _, err := c.fetchManifest(ctx, reference, tagOrDigest)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	var manifest Manifest
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("unable to parse manifest %s: %s", tagOrDigest, err.Error())
+	}
+
+	return manifest, nil
+}
+
+// Blob returns the blob with the given digest from the repository of the reference after
+// verifying its content against the digest.
+func (c *Client) Blob(ctx context.Context, reference imageref.Reference, digest string) ([]byte, error) {
+	content, _, err := c.get(ctx, reference, "blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifyDigest(content, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (c *Client) fetchManifest(ctx context.Context, reference imageref.Reference, tagOrDigest string) ([]byte, string, error) {
+	content, header, err := c.get(ctx, reference, "manifests/"+tagOrDigest, manifestMediaTypes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := header.Get("Docker-Content-Digest")
+	if strings.HasPrefix(tagOrDigest, "sha256:") {
+		digest = tagOrDigest
+	}
+
+	if len(digest) == 0 {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+	}
+
+	err = VerifyDigest(content, digest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return content, digest, nil
+}
+
+func (c *Client) get(ctx context.Context, reference imageref.Reference, path string, accept []string) ([]byte, http.Header, error) {
+	requestURL := c.registryURL(reference.Registry) + "/v2/" + reference.Repository + "/" + path
+
+	response, err := c.do(ctx, requestURL, reference.Repository, accept)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil, fmt.Errorf("%s/%s: %w", reference.Name(), path, ErrNotFound)
+	default:
+		return nil, nil, fmt.Errorf("%w %s for %s/%s", ErrUnexpectedStatus, response.Status, reference.Name(), path)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxContentSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return content, response.Header, nil
+}
+
+// do sends the request, fetching an anonymous bearer token for the repository if the
+// registry asks for one.
+func (c *Client) do(ctx context.Context, requestURL string, repository string, accept []string) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(accept) != 0 {
+			request.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+
+		if token, exists := c.tokens[repository]; exists {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		return c.httpClient.Do(request)
+	}
+
+	response, err := send()
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		return response, nil
+	}
+
+	challenge := response.Header.Get("WWW-Authenticate")
+	response.Body.Close()
+
+	token, err := c.fetchToken(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	c.tokens[repository] = token
+	return send()
+}
+
+func (c *Client) fetchToken(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("%w: unsupported authentication %q", ErrUnexpectedStatus, challenge)
+	}
+
+	parameters := parseChallenge(params)
+	realm, err := url.Parse(parameters["realm"])
+	if err != nil || len(realm.Host) == 0 {
+		return "", fmt.Errorf("%w: invalid token realm in %q", ErrUnexpectedStatus, challenge)
+	}
+
+	query := realm.Query()
+	for _, name := range []string{"service", "scope"} {
+		if value, exists := parameters[name]; exists {
+			query.Set(name, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w %s for the registry token", ErrUnexpectedStatus, response.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	err = json.NewDecoder(io.LimitReader(response.Body, maxContentSize)).Decode(&token)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the registry token: %s", err.Error())
+	}
+
+	if len(token.Token) == 0 {
+		token.Token = token.AccessToken
+	}
+
+	return token.Token, nil
+}
+
+func (c *Client) registryURL(registry string) string {
+	if len(c.baseURL) != 0 {
+		return c.baseURL
+	}
+
+	if registry == imageref.DefaultRegistry {
+		registry = dockerHubRegistry
+	}
+
+	host := registry
+	if colon := strings.LastIndex(host, ":"); colon != -1 {
+		host = host[:colon]
+	}
+
+	if host == "localhost" || host == "127.0.0.1" {
+		return "http://" + registry
+	}
+
+	return "https://" + registry
+}
+
+// parseChallenge parses the parameters of a WWW-Authenticate header
+// (i.e. realm="https://auth.docker.io/token",service="registry.docker.io").
+func parseChallenge(params string) map[string]string {
+	parameters := make(map[string]string)
+
+	for len(params) != 0 {
+		var name, value string
+		name, params, _ = strings.Cut(strings.TrimLeft(params, " ,"), "=")
+
+		if strings.HasPrefix(params, "\"") {
+			value, params, _ = strings.Cut(params[1:], "\"")
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+
+		if len(name) != 0 {
+			parameters[strings.ToLower(strings.TrimSpace(name))] = value
+		}
+	}
+
+	return parameters
+}
+
+// VerifyDigest checks that the content matches the sha256 digest (i.e. "sha256:abc...").
+func VerifyDigest(content []byte, digest string) error {
+	algorithm, hash, _ := strings.Cut(digest, ":")
+	if algorithm != "sha256" {
+		return fmt.Errorf("%w %q", ErrUnsupportedDigest, digest)
+	}
+
+	if fmt.Sprintf("%x", sha256.Sum256(content)) != strings.ToLower(hash) {
+		return fmt.Errorf("%w %s", ErrDigestMismatch, digest)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package ociregistry
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/imageref"
+)
+
+var (
+	testManifest = `{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json",` +
+		`"config": {"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", "size": 2},` +
+		`"layers": [{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", "size": 5, "annotations": {"name": "hello"}}]}`
+	testManifestDigest = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(testManifest)))
+	testBlob           = "hello"
+	testBlobDigest     = "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+)
+
+// newTestRegistry serves the test manifest and blob of team/app, requiring an anonymous
+// bearer token like Docker Hub does.
+func newTestRegistry(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:team/app:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"token": "test-token"}`)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:team/app:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/team/app/manifests/1.0", "/v2/team/app/manifests/" + testManifestDigest:
+			w.Header().Set("Docker-Content-Digest", testManifestDigest)
+			fmt.Fprint(w, testManifest)
+		case "/v2/team/app/manifests/tampered":
+			w.Header().Set("Docker-Content-Digest", testManifestDigest)
+			fmt.Fprint(w, testManifest+" ")
+		case "/v2/team/app/blobs/" + testBlobDigest:
+			fmt.Fprint(w, testBlob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := newTestRegistry(t)
+	reference := imageref.Reference{Registry: "registry.internal", Repository: "team/app", Tag: "1.0"}
+
+	client := NewClient(server.URL)
+
+	digest, err := client.Resolve(context.Background(), reference)
+	if err != nil || digest != testManifestDigest {
+		t.Errorf("unexpected digest -\nexpected: (%s)\ngot: (%s, %+v)", testManifestDigest, digest, err)
+	}
+
+	manifest, err := client.Manifest(context.Background(), reference, testManifestDigest)
+	expectedLayers := []Descriptor{{
+		MediaType:   "application/vnd.oci.image.layer.v1.tar",
+		Digest:      testBlobDigest,
+		Size:        5,
+		Annotations: map[string]string{"name": "hello"},
+	}}
+	if err != nil || !reflect.DeepEqual(manifest.Layers, expectedLayers) {
+		t.Errorf("unexpected manifest layers -\nexpected: (%+v)\ngot: (%+v, %+v)", expectedLayers, manifest.Layers, err)
+	}
+
+	blob, err := client.Blob(context.Background(), reference, testBlobDigest)
+	if err != nil || string(blob) != testBlob {
+		t.Errorf("unexpected blob -\nexpected: (%s)\ngot: (%s, %+v)", testBlob, blob, err)
+	}
+
+	_, err = client.Manifest(context.Background(), reference, "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("unexpected error for a missing manifest -\nexpected: (%+v)\ngot: (%+v)", ErrNotFound, err)
+	}
+
+	_, err = client.Manifest(context.Background(), reference, "tampered")
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("unexpected error for a tampered manifest -\nexpected: (%+v)\ngot: (%+v)", ErrDigestMismatch, err)
+	}
+
+	_, err = client.Blob(context.Background(), reference, "sha512:abc")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("unexpected error for a missing blob -\nexpected: (%+v)\ngot: (%+v)", ErrNotFound, err)
+	}
+}
+
+func TestRegistryURL(t *testing.T) {
+	registryURLTests := []struct {
+		name     string
+		baseURL  string
+		registry string
+		expected string
+	}{
+		{
+			name:     "docker hub",
+			registry: "docker.io",
+			expected: "https://registry-1.docker.io",
+		},
+		{
+			name:     "private registry",
+			registry: "registry.internal:5000",
+			expected: "https://registry.internal:5000",
+		},
+		{
+			name:     "local registry",
+			registry: "localhost:5000",
+			expected: "http://localhost:5000",
+		},
+		{
+			name:     "base url",
+			baseURL:  "http://127.0.0.1:5000/",
+			registry: "registry.internal",
+			expected: "http://127.0.0.1:5000",
+		},
+	}
+
+	for testNum, test := range registryURLTests {
+		registryURL := NewClient(test.baseURL).registryURL(test.registry)
+		if registryURL != test.expected {
+			t.Errorf("\n%d) unexpected registry url for %s -\nexpected: (%s)\ngot: (%s)", testNum, test.name, test.expected, registryURL)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	parameters := parseChallenge(`realm="https://auth.docker.io/token",service="registry.docker.io", scope=repository:library/alpine:pull`)
+	expected := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/alpine:pull",
+	}
+
+	if !reflect.DeepEqual(parameters, expected) {
+		t.Errorf("unexpected challenge parameters -\nexpected: (%+v)\ngot: (%+v)", expected, parameters)
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	verifyDigestTests := []struct {
+		name    string
+		content string
+		digest  string
+		err     error
+	}{
+		{
+			name:    "matching digest",
+			content: testBlob,
+			digest:  testBlobDigest,
+			err:     nil,
+		},
+		{
+			name:    "mismatching digest",
+			content: testBlob + "!",
+			digest:  testBlobDigest,
+			err:     ErrDigestMismatch,
+		},
+		{
+			name:    "unsupported algorithm",
+			content: testBlob,
+			digest:  "md5:5d41402abc4b2a76b9719d911017c592",
+			err:     ErrUnsupportedDigest,
+		},
+	}
+
+	for testNum, test := range verifyDigestTests {
+		err := VerifyDigest([]byte(test.content), test.digest)
+		if !errors.Is(err, test.err) {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%+v)\ngot: (%+v)", testNum, test.name, test.err, err)
+		}
+	}
+}
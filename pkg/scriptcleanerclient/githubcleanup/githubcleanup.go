@@ -1,6 +1,25 @@
 package githubcleanup
 
-import "strings"
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
+)
+
+// See https://docs.github.com/en/actions/using-workflows/events-that-trigger-workflows
+var githubPipelineSources = map[string]string{
+	"push":                checks.SourcePush,
+	"pull_request":        checks.SourceMergeRequest,
+	"pull_request_target": checks.SourceMergeRequestTarget,
+	"schedule":            checks.SourceSchedule,
+	"workflow_dispatch":   checks.SourceManual,
+	"repository_dispatch": checks.SourceApi,
+	"workflow_call":       checks.SourcePipeline,
+	"workflow_run":        checks.SourcePipeline,
+	"release":             checks.SourceRelease,
+}
 
 type GitHubCleaner struct{}
 
@@ -29,3 +48,51 @@ func (cleaner *GitHubCleaner) CleanupScript(script string) []string {
 	}
 	return scriptLines
 }
+
+func (cleaner *GitHubCleaner) PipelineSource(source string) string {
+	commonSource, exists := githubPipelineSources[strings.ToLower(strings.TrimSpace(source))]
+	if !exists {
+		return checks.SourceOther
+	}
+
+	return commonSource
+}
+
+// githubService is a service of a GitHub job, services given as a plain image are decoded into
+// Image.
+type githubService struct {
+	Image string `json:"image"`
+}
+
+func (service *githubService) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &service.Image); err == nil {
+		return nil
+	}
+
+	type plainService githubService
+	return json.Unmarshal(data, (*plainService)(service))
+}
+
+// Services parses the services block of the job given as JSON (i.e. {"db": {"image":
+// "postgres:15"}}). The images are ordered by service id.
+func (cleaner *GitHubCleaner) Services(services string) []string {
+	var githubServices map[string]githubService
+	if err := json.Unmarshal([]byte(services), &githubServices); err != nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(githubServices))
+	for id := range githubServices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var images []string
+	for _, id := range ids {
+		if image := strings.TrimSpace(githubServices[id].Image); len(image) != 0 {
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
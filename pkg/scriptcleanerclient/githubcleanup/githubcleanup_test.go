@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/scriptcleanerclient/githubcleanup"
 )
 
@@ -70,3 +71,82 @@ func TestCleanup(t *testing.T) {
 		}
 	}
 }
+
+func TestGitHubPipelineSource(t *testing.T) {
+	pipelineSourceTests := []struct {
+		name           string
+		source         string
+		expectedSource string
+	}{
+		{
+			name:           "push event",
+			source:         "push",
+			expectedSource: checks.SourcePush,
+		},
+		{
+			name:           "pull request event",
+			source:         "pull_request",
+			expectedSource: checks.SourceMergeRequest,
+		},
+		{
+			name:           "pull request target event",
+			source:         "pull_request_target",
+			expectedSource: checks.SourceMergeRequestTarget,
+		},
+		{
+			name:           "workflow dispatch event",
+			source:         "workflow_dispatch",
+			expectedSource: checks.SourceManual,
+		},
+		{
+			name:           "unknown event",
+			source:         "issue_comment",
+			expectedSource: checks.SourceOther,
+		},
+	}
+
+	cleaner := githubcleanup.GitHubCleaner{}
+	for testNum, test := range pipelineSourceTests {
+		source := cleaner.PipelineSource(test.source)
+		if source != test.expectedSource {
+			t.Errorf("\n%d) unexpected pipeline source for %s -\nexpected: (%s)\ngot: (%s)", testNum, test.name, test.expectedSource, source)
+		}
+	}
+}
+
+func TestGitHubServices(t *testing.T) {
+	servicesTests := []struct {
+		name           string
+		services       string
+		expectedImages []string
+	}{
+		{
+			name:           "services block",
+			services:       `{"redis": {"image": "redis:7", "ports": ["6379:6379"]}, "db": {"image": "postgres:15", "env": {"POSTGRES_PASSWORD": "test"}}}`,
+			expectedImages: []string{"postgres:15", "redis:7"},
+		},
+		{
+			name:           "service given as an image",
+			services:       `{"redis": "redis:7"}`,
+			expectedImages: []string{"redis:7"},
+		},
+		{
+			name:           "no services block",
+			services:       "null",
+			expectedImages: nil,
+		},
+		{
+			name:           "no services",
+			services:       "",
+			expectedImages: nil,
+		},
+	}
+
+	cleaner := githubcleanup.GitHubCleaner{}
+	for testNum, test := range servicesTests {
+		images := cleaner.Services(test.services)
+		if !reflect.DeepEqual(images, test.expectedImages) {
+			t.Errorf("\n%d) unexpected services for %s -\nexpected: (%#v)\ngot: (%#v)", testNum, test.name, test.expectedImages, images)
+		}
+	}
+}
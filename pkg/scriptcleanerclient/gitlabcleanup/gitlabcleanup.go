@@ -3,8 +3,23 @@ package gitlabcleanup
 import (
 	"regexp"
 	"strings"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
 )
 
+// See https://docs.gitlab.com/ee/ci/jobs/job_control.html#common-if-clauses-for-rules
+var gitlabPipelineSources = map[string]string{
+	"push":                        checks.SourcePush,
+	"merge_request_event":         checks.SourceMergeRequest,
+	"external_pull_request_event": checks.SourceMergeRequest,
+	"schedule":                    checks.SourceSchedule,
+	"web":                         checks.SourceManual,
+	"api":                         checks.SourceApi,
+	"trigger":                     checks.SourceApi,
+	"pipeline":                    checks.SourcePipeline,
+	"parent_pipeline":             checks.SourcePipeline,
+}
+
 type GitLabCleaner struct{}
 
 func (cleaner *GitLabCleaner) CleanupScript(script string) []string {
@@ -21,3 +36,19 @@ func (cleaner *GitLabCleaner) CleanupScript(script string) []string {
 
 	return lines
 }
+
+func (cleaner *GitLabCleaner) PipelineSource(source string) string {
+	commonSource, exists := gitlabPipelineSources[strings.ToLower(strings.TrimSpace(source))]
+	if !exists {
+		return checks.SourceOther
+	}
+
+	return commonSource
+}
+
+// Services returns no services: the custom executor does not start the services of GitLab jobs
+// and GitLab does not pass them to it. CUSTOM_ENV_CI_SERVICES is a variable of the job, which
+// could name any images while the job runs others, so it is not trusted.
+func (cleaner *GitLabCleaner) Services(services string) []string {
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/kudelskisecurity/youshallnotpass/pkg/checks"
 	"github.com/kudelskisecurity/youshallnotpass/pkg/scriptcleanerclient/gitlabcleanup"
 )
 
@@ -64,3 +65,82 @@ func TestGitlabCleanupScript(t *testing.T) {
 		}
 	}
 }
+
+func TestGitlabPipelineSource(t *testing.T) {
+	pipelineSourceTests := []struct {
+		name           string
+		source         string
+		expectedSource string
+	}{
+		{
+			name:           "push pipeline",
+			source:         "push",
+			expectedSource: checks.SourcePush,
+		},
+		{
+			name:           "merge request pipeline",
+			source:         "merge_request_event",
+			expectedSource: checks.SourceMergeRequest,
+		},
+		{
+			name:           "scheduled pipeline",
+			source:         "schedule",
+			expectedSource: checks.SourceSchedule,
+		},
+		{
+			name:           "pipeline run from the ui",
+			source:         "web",
+			expectedSource: checks.SourceManual,
+		},
+		{
+			name:           "triggered pipeline",
+			source:         "trigger",
+			expectedSource: checks.SourceApi,
+		},
+		{
+			name:           "unknown pipeline source",
+			source:         "webide",
+			expectedSource: checks.SourceOther,
+		},
+	}
+
+	cleaner := gitlabcleanup.GitLabCleaner{}
+	for testNum, test := range pipelineSourceTests {
+		source := cleaner.PipelineSource(test.source)
+		if source != test.expectedSource {
+			t.Errorf("\n%d) unexpected pipeline source for %s -\nexpected: (%s)\ngot: (%s)", testNum, test.name, test.expectedSource, source)
+		}
+	}
+}
+
+func TestGitlabServices(t *testing.T) {
+	servicesTests := []struct {
+		name           string
+		services       string
+		expectedImages []string
+	}{
+		{
+			name:           "services set by the job are not trusted",
+			services:       `[{"name": "postgres:15", "alias": "db", "entrypoint": null, "command": null}, {"name": "docker:24-dind"}]`,
+			expectedImages: nil,
+		},
+		{
+			name:           "comma separated services set by the job are not trusted",
+			services:       "redis:7, postgres:15",
+			expectedImages: nil,
+		},
+		{
+			name:           "no services",
+			services:       "",
+			expectedImages: nil,
+		},
+	}
+
+	cleaner := gitlabcleanup.GitLabCleaner{}
+	for testNum, test := range servicesTests {
+		images := cleaner.Services(test.services)
+		if !reflect.DeepEqual(images, test.expectedImages) {
+			t.Errorf("\n%d) unexpected services for %s -\nexpected: (%#v)\ngot: (%#v)", testNum, test.name, test.expectedImages, images)
+		}
+	}
+}
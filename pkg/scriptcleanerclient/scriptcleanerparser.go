@@ -14,6 +14,13 @@ var (
 
 type ScriptCleaner interface {
 	CleanupScript(script string) []string
+	// PipelineSource maps the platform's pipeline source (i.e. GitLab CI_PIPELINE_SOURCE or
+	// GitHub GITHUB_EVENT_NAME) to the common source vocabulary (checks.SourcePush, ...).
+	PipelineSource(source string) string
+	// Services returns the images of the job's service containers as given by the executor
+	// (i.e. the services block of a GitHub job), platforms whose services cannot be trusted
+	// report none.
+	Services(services string) []string
 }
 
 func ParseCleaner(platform string) (ScriptCleaner, error) {
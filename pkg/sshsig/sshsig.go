@@ -0,0 +1,126 @@
+package sshsig
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid ssh signature")
+	ErrBadSignature     = errors.New("ssh signature does not match the message")
+	PemType             = "SSH SIGNATURE"
+	magic               = []byte("SSHSIG")
+	version             = uint32(1)
+)
+
+// signature is the SSHSIG blob (see PROTOCOL.sshsig in the OpenSSH sources) following the
+// SSHSIG magic.
+type signature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// signedData is the data actually signed by the key following the SSHSIG magic.
+type signedData struct {
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// Verify verifies an armored SSH signature made over the message in the namespace (as created
+// by ssh-keygen -Y sign -n namespace) and returns the public key which made it. Whether the key
+// is trusted is up to the caller.
+func Verify(message []byte, armored []byte, namespace string) (ssh.PublicKey, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(armored))
+	if block == nil || block.Type != PemType || !bytes.HasPrefix(block.Bytes, magic) {
+		return nil, ErrInvalidSignature
+	}
+
+	var sig signature
+	if err := ssh.Unmarshal(block.Bytes[len(magic):], &sig); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error())
+	}
+
+	if sig.Version != version || sig.Namespace != namespace {
+		return nil, fmt.Errorf("%w: unsupported version %d or namespace %q", ErrInvalidSignature, sig.Version, sig.Namespace)
+	}
+
+	publicKey, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error())
+	}
+
+	h, err := newHash(sig.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(message)
+
+	var sshSignature ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &sshSignature); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error())
+	}
+
+	data := append([]byte{}, magic...)
+	data = append(data, ssh.Marshal(signedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+
+	if err := publicKey.Verify(data, &sshSignature); err != nil {
+		return publicKey, fmt.Errorf("%w: %s", ErrBadSignature, err.Error())
+	}
+
+	return publicKey, nil
+}
+
+// Sign creates an armored SSH signature over the message in the namespace with a sha512 hash,
+// the same as ssh-keygen -Y sign -n namespace.
+func Sign(signer ssh.Signer, message []byte, namespace string) ([]byte, error) {
+	h := sha512.Sum512(message)
+
+	data := append([]byte{}, magic...)
+	data = append(data, ssh.Marshal(signedData{Namespace: namespace, HashAlgorithm: "sha512", Hash: h[:]})...)
+
+	sshSignature, err := signer.Sign(rand.Reader, data)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := append([]byte{}, magic...)
+	blob = append(blob, ssh.Marshal(signature{
+		Version:       version,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(sshSignature),
+	})...)
+
+	return pem.EncodeToMemory(&pem.Block{Type: PemType, Bytes: blob}), nil
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported hash algorithm %q", ErrInvalidSignature, algorithm)
+	}
+}
@@ -0,0 +1,93 @@
+package sshsig
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newSigner(t *testing.T) ssh.Signer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("unable to create ssh signer: %s", err.Error())
+	}
+
+	return signer
+}
+
+func sign(t *testing.T, signer ssh.Signer, message string, namespace string) []byte {
+	signature, err := Sign(signer, []byte(message), namespace)
+	if err != nil {
+		t.Fatalf("unable to sign: %s", err.Error())
+	}
+
+	return signature
+}
+
+func TestVerify(t *testing.T) {
+	signer := newSigner(t)
+
+	var verifyTests = []struct {
+		name          string
+		message       string
+		armored       []byte
+		namespace     string
+		expectedError error
+	}{
+		{
+			name:      "valid signature",
+			message:   "message",
+			armored:   sign(t, signer, "message", "test"),
+			namespace: "test",
+		},
+		{
+			name:          "signature of another message",
+			message:       "other message",
+			armored:       sign(t, signer, "message", "test"),
+			namespace:     "test",
+			expectedError: ErrBadSignature,
+		},
+		{
+			name:          "signature in another namespace",
+			message:       "message",
+			armored:       sign(t, signer, "message", "git"),
+			namespace:     "test",
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "not an ssh signature",
+			message:       "message",
+			armored:       []byte("-----BEGIN PGP SIGNATURE-----\n\n-----END PGP SIGNATURE-----\n"),
+			namespace:     "test",
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "malformed signature",
+			message:       "message",
+			armored:       []byte("not a signature"),
+			namespace:     "test",
+			expectedError: ErrInvalidSignature,
+		},
+	}
+
+	for testNum, test := range verifyTests {
+		publicKey, err := Verify([]byte(test.message), test.armored, test.namespace)
+		if (test.expectedError == nil && err != nil) || !errors.Is(err, test.expectedError) {
+			t.Errorf("\n%d) Errors not equal for %s -\nexpected: (%v)\ngot: (%v)", testNum, test.name, test.expectedError, err)
+			continue
+		}
+
+		if test.expectedError == nil && !bytes.Equal(publicKey.Marshal(), signer.PublicKey().Marshal()) {
+			t.Errorf("\n%d) Public keys not equal for %s -\nexpected: (%s)\ngot: (%s)", testNum, test.name, ssh.FingerprintSHA256(signer.PublicKey()), ssh.FingerprintSHA256(publicKey))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package hashicorpclient
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -81,7 +82,7 @@ func InitVaultClient(context *cli.Context) (*HashicorpService, error) {
 }
 
 func (s *HashicorpService) GetNamespaceConfig(namespaceConfigMount string) (config.NamespaceConfig, error) {
-	vaultRes, err := s.getConfig(namespaceConfigMount)
+	vaultRes, err := s.getConfig(context.Background(), namespaceConfigMount)
 	if err != nil {
 		return config.DefaultNamespaceConfig, fmt.Errorf("unable to access namespace vault config at %s", namespaceConfigMount)
 	}
@@ -90,7 +91,7 @@ func (s *HashicorpService) GetNamespaceConfig(namespaceConfigMount string) (conf
 }
 
 func (s *HashicorpService) GetProjectConfig(projectConfigMount string) (config.ProjectConfig, error) {
-	vaultRes, err := s.getConfig(projectConfigMount)
+	vaultRes, err := s.getConfig(context.Background(), projectConfigMount)
 	if err != nil {
 		return config.DefaultProjectConfig, fmt.Errorf("unable to access project vault config at %s", projectConfigMount)
 	}
@@ -98,10 +99,10 @@ func (s *HashicorpService) GetProjectConfig(projectConfigMount string) (config.P
 	return config.ParseProjectConfig(vaultRes)
 }
 
-func (s *HashicorpService) getConfig(mount string) ([]byte, error) {
+func (s *HashicorpService) getConfig(ctx context.Context, mount string) ([]byte, error) {
 	s.client.SetToken(s.vaultToken)
 
-	secret, err := s.client.Logical().Read(mount)
+	secret, err := s.client.Logical().ReadWithContext(ctx, mount)
 	var vaultRes []byte
 
 	if err != nil {
@@ -121,15 +122,18 @@ func (s *HashicorpService) getConfig(mount string) ([]byte, error) {
 	return vaultRes, nil
 }
 
-func (s *HashicorpService) ReadWhitelists(namespaceMount string, projectMount string) (whitelist.Whitelist, error) {
+// ReadWhitelists reads the namespace and project whitelists. If trusted keys are given, both
+// whitelists must be signed by one of them for their mount with a version no older than the
+// one kept in the version store (see whitelist.VerifySignature).
+func (s *HashicorpService) ReadWhitelists(namespaceMount string, projectMount string, trustedKeys []string, versions whitelist.VersionStore) (whitelist.Whitelist, error) {
 	s.client.SetToken(s.vaultToken)
 
-	namespaceWhitelist, err := s.readWhitelist(namespaceMount)
+	namespaceWhitelist, err := s.readWhitelist(namespaceMount, trustedKeys, versions)
 	if err != nil {
 		return namespaceWhitelist, fmt.Errorf("unable to read namespace whitelist at %s: %s", namespaceMount, err.Error())
 	}
 
-	projectWhitelist, err := s.readWhitelist(projectMount)
+	projectWhitelist, err := s.readWhitelist(projectMount, trustedKeys, versions)
 	if err != nil {
 		return projectWhitelist, fmt.Errorf("unable to read project whitelist at %s: %s", projectMount, err.Error())
 	}
@@ -139,29 +143,46 @@ func (s *HashicorpService) ReadWhitelists(namespaceMount string, projectMount st
 	return namespaceWhitelist, nil
 }
 
+// Read the JSON encoded data of a secret (nil if the secret does not exist)
+func (s *HashicorpService) ReadSecret(ctx context.Context, mount string) ([]byte, error) {
+	vaultRes, err := s.getConfig(ctx, mount)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret at %s: %s", mount, err.Error())
+	}
+
+	return vaultRes, nil
+}
+
 // Read the allowed images and scripts from a whitelist mount location
-func (s *HashicorpService) readWhitelist(secretPath string) (whitelist.Whitelist, error) {
+func (s *HashicorpService) readWhitelist(secretPath string, trustedKeys []string, versions whitelist.VersionStore) (whitelist.Whitelist, error) {
 	s.client.SetToken(s.vaultToken)
 
 	secret, err := s.client.Logical().Read(secretPath)
-	whitelist := whitelist.Whitelist{}
+	wl := whitelist.Whitelist{}
 
 	if err != nil {
-		return whitelist, err
+		return wl, err
 	}
 
 	if secret == nil {
-		return whitelist, fmt.Errorf("invalid secret")
+		return wl, fmt.Errorf("invalid secret")
 	}
 
 	vaultRes, _ := json.Marshal(secret.Data)
-	err = json.Unmarshal(vaultRes, &whitelist)
+	if len(trustedKeys) != 0 {
+		err = whitelist.VerifySignature(vaultRes, secretPath, trustedKeys, versions)
+		if err != nil {
+			return wl, err
+		}
+	}
+
+	err = json.Unmarshal(vaultRes, &wl)
 
 	if err != nil {
-		return whitelist, err
+		return wl, err
 	}
 
-	return whitelist, nil
+	return wl, nil
 }
 
 // Write scratch code and make sure it exists after writing
@@ -193,9 +214,13 @@ func (s *HashicorpService) WriteScratch(pipelineId int, user string) (string, er
 }
 
 // Print to the console the instructions for deleting the scratch code
-func (s *HashicorpService) LogMFAInstructions(ciUserEmail string, loggerClient loggerclient.LoggerClient) {
+// together with the changes (unified diffs) which are to be authorized
+func (s *HashicorpService) LogMFAInstructions(ciUserEmail string, diffs []string, loggerClient loggerclient.LoggerClient) {
 	message := fmt.Sprintf("\nPlease delete the following scratch code to authorize this pipeline run -> %s/ui/vault/secrets/%s\n",
 		s.vaultExternalAddr, s.vaultRoot+"/list/"+s.ciProjectPath+"/scratch/"+ciUserEmail)
+	for _, diff := range diffs {
+		message += "\nChanges since the last approved version:\n```diff\n" + diff + "```\n"
+	}
 	_ = loggerClient.SendMFAInstructions(message)
 }
 
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/kudelskisecurity/youshallnotpass/pkg/config"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/whitelist"
 	"github.com/urfave/cli/v2"
 )
 
@@ -266,7 +267,7 @@ func TestVaultClientReadWhitelist(t *testing.T) {
 		"cicd/youshallnotpass/demo/whitelist",
 	}
 
-	whitelist, err := s.ReadWhitelists(whitelistMounts[0], whitelistMounts[1])
+	whitelist, err := s.ReadWhitelists(whitelistMounts[0], whitelistMounts[1], nil, whitelist.NewVersionStore(""))
 	if err != nil {
 		t.Errorf("Expected no error when reading the whitelist mounts, but got %s", err.Error())
 		return
@@ -1,6 +1,7 @@
 package vaultclient
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
@@ -22,9 +23,10 @@ var (
 type VaultClient interface {
 	GetNamespaceConfig(string) (config.NamespaceConfig, error)
 	GetProjectConfig(string) (config.ProjectConfig, error)
-	ReadWhitelists(string, string) (whitelist.Whitelist, error)
+	ReadWhitelists(string, string, []string, whitelist.VersionStore) (whitelist.Whitelist, error)
+	ReadSecret(context.Context, string) ([]byte, error)
 	WriteScratch(int, string) (string, error)
-	LogMFAInstructions(string, loggerclient.LoggerClient)
+	LogMFAInstructions(string, []string, loggerclient.LoggerClient)
 	WaitForMFA(int, string) bool
 	Cleanup(bool, string, string) error
 }
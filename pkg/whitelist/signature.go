@@ -0,0 +1,189 @@
+package whitelist
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/sshsig"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	ErrMissingSignature   = errors.New("whitelist is not signed")
+	ErrInvalidSignature   = errors.New("invalid whitelist signature")
+	ErrBadSignature       = errors.New("whitelist signature not made by a trusted key")
+	ErrInvalidTrustedKey  = errors.New("invalid trusted whitelist key")
+	ErrWrongMount         = errors.New("whitelist signed for another mount")
+	ErrInvalidVersion     = errors.New("whitelist signed without a positive version")
+	SignatureField        = "signature"
+	MountField            = "mount"
+	VersionField          = "version"
+	SshSignatureNamespace = "youshallnotpass-whitelist"
+)
+
+// VerifySignature verifies the detached signature stored in the signature field of a whitelist
+// document (the JSON of the whitelist secret) against the trusted keys. The signature is made
+// over the canonical document (see CanonicalDocument) either with an Ed25519 key (the base64
+// encoded signature) or with ssh-keygen -Y sign -n youshallnotpass-whitelist (the armored SSH
+// signature). The trusted keys are given in the authorized_keys format (i.e. "ssh-ed25519
+// AAAA... alice") or as base64 encoded Ed25519 public keys.
+//
+// The signed document must also name the mount it is read from in its mount field and carry a
+// positive version in its version field, so that a signed whitelist can neither be copied to
+// another mount nor replaced by an older one (see VersionStore).
+func VerifySignature(document []byte, mount string, trustedKeys []string, versions VersionStore) error {
+	keys, err := parseTrustedKeys(trustedKeys)
+	if err != nil {
+		return err
+	}
+
+	canonical, signature, err := CanonicalDocument(document)
+	if err != nil {
+		return err
+	}
+
+	signature = strings.TrimSpace(signature)
+	if len(signature) == 0 {
+		return ErrMissingSignature
+	}
+
+	if strings.HasPrefix(signature, "-----BEGIN") {
+		err = verifySsh(canonical, []byte(signature), keys)
+	} else {
+		err = verifyEd25519(canonical, signature, keys)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	version, err := verifyBinding(canonical, mount)
+	if err != nil {
+		return err
+	}
+
+	return versions.Check(mount, version)
+}
+
+// CanonicalDocument returns the canonical form of the whitelist document the signature is made
+// over and the signature stored in the document. The canonical form is the document without
+// its signature field encoded as compact JSON with sorted keys, the same as:
+//
+//	jq -cjS 'del(.signature)' whitelist.json
+func CanonicalDocument(document []byte) ([]byte, string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error())
+	}
+
+	signature, _ := fields[SignatureField].(string)
+	delete(fields, SignatureField)
+
+	var canonical bytes.Buffer
+	encoder := json.NewEncoder(&canonical)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(fields); err != nil {
+		return nil, "", err
+	}
+
+	return bytes.TrimSuffix(canonical.Bytes(), []byte("\n")), signature, nil
+}
+
+// verifyBinding checks that the canonical document was signed for the mount and returns its
+// version.
+func verifyBinding(canonical []byte, mount string) (uint64, error) {
+	decoder := json.NewDecoder(bytes.NewReader(canonical))
+	decoder.UseNumber()
+
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error())
+	}
+
+	signedMount, _ := fields[MountField].(string)
+	if signedMount != mount {
+		return 0, fmt.Errorf("%w: %q instead of %q", ErrWrongMount, signedMount, mount)
+	}
+
+	number, _ := fields[VersionField].(json.Number)
+	version, err := strconv.ParseUint(number.String(), 10, 64)
+	if err != nil || version == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidVersion, number.String())
+	}
+
+	return version, nil
+}
+
+func parseTrustedKeys(trustedKeys []string) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+	for _, trustedKey := range trustedKeys {
+		trustedKey = strings.TrimSpace(trustedKey)
+		if key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(trustedKey)); err == nil {
+			keys = append(keys, key)
+			continue
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(trustedKey)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTrustedKey, trustedKey)
+		}
+
+		key, err := ssh.NewPublicKey(ed25519.PublicKey(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTrustedKey, err.Error())
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func verifyEd25519(canonical []byte, signature string, keys []ssh.PublicKey) error {
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(raw) != ed25519.SignatureSize {
+		return ErrInvalidSignature
+	}
+
+	for _, key := range keys {
+		cryptoKey, ok := key.(ssh.CryptoPublicKey)
+		if !ok {
+			continue
+		}
+
+		publicKey, ok := cryptoKey.CryptoPublicKey().(ed25519.PublicKey)
+		if ok && ed25519.Verify(publicKey, canonical, raw) {
+			return nil
+		}
+	}
+
+	return ErrBadSignature
+}
+
+// verifySsh verifies an armored SSH signature (as created by ssh-keygen -Y sign -n
+// youshallnotpass-whitelist) made by one of the keys over the canonical document.
+func verifySsh(canonical []byte, armored []byte, keys []ssh.PublicKey) error {
+	publicKey, err := sshsig.Verify(canonical, armored, SshSignatureNamespace)
+	if errors.Is(err, sshsig.ErrInvalidSignature) {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error())
+	} else if err != nil {
+		return fmt.Errorf("%w: %s", ErrBadSignature, err.Error())
+	}
+
+	for _, key := range keys {
+		if bytes.Equal(key.Marshal(), publicKey.Marshal()) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrBadSignature, ssh.FingerprintSHA256(publicKey))
+}
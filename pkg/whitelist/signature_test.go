@@ -0,0 +1,204 @@
+package whitelist
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/sshsig"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	testMount         = "cicd/group/project/whitelist"
+	unsignedWhitelist = `{"mount": "cicd/group/project/whitelist", "version": 2, "allowed_scripts": [{"value": "testJob@sha256:1234", "approvedBy": "alice", "reason": "a<b"}], "allowed_images": ["alpine@sha256:5678"]}`
+)
+
+func newKey(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+
+	return privateKey
+}
+
+func authorizedKey(t *testing.T, privateKey ed25519.PrivateKey) string {
+	publicKey, err := ssh.NewPublicKey(privateKey.Public())
+	if err != nil {
+		t.Fatalf("unable to create ssh public key: %s", err.Error())
+	}
+
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))) + " alice@example.com"
+}
+
+func canonical(t *testing.T, document string) []byte {
+	canonical, _, err := CanonicalDocument([]byte(document))
+	if err != nil {
+		t.Fatalf("unable to canonicalize whitelist: %s", err.Error())
+	}
+
+	return canonical
+}
+
+// signed adds the signature to the whitelist document.
+func signed(t *testing.T, document string, signature string) []byte {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &fields); err != nil {
+		t.Fatalf("unable to parse whitelist: %s", err.Error())
+	}
+
+	fields[SignatureField] = signature
+	signedDocument, _ := json.Marshal(fields)
+
+	return signedDocument
+}
+
+func ed25519Sign(t *testing.T, privateKey ed25519.PrivateKey, document string) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, canonical(t, document)))
+}
+
+// sshSign creates an armored SSHSIG signature as created by ssh-keygen -Y sign -n namespace.
+func sshSign(t *testing.T, privateKey ed25519.PrivateKey, namespace string, document string) string {
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("unable to create ssh signer: %s", err.Error())
+	}
+
+	signature, err := sshsig.Sign(signer, canonical(t, document), namespace)
+	if err != nil {
+		t.Fatalf("unable to sign: %s", err.Error())
+	}
+
+	return string(signature)
+}
+
+func TestCanonicalDocument(t *testing.T) {
+	document := `{"b": [2, 1.50], "signature": "c2ln", "a": {"d": "<x>", "c": null}}`
+
+	canonical, signature, err := CanonicalDocument([]byte(document))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expectedCanonical := `{"a":{"c":null,"d":"<x>"},"b":[2,1.50]}`
+	if string(canonical) != expectedCanonical || signature != "c2ln" {
+		t.Errorf("canonical document not equal -\nexpected: (%s, %s)\ngot: (%s, %s)", expectedCanonical, "c2ln", canonical, signature)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	trusted := newKey(t)
+	untrusted := newKey(t)
+	rawTrustedKey := base64.StdEncoding.EncodeToString(trusted.Public().(ed25519.PublicKey))
+	tampered := strings.Replace(unsignedWhitelist, "sha256:1234", "sha256:4321", 1)
+	otherMount := strings.Replace(unsignedWhitelist, "group/project", "group/other", 1)
+	unversioned := strings.Replace(unsignedWhitelist, `"version": 2, `, "", 1)
+	olderVersion := strings.Replace(unsignedWhitelist, `"version": 2`, `"version": 1`, 1)
+
+	// The version store has already verified the second version of the whitelist
+	versions := NewVersionStore(t.TempDir())
+	if err := versions.Check(testMount, 2); err != nil {
+		t.Fatalf("unable to store the whitelist version: %s", err.Error())
+	}
+
+	verifySignatureTests := []struct {
+		name          string
+		document      []byte
+		trustedKeys   []string
+		expectedError error
+	}{
+		{
+			name:        "ed25519 signature with an authorized key",
+			document:    signed(t, unsignedWhitelist, ed25519Sign(t, trusted, unsignedWhitelist)),
+			trustedKeys: []string{authorizedKey(t, untrusted), authorizedKey(t, trusted)},
+		},
+		{
+			name:        "ed25519 signature with a raw key",
+			document:    signed(t, unsignedWhitelist, ed25519Sign(t, trusted, unsignedWhitelist)),
+			trustedKeys: []string{rawTrustedKey},
+		},
+		{
+			name:        "ssh signature",
+			document:    signed(t, unsignedWhitelist, sshSign(t, trusted, SshSignatureNamespace, unsignedWhitelist)),
+			trustedKeys: []string{authorizedKey(t, trusted)},
+		},
+		{
+			name:          "missing signature",
+			document:      []byte(unsignedWhitelist),
+			trustedKeys:   []string{rawTrustedKey},
+			expectedError: ErrMissingSignature,
+		},
+		{
+			name:          "ed25519 signature of another whitelist",
+			document:      signed(t, tampered, ed25519Sign(t, trusted, unsignedWhitelist)),
+			trustedKeys:   []string{rawTrustedKey},
+			expectedError: ErrBadSignature,
+		},
+		{
+			name:          "ed25519 signature of an untrusted key",
+			document:      signed(t, unsignedWhitelist, ed25519Sign(t, untrusted, unsignedWhitelist)),
+			trustedKeys:   []string{rawTrustedKey},
+			expectedError: ErrBadSignature,
+		},
+		{
+			name:          "ssh signature of another whitelist",
+			document:      signed(t, tampered, sshSign(t, trusted, SshSignatureNamespace, unsignedWhitelist)),
+			trustedKeys:   []string{authorizedKey(t, trusted)},
+			expectedError: ErrBadSignature,
+		},
+		{
+			name:          "ssh signature of an untrusted key",
+			document:      signed(t, unsignedWhitelist, sshSign(t, untrusted, SshSignatureNamespace, unsignedWhitelist)),
+			trustedKeys:   []string{authorizedKey(t, trusted)},
+			expectedError: ErrBadSignature,
+		},
+		{
+			name:          "ssh signature for git commits",
+			document:      signed(t, unsignedWhitelist, sshSign(t, trusted, "git", unsignedWhitelist)),
+			trustedKeys:   []string{authorizedKey(t, trusted)},
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "malformed signature",
+			document:      signed(t, unsignedWhitelist, "not a signature"),
+			trustedKeys:   []string{rawTrustedKey},
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "signature for another mount",
+			document:      signed(t, otherMount, ed25519Sign(t, trusted, otherMount)),
+			trustedKeys:   []string{rawTrustedKey},
+			expectedError: ErrWrongMount,
+		},
+		{
+			name:          "signature without a version",
+			document:      signed(t, unversioned, ed25519Sign(t, trusted, unversioned)),
+			trustedKeys:   []string{rawTrustedKey},
+			expectedError: ErrInvalidVersion,
+		},
+		{
+			name:          "signature of an older version",
+			document:      signed(t, olderVersion, ed25519Sign(t, trusted, olderVersion)),
+			trustedKeys:   []string{rawTrustedKey},
+			expectedError: ErrVersionRollback,
+		},
+		{
+			name:          "invalid trusted key",
+			document:      signed(t, unsignedWhitelist, ed25519Sign(t, trusted, unsignedWhitelist)),
+			trustedKeys:   []string{"bm90IGEga2V5"},
+			expectedError: ErrInvalidTrustedKey,
+		},
+	}
+
+	for testNum, test := range verifySignatureTests {
+		err := VerifySignature(test.document, testMount, test.trustedKeys, versions)
+		if (test.expectedError == nil && err != nil) || !errors.Is(err, test.expectedError) {
+			t.Errorf("\n%d) Errors not equal for %s -\nexpected: (%v)\ngot: (%v)", testNum, test.name, test.expectedError, err)
+		}
+	}
+}
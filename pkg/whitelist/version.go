@@ -0,0 +1,81 @@
+package whitelist
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var (
+	ErrVersionRollback = errors.New("whitelist version older than the last verified version")
+	versionLockFile    = ".lock"
+)
+
+// VersionStore keeps the highest version of the signed whitelist of every mount verified on the
+// runner in a directory (one file per mount) so that an older signed whitelist cannot be put
+// back in the vault. No versions are kept if the directory is empty.
+type VersionStore struct {
+	dir string
+}
+
+func NewVersionStore(dir string) VersionStore {
+	return VersionStore{dir: dir}
+}
+
+// Check returns ErrVersionRollback if a higher version of the whitelist of the mount was already
+// verified, otherwise the version becomes the latest version of the mount. Concurrent jobs are
+// serialized with a lock on the directory.
+func (store VersionStore) Check(mount string, version uint64) error {
+	if len(store.dir) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(store.dir, 0700); err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(filepath.Join(store.dir, versionLockFile), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+
+	path := filepath.Join(store.dir, url.PathEscape(mount))
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err == nil {
+		latest, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid whitelist version in %s: %s", path, err.Error())
+		}
+
+		if version < latest {
+			return fmt.Errorf("%w: %d < %d", ErrVersionRollback, version, latest)
+		}
+
+		if version == latest {
+			return nil
+		}
+	}
+
+	// Replace the file at once so that a failed write does not lose the latest version
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, []byte(strconv.FormatUint(version, 10)), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(temp, path)
+}
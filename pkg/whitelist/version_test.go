@@ -0,0 +1,65 @@
+package whitelist
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVersionStoreCheck(t *testing.T) {
+	versions := NewVersionStore(t.TempDir())
+
+	var checkTests = []struct {
+		name          string
+		mount         string
+		version       uint64
+		expectedError error
+	}{
+		{
+			name:    "first version",
+			mount:   "cicd/group/whitelist",
+			version: 2,
+		},
+		{
+			name:    "same version",
+			mount:   "cicd/group/whitelist",
+			version: 2,
+		},
+		{
+			name:          "older version",
+			mount:         "cicd/group/whitelist",
+			version:       1,
+			expectedError: ErrVersionRollback,
+		},
+		{
+			name:    "older version of another mount",
+			mount:   "cicd/group/project/whitelist",
+			version: 1,
+		},
+		{
+			name:    "newer version",
+			mount:   "cicd/group/whitelist",
+			version: 3,
+		},
+		{
+			name:          "version older than the newer version",
+			mount:         "cicd/group/whitelist",
+			version:       2,
+			expectedError: ErrVersionRollback,
+		},
+	}
+
+	for testNum, test := range checkTests {
+		err := versions.Check(test.mount, test.version)
+		if (test.expectedError == nil && err != nil) || !errors.Is(err, test.expectedError) {
+			t.Errorf("\n%d) Errors not equal for %s -\nexpected: (%v)\ngot: (%v)", testNum, test.name, test.expectedError, err)
+		}
+	}
+
+	// Without a directory no versions are kept
+	noVersions := NewVersionStore("")
+	for _, version := range []uint64{2, 1} {
+		if err := noVersions.Check("cicd/group/whitelist", version); err != nil {
+			t.Errorf("unexpected error without a version directory: %s", err.Error())
+		}
+	}
+}
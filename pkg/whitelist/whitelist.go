@@ -1,68 +1,371 @@
 package whitelist
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"strings"
+	"time"
+
+	"github.com/kudelskisecurity/youshallnotpass/pkg/glob"
+	"github.com/kudelskisecurity/youshallnotpass/pkg/imageref"
 )
 
 var (
 	ErrShaNotPresent       = errors.New("@sha256 not provided in image name")
 	ErrShaNotValidSha      = errors.New("@Sha 256 not valid sha256. Expected: name:tag@sha256:<sha>")
 	ErrInvalidWhitelistJob = errors.New("invalid script name for whitelist script. Expected: <jobName>@sha256:<sha>")
+	ErrNoScriptSource      = errors.New("no approved script source stored for script")
+	ErrEntryNoValue        = errors.New("whitelist entry without value or repository")
+	ErrInvalidEntryDate    = errors.New("invalid date in the whitelist entry")
+	ErrEntryNotApproved    = errors.New("whitelist entry allowing any digest without approver")
 )
 
 type Whitelist struct {
-	AllowedImages  []string `json:"allowed_images"`
-	AllowedScripts []string `json:"allowed_scripts"`
+	AllowedImages  []Entry `json:"allowed_images"`
+	AllowedScripts []Entry `json:"allowed_scripts"`
+	// ScriptSources maps an allowed script (<jobName>@sha256:<sha>) to its plaintext
+	// (one line per script line) gzip compressed and base64 encoded.
+	ScriptSources map[string]string `json:"script_sources,omitempty"`
+}
+
+// Entry is an allowed image or script. In the whitelist it is either given as its value (i.e.
+// "alpine:3.18@sha256:<sha>") or as an object recording who approved it, why and until when:
+//
+//	{"value": "alpine:3.18@sha256:<sha>", "approvedBy": "alice", "reason": "base image",
+//	 "createdAt": "2024-01-15", "expiresAt": "2024-07-15T00:00:00Z", "jobs": ["build"]}
+//
+// Dates are given as RFC 3339 timestamps or as days (midnight UTC).
+//
+// An image entry with a repository only matches images of the repositories matching its glob
+// pattern (i.e. "registry.internal/base/*", see glob.Match), against the normalized name of
+// the image (i.e. alpine -> docker.io/library/alpine), its value may then be the digest on its
+// own (i.e. "sha256:<sha>"). If it has no value, it allows any digest of the repositories and
+// must then be approved by someone (approvedBy), otherwise it is invalid.
+type Entry struct {
+	Value      string    `json:"value,omitempty"`
+	Repository string    `json:"repository,omitempty"`
+	ApprovedBy string    `json:"approvedBy,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	// Jobs restricts the entry to the given jobs, it is valid for every job if empty.
+	Jobs []string `json:"jobs,omitempty"`
+	// err is set if the entry is invalid (i.e. its dates could not be read), it is then expired.
+	err error
+}
+
+// now is the clock entries expire by.
+var now = time.Now
+
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &e.Value); err == nil {
+		return nil
+	}
+
+	var entry struct {
+		Value      string   `json:"value"`
+		Repository string   `json:"repository"`
+		ApprovedBy string   `json:"approvedBy"`
+		Reason     string   `json:"reason"`
+		CreatedAt  string   `json:"createdAt"`
+		ExpiresAt  string   `json:"expiresAt"`
+		Jobs       []string `json:"jobs"`
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+
+	if len(entry.Value) == 0 && len(entry.Repository) == 0 {
+		return ErrEntryNoValue
+	}
+
+	createdAt, createdErr := parseDate(entry.CreatedAt)
+	expiresAt, expiresErr := parseDate(entry.ExpiresAt)
+
+	*e = Entry{
+		Value:      entry.Value,
+		Repository: entry.Repository,
+		ApprovedBy: entry.ApprovedBy,
+		Reason:     entry.Reason,
+		CreatedAt:  createdAt,
+		ExpiresAt:  expiresAt,
+		Jobs:       entry.Jobs,
+	}
+
+	name := entry.Value
+	if len(name) == 0 {
+		name = entry.Repository
+	}
+
+	// an invalid entry is kept as expired instead of failing the whole whitelist
+	if len(entry.Value) == 0 && len(entry.ApprovedBy) == 0 {
+		e.err = fmt.Errorf("%w: %s", ErrEntryNotApproved, name)
+	} else if err := errors.Join(createdErr, expiresErr); err != nil {
+		e.err = fmt.Errorf("%w of %s: %w", ErrInvalidEntryDate, name, err)
+	}
+
+	return nil
+}
+
+func parseDate(date string) (time.Time, error) {
+	if len(date) == 0 {
+		return time.Time{}, nil
+	}
+
+	if day, err := time.Parse(time.DateOnly, date); err == nil {
+		return day, nil
+	}
+
+	return time.Parse(time.RFC3339, date)
+}
+
+// Expired returns whether the entry has an expiry date which has passed or is invalid (see Err).
+func (e Entry) Expired() bool {
+	return e.err != nil || (!e.ExpiresAt.IsZero() && !now().Before(e.ExpiresAt))
+}
+
+// Err returns why the entry is invalid (i.e. its dates could not be read) or nil.
+func (e Entry) Err() error {
+	return e.err
+}
+
+// IsValidFor returns whether the entry has not expired and is not restricted to other jobs.
+func (e Entry) IsValidFor(jobName string) bool {
+	if e.Expired() {
+		return false
+	}
+
+	if len(e.Jobs) == 0 {
+		return true
+	}
+
+	for _, job := range e.Jobs {
+		if job == jobName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Approval describes who approved the entry, why and until when (i.e. "approved by alice:
+// base image, expires on 2024-07-15"). It is empty for entries given as their value only.
+func (e Entry) Approval() string {
+	var approval string
+	if len(e.ApprovedBy) != 0 {
+		approval = "approved by " + e.ApprovedBy
+	}
+
+	if len(e.Reason) != 0 && len(approval) != 0 {
+		approval += ": " + e.Reason
+	} else if len(e.Reason) != 0 {
+		approval = e.Reason
+	}
+
+	if !e.ExpiresAt.IsZero() && len(approval) != 0 {
+		approval += ", expires on " + e.ExpiresAt.Format(time.DateOnly)
+	}
+
+	return approval
 }
 
 func (s Whitelist) ContainsImage(image string) (bool, error) {
+	_, found, err := s.Image(image, "", false)
+	return found, err
+}
+
+// Image returns the entry allowing the image for the job. Expired entries and entries
+// restricted to other jobs are left out. Entries match on the digest of the image and, if they
+// have a repository or in strict mode, on its repository as well.
+func (s Whitelist) Image(image string, jobName string, strict bool) (Entry, bool, error) {
 	imageSha, err := getImageSha(image)
 	if err != nil {
-		return false, err
+		return Entry{}, false, err
+	}
+
+	// an image which does not parse only matches on its digest
+	var name string
+	if reference, err := imageref.Parse(image); err == nil {
+		name = reference.Name()
 	}
 
-	for _, v := range s.AllowedImages {
-		sha256, _ := getImageSha(v)
-		if sha256 == imageSha {
-			return true, nil
+	for _, entry := range s.AllowedImages {
+		if entry.matchesImage(name, imageSha, strict) && entry.IsValidFor(jobName) {
+			return entry, true, nil
 		}
 	}
 
-	return false, nil
+	return Entry{}, false, nil
+}
+
+// matchesImage returns whether the entry matches the image of the given normalized name and
+// digest.
+func (e Entry) matchesImage(name string, imageSha string, strict bool) bool {
+	if len(e.Repository) != 0 && (len(name) == 0 || !glob.Match(e.Repository, name)) {
+		return false
+	}
+
+	if len(e.Value) == 0 {
+		return len(e.Repository) != 0
+	}
+
+	// entries with a repository may give the digest on its own
+	sha256, _ := getImageSha(e.Value)
+	if len(e.Repository) != 0 && strings.HasPrefix(e.Value, "sha256:") {
+		sha256 = e.Value
+	}
+
+	if sha256 != imageSha {
+		return false
+	}
+
+	if !strict || len(e.Repository) != 0 {
+		return true
+	}
+
+	reference, err := imageref.Parse(e.Value)
+	return err == nil && len(name) != 0 && reference.Name() == name
 }
 
 // Checks through the ScriptWhitelist to see if our script hs allowed.
 func (s Whitelist) ContainsScript(scriptSha string) bool {
-	for _, script := range s.AllowedScripts {
+	_, found := s.Script(scriptSha, "")
+	return found
+}
+
+// Script returns the entry allowing the script for the job. Expired entries and entries
+// restricted to other jobs are left out.
+func (s Whitelist) Script(scriptSha string, jobName string) (Entry, bool) {
+	for _, entry := range s.AllowedScripts {
 		// get script plaintext and hash
-		sha256, _ := getScriptSha(script)
-		if sha256 == scriptSha {
-			return true
+		sha256, _ := getScriptSha(entry.Value)
+		if sha256 == scriptSha && entry.IsValidFor(jobName) {
+			return entry, true
 		}
 	}
 
-	return false
+	return Entry{}, false
 }
 
+// ContainsJobName returns whether a script of the job is allowed (its entry has not expired and
+// is not restricted to other jobs) and the hash of the most recently approved script: the one
+// created last, or else the last one in the whitelist (project entries come after namespace ones).
 func (s Whitelist) ContainsJobName(JobName string) (bool, string) {
+	found := false
+	var latest Entry
+	var latestSha string
+
 	for _, script := range s.AllowedScripts {
-		whitelist_JobName, _ := getJobName(script)
-		if whitelist_JobName == JobName {
-			sha256, err := getScriptSha(script)
-			if err != nil {
-				continue
+		if !script.IsValidFor(JobName) {
+			continue
+		}
+
+		whitelist_JobName, _ := getJobName(script.Value)
+		if whitelist_JobName != JobName {
+			continue
+		}
+
+		sha256, err := getScriptSha(script.Value)
+		if err != nil {
+			continue
+		}
+
+		if !found || !script.CreatedAt.Before(latest.CreatedAt) {
+			found, latest, latestSha = true, script, sha256
+		}
+	}
+
+	return found, latestSha
+}
+
+// ScriptSource returns the approved script lines stored for an allowed script (<jobName>@sha256:<sha>).
+func (s Whitelist) ScriptSource(script string) ([]string, error) {
+	encoded, exists := s.ScriptSources[script]
+	if !exists {
+		return nil, ErrNoScriptSource
+	}
+
+	return DecodeScriptSource(encoded)
+}
+
+// EntryErrors returns the errors of the entries which are expired as they are invalid, so that
+// they can be reported.
+func (s Whitelist) EntryErrors() []error {
+	var errs []error
+	for _, entries := range [][]Entry{s.AllowedImages, s.AllowedScripts} {
+		for _, entry := range entries {
+			if err := entry.Err(); err != nil {
+				errs = append(errs, err)
 			}
-			return true, sha256
 		}
 	}
 
-	return false, ""
+	return errs
 }
 
 func (w *Whitelist) AddWhitelist(other Whitelist) {
 	w.AllowedImages = append(w.AllowedImages, other.AllowedImages...)
 	w.AllowedScripts = append(w.AllowedScripts, other.AllowedScripts...)
+
+	if len(other.ScriptSources) != 0 && w.ScriptSources == nil {
+		w.ScriptSources = make(map[string]string, len(other.ScriptSources))
+	}
+
+	for script, source := range other.ScriptSources {
+		w.ScriptSources[script] = source
+	}
+}
+
+// EncodeScriptSource compresses and encodes script lines the way they are stored in script_sources.
+// It is the equivalent of: printf '%s\n' "${lines[@]}" | gzip | base64 -w0
+func EncodeScriptSource(lines []string) (string, error) {
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	for _, line := range lines {
+		_, err := zw.Write([]byte(line + "\n"))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeScriptSource decodes and decompresses a script stored in script_sources into its lines.
+func DecodeScriptSource(encoded string) ([]string, error) {
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	plaintext, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	source := strings.TrimSuffix(string(plaintext), "\n")
+	if len(source) == 0 {
+		return []string{}, nil
+	}
+
+	return strings.Split(source, "\n"), nil
 }
 
 func getJobName(whitelistScript string) (string, error) {
@@ -1,8 +1,11 @@
 package whitelist
 
 import (
+	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestContainsImage(t *testing.T) {
@@ -16,8 +19,8 @@ func TestContainsImage(t *testing.T) {
 		{
 			name: "test contains image (image in whitelist)",
 			whitelist: Whitelist{
-				AllowedImages: []string{
-					"alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820",
+				AllowedImages: []Entry{
+					{Value: "alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820"},
 				},
 			},
 			image:    "alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820",
@@ -27,8 +30,8 @@ func TestContainsImage(t *testing.T) {
 		{
 			name: "test contains image (image not in whitelist)",
 			whitelist: Whitelist{
-				AllowedImages: []string{
-					"alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820",
+				AllowedImages: []Entry{
+					{Value: "alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820"},
 				},
 			},
 			image:    "alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883abc",
@@ -38,8 +41,8 @@ func TestContainsImage(t *testing.T) {
 		{
 			name: "test contains image invalid image (error)",
 			whitelist: Whitelist{
-				AllowedImages: []string{
-					"alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820",
+				AllowedImages: []Entry{
+					{Value: "alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820"},
 				},
 			},
 			image:    "alping:3.12.7@sha256:abc",
@@ -70,8 +73,8 @@ func TestContainsScript(t *testing.T) {
 		{
 			name: "test contains script (script in whitelist)",
 			whitelist: Whitelist{
-				AllowedScripts: []string{
-					"automatic_job@sha256:oNey8xJbYXyuxWr7Wla8tMexCTy7s82k6U1uwp4tFEY=",
+				AllowedScripts: []Entry{
+					{Value: "automatic_job@sha256:oNey8xJbYXyuxWr7Wla8tMexCTy7s82k6U1uwp4tFEY="},
 				},
 			},
 			scriptSha:   "sha256:oNey8xJbYXyuxWr7Wla8tMexCTy7s82k6U1uwp4tFEY=",
@@ -80,8 +83,8 @@ func TestContainsScript(t *testing.T) {
 		{
 			name: "test contains script (script not in whitelist)",
 			whitelist: Whitelist{
-				AllowedScripts: []string{
-					"automatic_job@sha256:oNey8xJbYXyuxWr7Wla8tMexCTy7s82k6U1uwp4tFEY=",
+				AllowedScripts: []Entry{
+					{Value: "automatic_job@sha256:oNey8xJbYXyuxWr7Wla8tMexCTy7s82k6U1uwp4tFEY="},
 				},
 			},
 			scriptSha:   "build_job@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=",
@@ -90,8 +93,8 @@ func TestContainsScript(t *testing.T) {
 		{
 			name: "test contains script (invalid script hash format)",
 			whitelist: Whitelist{
-				AllowedScripts: []string{
-					"automatic_job@sha256:oNey8xJbY=",
+				AllowedScripts: []Entry{
+					{Value: "automatic_job@sha256:oNey8xJbY="},
 				},
 			},
 			scriptSha:   "sha256:oNey8xJbYXyuxWr7Wla8tMexCTy7s82k6U1uwp4tFEY=",
@@ -118,8 +121,8 @@ func TestContainsJobName(t *testing.T) {
 		{
 			name: "whitelist contains job name",
 			whitelist: Whitelist{
-				AllowedScripts: []string{
-					"testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=",
+				AllowedScripts: []Entry{
+					{Value: "testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE="},
 				},
 			},
 			jobName:      "testJob",
@@ -129,8 +132,43 @@ func TestContainsJobName(t *testing.T) {
 		{
 			name: "whitelist does not contain job name",
 			whitelist: Whitelist{
-				AllowedScripts: []string{
-					"build_job@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=",
+				AllowedScripts: []Entry{
+					{Value: "build_job@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE="},
+				},
+			},
+			jobName:      "testJob",
+			foundJobName: false,
+			foundJobHash: "",
+		},
+		{
+			name: "several scripts of the job - last one",
+			whitelist: Whitelist{
+				AllowedScripts: []Entry{
+					{Value: "testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE="},
+					{Value: "testJob@sha256:2NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE="},
+				},
+			},
+			jobName:      "testJob",
+			foundJobName: true,
+			foundJobHash: "sha256:2NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=",
+		},
+		{
+			name: "several scripts of the job - created last",
+			whitelist: Whitelist{
+				AllowedScripts: []Entry{
+					{Value: "testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=", CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+					{Value: "testJob@sha256:2NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				},
+			},
+			jobName:      "testJob",
+			foundJobName: true,
+			foundJobHash: "sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=",
+		},
+		{
+			name: "script of the job restricted to other jobs",
+			whitelist: Whitelist{
+				AllowedScripts: []Entry{
+					{Value: "testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=", Jobs: []string{"deploy"}},
 				},
 			},
 			jobName:      "testJob",
@@ -167,33 +205,58 @@ func TestAddWhitelist(t *testing.T) {
 		{
 			name: "add whitelists allowed images and scripts",
 			whitelistOne: Whitelist{
-				AllowedImages: []string{
-					"imageOne",
-					"imageTwo",
+				AllowedImages: []Entry{
+					{Value: "imageOne"},
+					{Value: "imageTwo"},
+				},
+				AllowedScripts: []Entry{
+					{Value: "scriptOne"},
+					{Value: "scriptTwo"},
+				},
+			},
+			whitelistTwo: Whitelist{
+				AllowedImages: []Entry{
+					{Value: "imageThree"},
+				},
+				AllowedScripts: []Entry{
+					{Value: "scriptThree"},
+				},
+			},
+			expectedWhitelist: Whitelist{
+				AllowedImages: []Entry{
+					{Value: "imageOne"},
+					{Value: "imageTwo"},
+					{Value: "imageThree"},
 				},
-				AllowedScripts: []string{
-					"scriptOne",
-					"scriptTwo",
+				AllowedScripts: []Entry{
+					{Value: "scriptOne"},
+					{Value: "scriptTwo"},
+					{Value: "scriptThree"},
+				},
+			},
+		},
+		{
+			name: "add whitelists script sources",
+			whitelistOne: Whitelist{
+				AllowedScripts: []Entry{
+					{Value: "scriptOne"},
 				},
 			},
 			whitelistTwo: Whitelist{
-				AllowedImages: []string{
-					"imageThree",
+				AllowedScripts: []Entry{
+					{Value: "scriptTwo"},
 				},
-				AllowedScripts: []string{
-					"scriptThree",
+				ScriptSources: map[string]string{
+					"scriptTwo": "sourceTwo",
 				},
 			},
 			expectedWhitelist: Whitelist{
-				AllowedImages: []string{
-					"imageOne",
-					"imageTwo",
-					"imageThree",
+				AllowedScripts: []Entry{
+					{Value: "scriptOne"},
+					{Value: "scriptTwo"},
 				},
-				AllowedScripts: []string{
-					"scriptOne",
-					"scriptTwo",
-					"scriptThree",
+				ScriptSources: map[string]string{
+					"scriptTwo": "sourceTwo",
 				},
 			},
 		},
@@ -207,6 +270,62 @@ func TestAddWhitelist(t *testing.T) {
 	}
 }
 
+func TestScriptSource(t *testing.T) {
+	lines := []string{"echo hello", "", "make build"}
+	encoded, err := EncodeScriptSource(lines)
+	if err != nil {
+		t.Fatalf("unable to encode script source: %s", err.Error())
+	}
+
+	scriptSourceTests := []struct {
+		name          string
+		whitelist     Whitelist
+		script        string
+		expectedLines []string
+		err           error
+	}{
+		{
+			name: "script source stored",
+			whitelist: Whitelist{
+				ScriptSources: map[string]string{
+					"testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=": encoded,
+				},
+			},
+			script:        "testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=",
+			expectedLines: lines,
+			err:           nil,
+		},
+		{
+			name: "script source generated with gzip and base64",
+			whitelist: Whitelist{
+				ScriptSources: map[string]string{
+					"testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=": "H4sIAAAAAAAAA0tNzshXyEjNycnnyk3MTlVIKs3MSeECAF7iOnQWAAAA",
+				},
+			},
+			script:        "testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=",
+			expectedLines: []string{"echo hello", "make build"},
+			err:           nil,
+		},
+		{
+			name:      "no script source stored",
+			whitelist: Whitelist{},
+			script:    "testJob@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=",
+			err:       ErrNoScriptSource,
+		},
+	}
+
+	for testNum, test := range scriptSourceTests {
+		got, err := test.whitelist.ScriptSource(test.script)
+		if err != test.err {
+			t.Errorf("\n%d) unexpected error -\nexpected: (%+v)\ngot: (%+v)", testNum, test.err, err)
+		}
+
+		if !reflect.DeepEqual(got, test.expectedLines) {
+			t.Errorf("\n%d) unexpected script source -\nexpected: (%q)\ngot: (%q)", testNum, test.expectedLines, got)
+		}
+	}
+}
+
 func TestGetJobName(t *testing.T) {
 	getJobNameTests := []struct {
 		name            string
@@ -341,3 +460,294 @@ func TestGetImageSha(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalEntries(t *testing.T) {
+	data := `{
+		"allowed_images": [
+			"alpine:3.18@sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978",
+			{
+				"value": "postgres:15@sha256:a2282ad0db623c27f03bab803975c9e3942a24e974f07142d5d69b6b8eaaf9e2",
+				"approvedBy": "alice",
+				"reason": "integration test database",
+				"createdAt": "2024-01-15",
+				"expiresAt": "2024-07-15T12:00:00Z",
+				"jobs": ["integration_test"]
+			}
+		],
+		"allowed_scripts": [{"value": "build@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE="}]
+	}`
+
+	expectedWhitelist := Whitelist{
+		AllowedImages: []Entry{
+			{Value: "alpine:3.18@sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978"},
+			{
+				Value:      "postgres:15@sha256:a2282ad0db623c27f03bab803975c9e3942a24e974f07142d5d69b6b8eaaf9e2",
+				ApprovedBy: "alice",
+				Reason:     "integration test database",
+				CreatedAt:  time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC),
+				ExpiresAt:  time.Date(2024, time.July, 15, 12, 0, 0, 0, time.UTC),
+				Jobs:       []string{"integration_test"},
+			},
+		},
+		AllowedScripts: []Entry{
+			{Value: "build@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE="},
+		},
+	}
+
+	var whitelist Whitelist
+	if err := json.Unmarshal([]byte(data), &whitelist); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(whitelist, expectedWhitelist) {
+		t.Errorf("whitelists not equal -\nexpected: (%+v)\ngot: (%+v)", expectedWhitelist, whitelist)
+	}
+}
+
+func TestUnmarshalEntryErrors(t *testing.T) {
+	unmarshalEntryErrorTests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{
+			name: "entry without value",
+			data: `{"approvedBy": "alice"}`,
+			err:  ErrEntryNoValue,
+		},
+	}
+
+	for testNum, test := range unmarshalEntryErrorTests {
+		var entry Entry
+		err := json.Unmarshal([]byte(test.data), &entry)
+		if !errors.Is(err, test.err) {
+			t.Errorf("\n%d) unexpected error for %s -\nexpected: (%v)\ngot: (%v)", testNum, test.name, test.err, err)
+		}
+	}
+}
+
+func TestUnmarshalEntryInvalidDate(t *testing.T) {
+	data := `{
+		"allowed_scripts": [
+			{"value": "build@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=", "expiresAt": "15/07/2024"},
+			{"value": "test@sha256:Rdi1ZdrNqXDu5OJ6MJMKobhKlF2sbbFsoR3KuSb3OVM=", "createdAt": "2024-01-15"}
+		]
+	}`
+
+	var whitelist Whitelist
+	if err := json.Unmarshal([]byte(data), &whitelist); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if whitelist.ContainsScript("sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=") {
+		t.Errorf("expected the script with an invalid date to be absent")
+	}
+
+	if !whitelist.ContainsScript("sha256:Rdi1ZdrNqXDu5OJ6MJMKobhKlF2sbbFsoR3KuSb3OVM=") {
+		t.Errorf("expected the other scripts of the whitelist to be allowed")
+	}
+
+	errs := whitelist.EntryErrors()
+	if len(errs) != 1 || !errors.Is(errs[0], ErrInvalidEntryDate) {
+		t.Errorf("unexpected entry errors -\nexpected: (%v)\ngot: (%v)", ErrInvalidEntryDate, errs)
+	}
+}
+
+func TestUnmarshalEntryNotApproved(t *testing.T) {
+	data := `{
+		"allowed_images": [
+			{"repository": "registry.internal/base/*"},
+			"alpine:3.18@sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978"
+		]
+	}`
+
+	var whitelist Whitelist
+	if err := json.Unmarshal([]byte(data), &whitelist); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, found, _ := whitelist.Image("registry.internal/base/alpine@sha256:1f1bd4adf5dabf173b235ba373faef55f3ad53394791d1473763bf5a2181780d", "build", false)
+	if found {
+		t.Errorf("expected the image of the entry without approver to be absent")
+	}
+
+	_, found, _ = whitelist.Image("alpine:3.18@sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978", "build", false)
+	if !found {
+		t.Errorf("expected the other images of the whitelist to be allowed")
+	}
+
+	errs := whitelist.EntryErrors()
+	if len(errs) != 1 || !errors.Is(errs[0], ErrEntryNotApproved) {
+		t.Errorf("unexpected entry errors -\nexpected: (%v)\ngot: (%v)", ErrEntryNotApproved, errs)
+	}
+}
+
+func TestEntryIsValidFor(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	entryIsValidForTests := []struct {
+		name    string
+		entry   Entry
+		jobName string
+		valid   bool
+	}{
+		{
+			name:    "plain entry",
+			entry:   Entry{Value: "build@sha256:abc"},
+			jobName: "build",
+			valid:   true,
+		},
+		{
+			name:    "entry not expired yet",
+			entry:   Entry{Value: "build@sha256:abc", ExpiresAt: time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)},
+			jobName: "build",
+			valid:   true,
+		},
+		{
+			name:    "expired entry",
+			entry:   Entry{Value: "build@sha256:abc", ExpiresAt: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+			jobName: "build",
+			valid:   false,
+		},
+		{
+			name:    "entry of the job",
+			entry:   Entry{Value: "alpine@sha256:abc", Jobs: []string{"build", "test"}},
+			jobName: "test",
+			valid:   true,
+		},
+		{
+			name:    "entry of other jobs",
+			entry:   Entry{Value: "alpine@sha256:abc", Jobs: []string{"build", "test"}},
+			jobName: "deploy",
+			valid:   false,
+		},
+	}
+
+	for testNum, test := range entryIsValidForTests {
+		valid := test.entry.IsValidFor(test.jobName)
+		if valid != test.valid {
+			t.Errorf("\n%d) unexpected valid for %s -\nexpected: (%t)\ngot: (%t)", testNum, test.name, test.valid, valid)
+		}
+	}
+}
+
+func TestExpiredEntriesAreAbsent(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	expired := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	whitelist := Whitelist{
+		AllowedImages: []Entry{
+			{Value: "alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820", ExpiresAt: expired},
+		},
+		AllowedScripts: []Entry{
+			{Value: "build@sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=", ExpiresAt: expired},
+		},
+	}
+
+	found, err := whitelist.ContainsImage("alpine:3.12.7@sha256:a9c28c813336ece5bb98b36af5b66209ed777a394f4f856c6e62267790883820")
+	if found || err != nil {
+		t.Errorf("expected the expired image to be absent, got (%t, %v)", found, err)
+	}
+
+	if whitelist.ContainsScript("sha256:1NmXdCi0PhRNMKX91bypxJKJhzB1nUQqtRowPbgpqqE=") {
+		t.Errorf("expected the expired script to be absent")
+	}
+
+	if found, _ := whitelist.ContainsJobName("build"); found {
+		t.Errorf("expected the expired script of the job to be absent")
+	}
+}
+
+func TestEntryApproval(t *testing.T) {
+	expiresAt := time.Date(2024, time.July, 15, 0, 0, 0, 0, time.UTC)
+	entryApprovalTests := []struct {
+		entry    Entry
+		approval string
+	}{
+		{entry: Entry{Value: "build@sha256:abc"}, approval: ""},
+		{entry: Entry{Value: "build@sha256:abc", ApprovedBy: "alice"}, approval: "approved by alice"},
+		{entry: Entry{Value: "build@sha256:abc", Reason: "release build"}, approval: "release build"},
+		{entry: Entry{Value: "build@sha256:abc", ApprovedBy: "alice", Reason: "release build", ExpiresAt: expiresAt}, approval: "approved by alice: release build, expires on 2024-07-15"},
+		{entry: Entry{Value: "build@sha256:abc", ExpiresAt: expiresAt}, approval: ""},
+	}
+
+	for testNum, test := range entryApprovalTests {
+		approval := test.entry.Approval()
+		if approval != test.approval {
+			t.Errorf("\n%d) unexpected approval -\nexpected: (%s)\ngot: (%s)", testNum, test.approval, approval)
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	alpineSha := "sha256:eece025e432126ce23f223450a0326fbebde39cdf496a85d8c016293fc851978"
+	whitelist := Whitelist{
+		AllowedImages: []Entry{
+			{Value: "alpine:3.18@" + alpineSha},
+			{Value: "sha256:a2282ad0db623c27f03bab803975c9e3942a24e974f07142d5d69b6b8eaaf9e2", Repository: "registry.internal/db/*"},
+			{Repository: "registry.internal/base/**", ApprovedBy: "alice"},
+		},
+	}
+
+	imageTests := []struct {
+		name     string
+		image    string
+		strict   bool
+		expected int
+	}{
+		{
+			name:     "same digest under another repository",
+			image:    "evil/alpine@" + alpineSha,
+			expected: 0,
+		},
+		{
+			name:     "same digest under another repository - strict",
+			image:    "evil/alpine@" + alpineSha,
+			strict:   true,
+			expected: -1,
+		},
+		{
+			name:     "repository normalized - strict",
+			image:    "docker.io/library/alpine:latest@" + alpineSha,
+			strict:   true,
+			expected: 0,
+		},
+		{
+			name:     "repository and digest match",
+			image:    "registry.internal/db/postgres:15@sha256:a2282ad0db623c27f03bab803975c9e3942a24e974f07142d5d69b6b8eaaf9e2",
+			expected: 1,
+		},
+		{
+			name:     "digest matches outside of the repository",
+			image:    "docker.io/library/postgres:15@sha256:a2282ad0db623c27f03bab803975c9e3942a24e974f07142d5d69b6b8eaaf9e2",
+			expected: -1,
+		},
+		{
+			name:     "any digest of the repository - strict",
+			image:    "registry.internal/base/team/alpine@sha256:1f1bd4adf5dabf173b235ba373faef55f3ad53394791d1473763bf5a2181780d",
+			strict:   true,
+			expected: 2,
+		},
+		{
+			name:     "unknown digest",
+			image:    "registry.internal/other/alpine@sha256:1f1bd4adf5dabf173b235ba373faef55f3ad53394791d1473763bf5a2181780d",
+			expected: -1,
+		},
+	}
+
+	for testNum, test := range imageTests {
+		entry, found, err := whitelist.Image(test.image, "build", test.strict)
+		if err != nil {
+			t.Errorf("\n%d) unexpected error for %s: %v", testNum, test.name, err)
+			continue
+		}
+
+		if test.expected == -1 && found {
+			t.Errorf("\n%d) unexpected entry for %s: (%+v)", testNum, test.name, entry)
+		} else if test.expected != -1 && (!found || !reflect.DeepEqual(entry, whitelist.AllowedImages[test.expected])) {
+			t.Errorf("\n%d) unexpected entry for %s -\nexpected: (%+v)\ngot: (%+v, %t)", testNum, test.name, whitelist.AllowedImages[test.expected], entry, found)
+		}
+	}
+}
@@ -23,6 +23,17 @@ if [[ "${runTask}" == "all" || "${runTask}" == "checkparser" ]]; then
     fi
 fi
 
+# run checkrunner Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "checkrunner" ]]; then
+    echo -e "${GREEN}Testing Check Runner${NC}"
+    if go test "${currentDir}/../../pkg/checkrunner"; then
+        echo -e "${BLUE}Check Runner Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Check Runner Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
 # run checks tests
 if [[ "${runTask}" == "all" || "${runTask}" == "checks" ]]; then
     echo -e "${GREEN}Testing Checks Client${NC}"
@@ -78,6 +89,160 @@ if [[ "${runTask}" == "all" || "${runTask}" == "datetime" ]]; then
     fi
 fi
 
+# run refRestrictionCheck Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "refrestriction" ]]; then
+    echo -e "${GREEN}Testing Ref Restriction Check Client${NC}"
+    if go test "${currentDir}/../../pkg/checks/refrestriction"; then
+        echo -e "${BLUE}Ref Restriction Check Client Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Ref Restriction Check Client Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run userAllowlist Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "userallowlist" ]]; then
+    echo -e "${GREEN}Testing User Allowlist Check Client${NC}"
+    if go test "${currentDir}/../../pkg/checks/userallowlist"; then
+        echo -e "${BLUE}User Allowlist Check Client Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}User Allowlist Check Client Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run imageSource Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "imagesource" ]]; then
+    echo -e "${GREEN}Testing Image Source Check Client${NC}"
+    if go test "${currentDir}/../../pkg/checks/imagesource"; then
+        echo -e "${BLUE}Image Source Check Client Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Image Source Check Client Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run scriptLint Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "scriptlint" ]]; then
+    echo -e "${GREEN}Testing Script Lint Check Client${NC}"
+    if go test "${currentDir}/../../pkg/checks/scriptlint"; then
+        echo -e "${BLUE}Script Lint Check Client Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Script Lint Check Client Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run commitSignature Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "commitsignature" ]]; then
+    echo -e "${GREEN}Testing Commit Signature Check Client${NC}"
+    if go test "${currentDir}/../../pkg/checks/commitsignature"; then
+        echo -e "${BLUE}Commit Signature Check Client Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Commit Signature Check Client Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run triggerSource Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "triggersource" ]]; then
+    echo -e "${GREEN}Testing Trigger Source Check Client${NC}"
+    if go test "${currentDir}/../../pkg/checks/triggersource"; then
+        echo -e "${BLUE}Trigger Source Check Client Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Trigger Source Check Client Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run freezeWindow Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "freezewindow" ]]; then
+    echo -e "${GREEN}Testing Freeze Window Check${NC}"
+    if go test "${currentDir}/../../pkg/checks/freezewindow"; then
+        echo -e "${BLUE}Freeze Window Check Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Freeze Window Check Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run imageSignature Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "imagesignature" ]]; then
+    echo -e "${GREEN}Testing Image Signature Check${NC}"
+    if go test "${currentDir}/../../pkg/checks/imagesignature"; then
+        echo -e "${BLUE}Image Signature Check Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Image Signature Check Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run composite Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "composite" ]]; then
+    echo -e "${GREEN}Testing Composite Check${NC}"
+    if go test "${currentDir}/../../pkg/checks/composite"; then
+        echo -e "${BLUE}Composite Check Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Composite Check Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run actionPinning Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "actionpinning" ]]; then
+    echo -e "${GREEN}Testing Action Pinning Check${NC}"
+    if go test "${currentDir}/../../pkg/checks/actionpinning"; then
+        echo -e "${BLUE}Action Pinning Check Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Action Pinning Check Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run secretScan Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "secretscan" ]]; then
+    echo -e "${GREEN}Testing Secret Scan Check${NC}"
+    if go test "${currentDir}/../../pkg/checks/secretscan"; then
+        echo -e "${BLUE}Secret Scan Check Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Secret Scan Check Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run mrApprovals Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "mrapprovals" ]]; then
+    echo -e "${GREEN}Testing Merge Request Approvals Check${NC}"
+    if go test "${currentDir}/../../pkg/checks/mrapprovals"; then
+        echo -e "${BLUE}Merge Request Approvals Check Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Merge Request Approvals Check Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run runnerConstraint Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "runnerconstraint" ]]; then
+    echo -e "${GREEN}Testing Runner Constraint Check${NC}"
+    if go test "${currentDir}/../../pkg/checks/runnerconstraint"; then
+        echo -e "${BLUE}Runner Constraint Check Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Runner Constraint Check Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run containerRuntime Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "containerruntime" ]]; then
+    echo -e "${GREEN}Testing Container Runtime Check${NC}"
+    if go test "${currentDir}/../../pkg/checks/containerruntime"; then
+        echo -e "${BLUE}Container Runtime Check Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Container Runtime Check Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
 # run config Tests
 if [[ "${runTask}" == "all" || "${runTask}" == "config" ]]; then
     echo -e "${GREEN}Testing Config Client${NC}"
@@ -133,6 +298,94 @@ if [[ "${runTask}" == "all" || "${runTask}" == "gitlabcleanup" ]]; then
     fi
 fi
 
+# run glob Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "glob" ]]; then
+    echo -e "${GREEN}Testing Glob${NC}"
+    if go test "${currentDir}/../../pkg/glob"; then
+        echo -e "${BLUE}Glob Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Glob Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run imageref Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "imageref" ]]; then
+    echo -e "${GREEN}Testing Image Reference${NC}"
+    if go test "${currentDir}/../../pkg/imageref"; then
+        echo -e "${BLUE}Image Reference Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Image Reference Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run diff Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "diff" ]]; then
+    echo -e "${GREEN}Testing Diff${NC}"
+    if go test "${currentDir}/../../pkg/diff"; then
+        echo -e "${BLUE}Diff Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Diff Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run cron Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "cron" ]]; then
+    echo -e "${GREEN}Testing Cron${NC}"
+    if go test "${currentDir}/../../pkg/cron"; then
+        echo -e "${BLUE}Cron Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Cron Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run ociregistry Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "ociregistry" ]]; then
+    echo -e "${GREEN}Testing OCI Registry${NC}"
+    if go test "${currentDir}/../../pkg/ociregistry"; then
+        echo -e "${BLUE}OCI Registry Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}OCI Registry Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run gitlabapi Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "gitlabapi" ]]; then
+    echo -e "${GREEN}Testing GitLab API${NC}"
+    if go test "${currentDir}/../../pkg/gitlabapi"; then
+        echo -e "${BLUE}GitLab API Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}GitLab API Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run jobtoken Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "jobtoken" ]]; then
+    echo -e "${GREEN}Testing Job Token${NC}"
+    if go test "${currentDir}/../../pkg/jobtoken"; then
+        echo -e "${BLUE}Job Token Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}Job Token Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
+# run sshsig Tests
+if [[ "${runTask}" == "all" || "${runTask}" == "sshsig" ]]; then
+    echo -e "${GREEN}Testing SSH Signature${NC}"
+    if go test "${currentDir}/../../pkg/sshsig"; then
+        echo -e "${BLUE}SSH Signature Tests PASSED${NC}\n"
+    else
+        echo -e "${RED}SSH Signature Tests FAILED${NC}\n"
+        exit 1
+    fi
+fi
+
 # run whitelist tests
 if [[ "${runTask}" == "all" || "${runTask}" == "whitelist" ]]; then
     echo -e "${GREEN}Testing Whitelist${NC}"